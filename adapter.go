@@ -1,13 +1,102 @@
 package ipp
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"net/http"
+	"time"
 )
 
+// DefaultDialTimeout is the timeout for establishing connections to the server
+const DefaultDialTimeout = 30 * time.Second
+
 type Adapter interface {
 	SendRequest(url string, req *Request, additionalResponseData io.Writer) (*Response, error)
 	SendRequestContext(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error)
 	GetHttpUri(namespace string, object interface{}) string
 	TestConnection() error
 }
+
+// connectionTester is implemented by adapters which can test the connection with a context. the wrapping adapters
+// pass it on to the wrapped adapter
+type connectionTester interface {
+	TestConnectionContext(ctx context.Context) error
+}
+
+// testConnection tests the connection with the context if the adapter supports it, otherwise TestConnection is used
+func testConnection(ctx context.Context, adapter Adapter) error {
+	if tester, ok := adapter.(connectionTester); ok {
+		return tester.TestConnectionContext(ctx)
+	}
+	return adapter.TestConnection()
+}
+
+// Downloader is implemented by adapters which can fetch plain http resources, e.g. the ppd file cups redirects to
+// with a cups-see-other status. the wrapping adapters pass downloads on to the wrapped adapter
+type Downloader interface {
+	Download(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// download fetches the url through the adapter if it is a Downloader, otherwise the default http client is used
+func download(ctx context.Context, adapter Adapter, url string) (io.ReadCloser, error) {
+	if downloader, ok := adapter.(Downloader); ok {
+		return downloader.Download(ctx, url)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return doDownload(http.DefaultClient, httpReq)
+}
+
+func doDownload(client *http.Client, httpReq *http.Request) (io.ReadCloser, error) {
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		return nil, newHTTPError(httpResp)
+	}
+
+	return httpResp.Body, nil
+}
+
+// encodeRequestBody encodes the request and appends the optional document. the returned size is -1 if the document
+// size is unknown. reading the document stops once the context is done, so a canceled request aborts the upload
+func encodeRequestBody(ctx context.Context, req *Request) (io.Reader, int, error) {
+	payload, err := req.Encode()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if req.File == nil {
+		return countSentBytes(ctx, bytes.NewBuffer(payload)), len(payload), nil
+	}
+
+	size := len(payload)
+	// documents of unknown size are sent chunked
+	if req.FileSize == -1 {
+		size = -1
+	} else {
+		size += req.FileSize
+	}
+
+	return countSentBytes(ctx, io.MultiReader(bytes.NewBuffer(payload), &contextReader{ctx: ctx, reader: req.File})), size, nil
+}
+
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
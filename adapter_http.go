@@ -8,7 +8,9 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 type HttpAdapter struct {
@@ -51,26 +53,20 @@ func (a *HttpAdapter) SendRequest(url string, req *Request, additionalData io.Wr
 }
 
 func (a *HttpAdapter) SendRequestContext(ctx context.Context, url string, req *Request, additionalData io.Writer) (*Response, error) {
-	payload, err := req.Encode()
+	body, size, err := encodeRequestBody(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	size := len(payload)
-	var body io.Reader
-	if req.File != nil && req.FileSize != -1 {
-		size += req.FileSize
-		body = io.MultiReader(bytes.NewBuffer(payload), req.File)
-	} else {
-		body = bytes.NewBuffer(payload)
-	}
-
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq.Header.Set("Content-Length", strconv.Itoa(size))
+	if size != -1 {
+		httpReq.ContentLength = int64(size)
+		httpReq.Header.Set("Content-Length", strconv.Itoa(size))
+	}
 	httpReq.Header.Set("Content-Type", ContentTypeIPP)
 
 	if a.username != "" && a.password != "" {
@@ -84,9 +80,7 @@ func (a *HttpAdapter) SendRequestContext(ctx context.Context, url string, req *R
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != 200 {
-		return nil, HTTPError{
-			Code: httpResp.StatusCode,
-		}
+		return nil, newHTTPError(httpResp)
 	}
 
 	// buffer response to avoid read issues
@@ -94,7 +88,7 @@ func (a *HttpAdapter) SendRequestContext(ctx context.Context, url string, req *R
 	if httpResp.ContentLength > 0 {
 		buf.Grow(int(httpResp.ContentLength))
 	}
-	if _, err := io.Copy(buf, httpResp.Body); err != nil {
+	if _, err := io.Copy(buf, countReceivedBytes(ctx, httpResp.Body)); err != nil {
 		return nil, fmt.Errorf("unable to buffer response: %w", err)
 	}
 
@@ -103,13 +97,45 @@ func (a *HttpAdapter) SendRequestContext(ctx context.Context, url string, req *R
 		return nil, err
 	}
 
-	if err = ippResp.CheckForErrors(); err != nil {
+	if err = checkResponse(ippResp); err != nil {
 		return nil, fmt.Errorf("received error IPP response: %w", err)
 	}
 
 	return ippResp, nil
 }
 
+// Download fetches a http resource, the credentials of the adapter are only sent to the server of the adapter
+func (a *HttpAdapter) Download(ctx context.Context, url string) (io.ReadCloser, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.username != "" && a.password != "" && a.isOwnUrl(httpReq.URL) {
+		httpReq.SetBasicAuth(a.username, a.password)
+	}
+
+	return doDownload(a.client, httpReq)
+}
+
+// isOwnUrl checks if scheme, host and port of the url match the server of the adapter
+func (a *HttpAdapter) isOwnUrl(u *url.URL) bool {
+	own, err := url.Parse(a.GetHttpUri("", nil))
+	if err != nil {
+		return false
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	return u.Scheme == own.Scheme && strings.EqualFold(u.Hostname(), own.Hostname()) && port == own.Port()
+}
+
 func (a *HttpAdapter) GetHttpUri(namespace string, object interface{}) string {
 	proto := "http"
 	if a.useTLS {
@@ -130,7 +156,13 @@ func (a *HttpAdapter) GetHttpUri(namespace string, object interface{}) string {
 }
 
 func (a *HttpAdapter) TestConnection() error {
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", a.host, a.port))
+	return a.TestConnectionContext(context.Background())
+}
+
+// TestConnectionContext tests if a tcp connection to the server is possible
+func (a *HttpAdapter) TestConnectionContext(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: DefaultDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(a.host, strconv.Itoa(a.port)))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,80 @@
+package ipp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHttpAdapter(t *testing.T, handler http.HandlerFunc) *HttpAdapter {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.Nil(t, err)
+	portNumber, err := strconv.Atoi(port)
+	assert.Nil(t, err)
+
+	return NewHttpAdapter(host, portNumber, "", "", false)
+}
+
+func TestHttpAdapter_SendRequestContext(t *testing.T) {
+	adapter := newTestHttpAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+		req, err := NewRequestDecoder(r.Body).Decode(io.Discard)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if req.Operation == OperationGetJobs {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		payload, _ := NewResponse(StatusOk, req.RequestId).Encode()
+		w.Header().Set("Content-Type", ContentTypeIPP)
+		_, _ = w.Write(payload)
+	})
+
+	client := NewIPPClientWithAdapter("user", adapter)
+	client.SetOperationTimeout(OperationGetJobs, 50*time.Millisecond)
+
+	assert.Nil(t, client.TestConnectionContext(context.Background()))
+
+	_, err := client.GetPrinterAttributes("test", nil)
+	assert.NotNil(t, err, "empty printer attributes must be reported")
+	assert.False(t, errors.Is(err, context.DeadlineExceeded))
+
+	_, err = client.GetJobs("", "", JobStateFilterAll, false, 0, 0, nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+}
+
+func TestHttpAdapter_Download(t *testing.T) {
+	var authorized []bool
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _, ok := r.BasicAuth()
+		authorized = append(authorized, ok)
+		_, _ = io.WriteString(w, "*PPD-Adobe")
+	}
+
+	own := newTestHttpAdapter(t, handler)
+	adapter := NewHttpAdapter(own.host, own.port, "user", "secret", false)
+	other := newTestHttpAdapter(t, handler)
+
+	for _, uri := range []string{own.GetHttpUri("printers", "test.ppd"), other.GetHttpUri("printers", "test.ppd")} {
+		body, err := adapter.Download(context.Background(), uri)
+		assert.NoError(t, err)
+		if err == nil {
+			body.Close()
+		}
+	}
+
+	assert.Equal(t, []bool{true, false}, authorized)
+}
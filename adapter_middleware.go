@@ -0,0 +1,121 @@
+package ipp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// RoundTripper sends a single ipp request and returns its response
+type RoundTripper interface {
+	RoundTrip(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error)
+}
+
+// RoundTripperFunc is an adapter to use ordinary functions as RoundTripper
+type RoundTripperFunc func(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error)
+
+// RoundTrip calls f(ctx, url, req, additionalResponseData)
+func (f RoundTripperFunc) RoundTrip(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	return f(ctx, url, req, additionalResponseData)
+}
+
+// Middleware wraps a RoundTripper to intercept requests and responses
+type Middleware func(next RoundTripper) RoundTripper
+
+// MiddlewareAdapter wraps an Adapter with a chain of middlewares
+type MiddlewareAdapter struct {
+	adapter Adapter
+	chain   RoundTripper
+}
+
+// NewMiddlewareAdapter creates a new adapter which passes every request through the middlewares before it is sent by
+// the given adapter. the first middleware is the outermost one
+func NewMiddlewareAdapter(adapter Adapter, middlewares ...Middleware) *MiddlewareAdapter {
+	var chain RoundTripper = RoundTripperFunc(adapter.SendRequestContext)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		chain = middlewares[i](chain)
+	}
+
+	return &MiddlewareAdapter{
+		adapter: adapter,
+		chain:   chain,
+	}
+}
+
+func (a *MiddlewareAdapter) SendRequest(url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	return a.SendRequestContext(context.Background(), url, req, additionalResponseData)
+}
+
+func (a *MiddlewareAdapter) SendRequestContext(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	return a.chain.RoundTrip(ctx, url, req, additionalResponseData)
+}
+
+func (a *MiddlewareAdapter) Download(ctx context.Context, url string) (io.ReadCloser, error) {
+	return download(ctx, a.adapter, url)
+}
+
+func (a *MiddlewareAdapter) GetHttpUri(namespace string, object interface{}) string {
+	return a.adapter.GetHttpUri(namespace, object)
+}
+
+func (a *MiddlewareAdapter) TestConnection() error {
+	return a.adapter.TestConnection()
+}
+
+func (a *MiddlewareAdapter) TestConnectionContext(ctx context.Context) error {
+	return testConnection(ctx, a.adapter)
+}
+
+// LoggingMiddleware logs every request and its outcome to the given logger
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(ctx, url, req, additionalResponseData)
+
+			attrs := []any{
+				slog.String("operation", OperationName(req.Operation)),
+				slog.String("url", url),
+				slog.Int("request-id", int(req.RequestId)),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			if err != nil {
+				logger.ErrorContext(ctx, "ipp request failed", append(attrs, slog.Any("error", err))...)
+				return resp, err
+			}
+
+			logger.DebugContext(ctx, "ipp request", append(attrs, slog.Int("status-code", int(resp.StatusCode)))...)
+			return resp, nil
+		})
+	}
+}
+
+// TimingMiddleware reports the duration of every request to observe
+func TimingMiddleware(observe func(operation int16, url string, duration time.Duration, err error)) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(ctx, url, req, additionalResponseData)
+			observe(req.Operation, url, time.Since(start), err)
+			return resp, err
+		})
+	}
+}
+
+// DefaultAttributesMiddleware sets operation attributes which are missing or empty in a request.
+// the IPPClient always sets requesting-user-name to its username, so a default for it only applies to clients created
+// with an empty username
+func DefaultAttributesMiddleware(attributes map[string]any) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+			for name, value := range attributes {
+				if current, ok := req.OperationAttributes[name]; !ok || current == nil || current == "" {
+					req.OperationAttributes[name] = value
+				}
+			}
+			return next.RoundTrip(ctx, url, req, additionalResponseData)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareAdapter_Order(t *testing.T) {
+	var calls []string
+
+	record := func(name string) Middleware {
+		return func(next RoundTripper) RoundTripper {
+			return RoundTripperFunc(func(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+				calls = append(calls, name)
+				return next.RoundTrip(ctx, url, req, additionalResponseData)
+			})
+		}
+	}
+
+	adapter := NewMiddlewareAdapter(&retryTestAdapter{}, record("outer"), record("inner"), DefaultAttributesMiddleware(map[string]any{
+		AttributeRequestingUserName: "default-user",
+		AttributeJobName:            "default-job",
+	}))
+
+	req := NewRequest(OperationPrintJob, 1)
+	req.OperationAttributes[AttributeJobName] = "my-job"
+
+	_, err := adapter.SendRequest("", req, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+	assert.Equal(t, "default-user", req.OperationAttributes[AttributeRequestingUserName])
+	assert.Equal(t, "my-job", req.OperationAttributes[AttributeJobName])
+}
+
+func TestMiddlewareAdapter_DefaultAttributes(t *testing.T) {
+	adapter := NewMiddlewareAdapter(&retryTestAdapter{}, DefaultAttributesMiddleware(map[string]any{
+		AttributeRequestingUserName: "default-user",
+	}))
+
+	req := NewRequest(OperationGetJobs, 1)
+	_, err := NewIPPClientWithAdapter("", adapter).SendRequest("", req, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "default-user", req.OperationAttributes[AttributeRequestingUserName])
+
+	req = NewRequest(OperationGetJobs, 1)
+	_, err = NewIPPClientWithAdapter("user", adapter).SendRequest("", req, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "user", req.OperationAttributes[AttributeRequestingUserName])
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	adapter := NewMiddlewareAdapter(&retryTestAdapter{errs: []error{IPPError{Status: StatusErrorNotFound}}}, LoggingMiddleware(logger))
+
+	_, err := adapter.SendRequest("ipp://localhost/printers/test", NewRequest(OperationGetJobs, 7), nil)
+	assert.NotNil(t, err)
+	_, err = adapter.SendRequest("ipp://localhost/printers/test", NewRequest(OperationGetJobs, 8), nil)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	records := make([]map[string]any, len(lines))
+	for i, line := range lines {
+		assert.Nil(t, json.Unmarshal([]byte(line), &records[i]))
+	}
+
+	assert.Equal(t, "ERROR", records[0]["level"])
+	assert.Equal(t, "ipp request failed", records[0]["msg"])
+	assert.Equal(t, "Get-Jobs", records[0]["operation"])
+	assert.Equal(t, "ipp://localhost/printers/test", records[0]["url"])
+	assert.Equal(t, float64(7), records[0]["request-id"])
+	assert.Equal(t, IPPError{Status: StatusErrorNotFound}.Error(), records[0]["error"])
+	assert.Contains(t, records[0], "duration")
+
+	assert.Equal(t, "DEBUG", records[1]["level"])
+	assert.Equal(t, "ipp request", records[1]["msg"])
+	assert.Equal(t, float64(8), records[1]["request-id"])
+	assert.Equal(t, float64(StatusOk), records[1]["status-code"])
+	assert.NotContains(t, records[1], "error")
+}
+
+func TestTimingMiddleware(t *testing.T) {
+	type observation struct {
+		operation int16
+		url       string
+		duration  time.Duration
+		err       error
+	}
+	var observed []observation
+
+	delay := func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+			time.Sleep(10 * time.Millisecond)
+			return next.RoundTrip(ctx, url, req, additionalResponseData)
+		})
+	}
+	timing := TimingMiddleware(func(operation int16, url string, duration time.Duration, err error) {
+		observed = append(observed, observation{operation, url, duration, err})
+	})
+	adapter := NewMiddlewareAdapter(&retryTestAdapter{errs: []error{IPPError{Status: StatusErrorBusy}}}, timing, delay)
+
+	_, err := adapter.SendRequest("ipp://localhost/printers/test", NewRequest(OperationPrintJob, 1), nil)
+	assert.NotNil(t, err)
+	_, err = adapter.SendRequest("ipp://localhost/printers/test", NewRequest(OperationGetJobs, 2), nil)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(observed))
+	assert.Equal(t, OperationPrintJob, observed[0].operation)
+	assert.Equal(t, "ipp://localhost/printers/test", observed[0].url)
+	assert.Equal(t, IPPError{Status: StatusErrorBusy}, observed[0].err)
+	assert.Equal(t, OperationGetJobs, observed[1].operation)
+	assert.Nil(t, observed[1].err)
+	for _, o := range observed {
+		assert.GreaterOrEqual(t, o.duration, 10*time.Millisecond)
+	}
+}
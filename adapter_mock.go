@@ -0,0 +1,711 @@
+package ipp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockJob is a job kept by the MockAdapter
+type MockJob struct {
+	ID         int
+	Printer    string
+	Name       string
+	User       string
+	State      int8
+	HoldUntil  string
+	Attributes map[string]any
+	Documents  [][]byte
+	// DocumentFormats are the document-format values of the documents
+	DocumentFormats []string
+	// StateReasons are the job-state-reasons of the job, jobs of printers with an auth-info-required attribute are
+	// held with cups-held-for-authentication until AuthInfo is sent
+	StateReasons []string
+	AuthInfo     []string
+}
+
+// MockAdapter is an in-memory Adapter which simulates a cups server with its printers and jobs.
+// errors and delays can be injected per operation to test error handling without network access
+type MockAdapter struct {
+	mu sync.Mutex
+
+	printers  map[string]Attributes
+	classes   map[string][]string
+	jobs      map[int]*MockJob
+	devices   []Attributes
+	ppds      []Attributes
+	nextJobID int
+
+	defaultPrinter string
+	printerPPDs    map[string][]byte
+
+	errors   map[int16][]error
+	delays   map[int16]time.Duration
+	requests []*Request
+}
+
+// NewMockAdapter creates a new mock adapter without any printers
+func NewMockAdapter() *MockAdapter {
+	return &MockAdapter{
+		printers:    make(map[string]Attributes),
+		classes:     make(map[string][]string),
+		jobs:        make(map[int]*MockJob),
+		printerPPDs: make(map[string][]byte),
+		nextJobID:   1,
+		errors:      make(map[int16][]error),
+		delays:      make(map[int16]time.Duration),
+	}
+}
+
+// AddPrinter adds a printer with the given attributes, default values are set for missing state attributes
+func (a *MockAdapter) AddPrinter(name string, attributes Attributes) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.addPrinter(name, attributes)
+}
+
+// AddDevice adds a device returned by CUPS-Get-Devices
+func (a *MockAdapter) AddDevice(attributes Attributes) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.devices = append(a.devices, attributes)
+}
+
+// AddPPD adds a ppd returned by CUPS-Get-PPDs
+func (a *MockAdapter) AddPPD(attributes Attributes) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.ppds = append(a.ppds, attributes)
+}
+
+// InjectError lets the next request of the given operation fail with err. IPPError values are wrapped like the
+// errors returned by the HttpAdapter. multiple errors for the same operation are returned in order
+func (a *MockAdapter) InjectError(operation int16, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.errors[operation] = append(a.errors[operation], err)
+}
+
+// InjectDelay delays every request of the given operation, a canceled context aborts the request
+func (a *MockAdapter) InjectDelay(operation int16, delay time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.delays[operation] = delay
+}
+
+// Job returns a copy of a job
+func (a *MockAdapter) Job(jobID int) (MockJob, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	job, ok := a.jobs[jobID]
+	if !ok {
+		return MockJob{}, false
+	}
+	return *job, true
+}
+
+// PrinterPPD returns the ppd file uploaded for a printer with CUPS-Add-Modify-Printer
+func (a *MockAdapter) PrinterPPD(name string) ([]byte, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ppd, ok := a.printerPPDs[name]
+	return ppd, ok
+}
+
+// SetPrinterPPD sets the ppd file of a printer returned by CUPS-Get-PPD
+func (a *MockAdapter) SetPrinterPPD(name string, ppd []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.printerPPDs[name] = ppd
+}
+
+// SetJobState changes the state of a job, e.g. to simulate a completed job
+func (a *MockAdapter) SetJobState(jobID int, state int8) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if job, ok := a.jobs[jobID]; ok {
+		job.State = state
+	}
+}
+
+// Requests returns all requests received by the adapter
+func (a *MockAdapter) Requests() []*Request {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]*Request(nil), a.requests...)
+}
+
+func (a *MockAdapter) SendRequest(url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	return a.SendRequestContext(context.Background(), url, req, additionalResponseData)
+}
+
+func (a *MockAdapter) SendRequestContext(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	// the request must be encodable to be sent by a real adapter
+	if _, err := req.Encode(); err != nil {
+		return nil, fmt.Errorf("unable to encode IPP request: %w", err)
+	}
+
+	var document []byte
+	if req.File != nil {
+		data, err := io.ReadAll(&contextReader{ctx: ctx, reader: req.File})
+		if err != nil {
+			return nil, err
+		}
+		document = data
+	}
+
+	a.mu.Lock()
+	a.requests = append(a.requests, req)
+	delay := a.delays[req.Operation]
+	var injectedErr error
+	if errs := a.errors[req.Operation]; len(errs) > 0 {
+		injectedErr = errs[0]
+		a.errors[req.Operation] = errs[1:]
+	}
+	a.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if injectedErr != nil {
+		if _, ok := injectedErr.(IPPError); ok {
+			return nil, fmt.Errorf("received error IPP response: %w", injectedErr)
+		}
+		return nil, injectedErr
+	}
+
+	a.mu.Lock()
+	resp, err := a.handle(req, document, additionalResponseData)
+	a.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("received error IPP response: %w", err)
+	}
+
+	resp.RequestId = req.RequestId
+	return resp, nil
+}
+
+func (a *MockAdapter) GetHttpUri(namespace string, object interface{}) string {
+	return localHttpUri(namespace, object)
+}
+
+func (a *MockAdapter) TestConnection() error {
+	return nil
+}
+
+func (a *MockAdapter) TestConnectionContext(_ context.Context) error {
+	return nil
+}
+
+func (a *MockAdapter) handle(req *Request, document []byte, additionalResponseData io.Writer) (*Response, error) {
+	switch req.Operation {
+	case OperationPrintJob, OperationCreateJob:
+		return a.createJob(req, document)
+	case OperationSendDocument:
+		return a.sendDocument(req, document)
+	case OperationGetPrinterAttributes:
+		name, err := a.printerFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		resp := NewResponse(StatusOk, req.RequestId)
+		resp.PrinterAttributes = append(resp.PrinterAttributes, filterAttributes(a.printerAttributes(name), req))
+		return resp, nil
+	case OperationGetJobAttributes:
+		job, err := a.jobFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		resp := NewResponse(StatusOk, req.RequestId)
+		resp.JobAttributes = append(resp.JobAttributes, filterAttributes(job.attributes(), req))
+		return resp, nil
+	case OperationGetJobs:
+		return a.getJobs(req)
+	case OperationCancelJob:
+		job, err := a.jobFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		if job.State >= JobStateCanceled {
+			return nil, IPPError{Status: StatusErrorNotPossible, Message: fmt.Sprintf("Job #%d is already finished.", job.ID)}
+		}
+		job.State = JobStateCanceled
+	case OperationCancelJobs:
+		name, err := a.printerFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range a.jobs {
+			if job.Printer == name && job.State < JobStateCanceled {
+				job.State = JobStateCanceled
+			}
+		}
+	case OperationRestartJob:
+		job, err := a.jobFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		if holdUntil, ok := req.JobAttributes[AttributeHoldJobUntil].(string); ok && holdUntil != "no-hold" {
+			job.HoldUntil = holdUntil
+			job.State = JobStateHeld
+			job.StateReasons = []string{JobStateReasonJobHoldUntilSpecified}
+		} else {
+			job.HoldUntil = ""
+			job.State = JobStatePending
+			job.StateReasons = nil
+		}
+	case OperationPausePrinter, OperationResumePrinter, OperationCupsAcceptJobs, OperationCupsRejectJobs:
+		name, err := a.printerFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		printer := a.printers[name]
+		switch req.Operation {
+		case OperationPausePrinter:
+			printer[AttributePrinterState] = newAttribute(AttributePrinterState, int(PrinterStateStopped))
+		case OperationResumePrinter:
+			printer[AttributePrinterState] = newAttribute(AttributePrinterState, int(PrinterStateIdle))
+		case OperationCupsAcceptJobs:
+			printer[AttributePrinterIsAcceptingJobs] = newAttribute(AttributePrinterIsAcceptingJobs, true)
+		case OperationCupsRejectJobs:
+			printer[AttributePrinterIsAcceptingJobs] = newAttribute(AttributePrinterIsAcceptingJobs, false)
+		}
+	case OperationCupsGetDefault:
+		if a.printerAttributes(a.defaultPrinter) == nil {
+			return nil, IPPError{Status: StatusErrorNotFound, Message: "No default printer."}
+		}
+		resp := NewResponse(StatusOk, req.RequestId)
+		resp.PrinterAttributes = append(resp.PrinterAttributes, filterAttributes(a.printerAttributes(a.defaultPrinter), req))
+		return resp, nil
+	case OperationCupsSetDefault:
+		name, err := a.printerFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		a.defaultPrinter = name
+	case OperationCupsGetPrinters:
+		resp := NewResponse(StatusOk, req.RequestId)
+		for _, name := range sortedKeys(a.printers) {
+			resp.PrinterAttributes = append(resp.PrinterAttributes, filterAttributes(a.printerAttributes(name), req))
+		}
+		return resp, nil
+	case OperationCupsGetClasses:
+		resp := NewResponse(StatusOk, req.RequestId)
+		for _, name := range sortedKeys(a.classes) {
+			resp.PrinterAttributes = append(resp.PrinterAttributes, filterAttributes(a.printerAttributes(name), req))
+		}
+		return resp, nil
+	case OperationCupsAddModifyPrinter:
+		name := lastPathElement(req.OperationAttributes[AttributePrinterURI])
+		if _, ok := a.printers[name]; !ok {
+			a.addPrinter(name, nil)
+		}
+		printer := a.printers[name]
+		for _, attributes := range []map[string]any{req.OperationAttributes, req.PrinterAttributes} {
+			for attr, value := range attributes {
+				switch attr {
+				case AttributeCharset, AttributeNaturalLanguage, AttributePrinterURI, AttributeRequestingUserName:
+					continue
+				}
+				printer[attr] = newAttribute(attr, value)
+			}
+		}
+		if document != nil {
+			a.printerPPDs[name] = document
+		}
+	case OperationCupsCreateLocalPrinter:
+		name, _ := req.PrinterAttributes[AttributePrinterName].(string)
+		deviceURI, _ := req.PrinterAttributes[AttributeDeviceURI].(string)
+		if name == "" {
+			return nil, IPPError{Status: StatusErrorBadRequest, Message: "Missing required attributes."}
+		}
+		if !strings.HasPrefix(deviceURI, "ipp://") && !strings.HasPrefix(deviceURI, "ipps://") {
+			return nil, IPPError{Status: StatusErrorBadRequest, Message: fmt.Sprintf("Bad device-uri \"%s\".", deviceURI)}
+		}
+		if _, ok := a.printers[name]; !ok {
+			a.addPrinter(name, nil)
+			printer := a.printers[name]
+			printer[AttributePrinterIsTemporary] = newAttribute(AttributePrinterIsTemporary, true)
+			for attr, value := range req.PrinterAttributes {
+				printer[attr] = newAttribute(attr, value)
+			}
+		}
+		printer := a.printers[name]
+		resp := NewResponse(StatusOk, req.RequestId)
+		resp.PrinterAttributes = append(resp.PrinterAttributes, Attributes{
+			AttributePrinterUriSupported: printer[AttributePrinterUriSupported],
+			AttributePrinterState:        printer[AttributePrinterState],
+			AttributePrinterStateReasons: printer[AttributePrinterStateReasons],
+		})
+		return resp, nil
+	case OperationCupsAddModifyClass:
+		name := lastPathElement(req.OperationAttributes[AttributePrinterURI])
+		members := make([]string, 0)
+		switch v := req.PrinterAttributes[AttributeMemberURIs].(type) {
+		case string:
+			members = append(members, lastPathElement(v))
+		case []string:
+			for _, uri := range v {
+				members = append(members, lastPathElement(uri))
+			}
+		}
+		for _, member := range members {
+			if _, ok := a.printers[member]; !ok {
+				return nil, IPPError{Status: StatusErrorNotFound, Message: "The printer or class does not exist."}
+			}
+		}
+		a.classes[name] = members
+	case OperationCupsDeletePrinter:
+		name, err := a.printerFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		delete(a.printers, name)
+		delete(a.printerPPDs, name)
+		// like cups the printer is removed from all classes
+		for class, members := range a.classes {
+			remaining := make([]string, 0, len(members))
+			for _, member := range members {
+				if member != name {
+					remaining = append(remaining, member)
+				}
+			}
+			a.classes[class] = remaining
+		}
+	case OperationCupsDeleteClass:
+		name, err := a.printerFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		delete(a.classes, name)
+	case OperationCupsGetPpd:
+		if _, ok := req.OperationAttributes[AttributePrinterURI]; !ok {
+			return nil, IPPError{Status: StatusErrorNotFound, Message: "The PPD file does not exist."}
+		}
+		name, err := a.printerFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		ppd, ok := a.printerPPDs[name]
+		if !ok {
+			return nil, IPPError{Status: StatusErrorNotFound, Message: fmt.Sprintf("The PPD file for printer %s does not exist.", name)}
+		}
+		if additionalResponseData != nil {
+			if _, err := additionalResponseData.Write(ppd); err != nil {
+				return nil, err
+			}
+		}
+	case OperationCupsGetDocument:
+		job, err := a.jobFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		number, _ := req.OperationAttributes[AttributeDocumentNumber].(int)
+		if number < 1 || number > len(job.Documents) {
+			return nil, IPPError{Status: StatusErrorNotFound, Message: fmt.Sprintf("Document #%d does not exist in job #%d.", number, job.ID)}
+		}
+		if additionalResponseData != nil {
+			if _, err := additionalResponseData.Write(job.Documents[number-1]); err != nil {
+				return nil, err
+			}
+		}
+		resp := NewResponse(StatusOk, req.RequestId)
+		resp.OperationAttributes[AttributeDocumentFormat] = newAttribute(AttributeDocumentFormat, job.DocumentFormats[number-1])
+		resp.OperationAttributes[AttributeDocumentNumber] = newAttribute(AttributeDocumentNumber, number)
+		if job.Name != "" {
+			resp.OperationAttributes[AttributeDocumentName] = newAttribute(AttributeDocumentName, job.Name)
+		}
+		return resp, nil
+	case OperationCupsAuthenticateJob:
+		job, err := a.jobFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		authInfo, _ := req.OperationAttributes[AttributeAuthInfo].([]string)
+		if len(authInfo) == 0 {
+			return nil, IPPError{Status: StatusErrorBadRequest, Message: "No authentication information provided."}
+		}
+		if !IsJobHeldForAuthentication(job.attributes()) {
+			return nil, IPPError{Status: StatusErrorNotPossible, Message: fmt.Sprintf("Job #%d is not held for authentication.", job.ID)}
+		}
+		job.AuthInfo = authInfo
+		job.State = JobStatePending
+		job.StateReasons = nil
+	case OperationCupsMoveJob:
+		dest := lastPathElement(req.PrinterAttributes[AttributeJobPrinterURI])
+		if _, ok := a.printers[dest]; !ok {
+			return nil, IPPError{Status: StatusErrorNotFound, Message: "The printer or class does not exist."}
+		}
+		if _, ok := req.OperationAttributes[AttributeJobURI]; ok {
+			job, err := a.jobFromRequest(req)
+			if err != nil {
+				return nil, err
+			}
+			job.Printer = dest
+			break
+		}
+		src, err := a.printerFromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range a.jobs {
+			if job.Printer == src && job.State < JobStateCanceled {
+				job.Printer = dest
+			}
+		}
+	case OperationCupsGetDevices:
+		resp := NewResponse(StatusOk, req.RequestId)
+		resp.PrinterAttributes = append(resp.PrinterAttributes, a.devices...)
+		return resp, nil
+	case OperationCupsGetPPDs:
+		resp := NewResponse(StatusOk, req.RequestId)
+		resp.PrinterAttributes = append(resp.PrinterAttributes, a.ppds...)
+		return resp, nil
+	default:
+		return nil, IPPError{Status: StatusErrorOperationNotSupported, Message: fmt.Sprintf("%s is not supported.", OperationName(req.Operation))}
+	}
+
+	return NewResponse(StatusOk, req.RequestId), nil
+}
+
+func (a *MockAdapter) createJob(req *Request, document []byte) (*Response, error) {
+	name, err := a.printerFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if accepting, ok := a.printerAttributes(name)[AttributePrinterIsAcceptingJobs]; ok && accepting[0].Value == false {
+		return nil, IPPError{Status: StatusErrorNotAcceptingJobs, Message: fmt.Sprintf("Destination \"%s\" is not accepting jobs.", name)}
+	}
+
+	job := &MockJob{
+		ID:         a.nextJobID,
+		Printer:    name,
+		State:      JobStatePending,
+		Attributes: make(map[string]any),
+	}
+	a.nextJobID++
+
+	if jobName, ok := req.OperationAttributes[AttributeJobName].(string); ok {
+		job.Name = jobName
+	}
+	if user, ok := req.OperationAttributes[AttributeRequestingUserName].(string); ok {
+		job.User = user
+	}
+	for attr, value := range req.JobAttributes {
+		job.Attributes[attr] = value
+	}
+	if holdUntil, ok := req.JobAttributes[AttributeHoldJobUntil].(string); ok && holdUntil != "no-hold" {
+		job.HoldUntil = holdUntil
+		job.State = JobStateHeld
+		job.StateReasons = []string{JobStateReasonJobHoldUntilSpecified}
+	}
+	if required, ok := a.printerAttributes(name)[AttributeAuthInfoRequired]; ok && required[0].Value != "none" {
+		job.State = JobStateHeld
+		job.StateReasons = []string{JobStateReasonHeldForAuthentication}
+	}
+	if req.Operation == OperationPrintJob {
+		job.addDocument(req, document)
+	}
+
+	a.jobs[job.ID] = job
+
+	resp := NewResponse(StatusOk, req.RequestId)
+	resp.JobAttributes = append(resp.JobAttributes, job.attributes())
+	return resp, nil
+}
+
+func (a *MockAdapter) sendDocument(req *Request, document []byte) (*Response, error) {
+	job, err := a.jobFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.State >= JobStateCanceled {
+		return nil, IPPError{Status: StatusErrorNotPossible, Message: fmt.Sprintf("Job #%d is finished and cannot be altered.", job.ID)}
+	}
+
+	job.addDocument(req, document)
+
+	resp := NewResponse(StatusOk, req.RequestId)
+	resp.JobAttributes = append(resp.JobAttributes, job.attributes())
+	return resp, nil
+}
+
+func (a *MockAdapter) getJobs(req *Request) (*Response, error) {
+	printer := ""
+	if uri, ok := req.OperationAttributes[AttributePrinterURI].(string); ok {
+		printer = lastPathElement(uri)
+	}
+
+	whichJobs, _ := req.OperationAttributes[AttributeWhichJobs].(string)
+	myJobs, _ := req.OperationAttributes[AttributeMyJobs].(bool)
+	user, _ := req.OperationAttributes[AttributeRequestingUserName].(string)
+	firstJobID, _ := req.OperationAttributes[AttributeFirstJobID].(int)
+	limit, _ := req.OperationAttributes[AttributeLimit].(int)
+
+	ids := make([]int, 0, len(a.jobs))
+	for id := range a.jobs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	resp := NewResponse(StatusOk, req.RequestId)
+	for _, id := range ids {
+		job := a.jobs[id]
+
+		if printer != "" && job.Printer != printer || id < firstJobID || myJobs && job.User != user {
+			continue
+		}
+
+		completed := job.State >= JobStateCanceled
+		if whichJobs == JobStateFilterCompleted && !completed || (whichJobs == "" || whichJobs == JobStateFilterNotCompleted) && completed {
+			continue
+		}
+
+		resp.JobAttributes = append(resp.JobAttributes, filterAttributes(job.attributes(), req))
+		if limit > 0 && len(resp.JobAttributes) == limit {
+			break
+		}
+	}
+
+	return resp, nil
+}
+
+func (a *MockAdapter) addPrinter(name string, attributes Attributes) {
+	printer := make(Attributes)
+	for attr, values := range attributes {
+		printer[attr] = values
+	}
+
+	defaults := map[string]any{
+		AttributePrinterName:            name,
+		AttributePrinterState:           int(PrinterStateIdle),
+		AttributePrinterStateReasons:    "none",
+		AttributePrinterIsAcceptingJobs: true,
+		AttributePrinterUriSupported:    fmt.Sprintf("ipp://localhost/printers/%s", name),
+	}
+	for attr, value := range defaults {
+		if _, ok := printer[attr]; !ok {
+			printer[attr] = newAttribute(attr, value)
+		}
+	}
+
+	a.printers[name] = printer
+}
+
+// printerAttributes returns the attributes of a printer or class
+func (a *MockAdapter) printerAttributes(name string) Attributes {
+	if members, ok := a.classes[name]; ok {
+		uris := make([]string, 0, len(members))
+		for _, member := range members {
+			uris = append(uris, fmt.Sprintf("ipp://localhost/printers/%s", member))
+		}
+
+		attributes := Attributes{
+			AttributePrinterName:     newAttribute(AttributePrinterName, name),
+			AttributePrinterState:    newAttribute(AttributePrinterState, int(PrinterStateIdle)),
+			AttributeMemberNames:     newAttribute(AttributeMemberNames, members),
+			AttributeMemberURIs:      newAttribute(AttributeMemberURIs, uris),
+			AttributePrinterIsShared: newAttribute(AttributePrinterIsShared, false),
+		}
+		return attributes
+	}
+
+	return a.printers[name]
+}
+
+func (a *MockAdapter) printerFromRequest(req *Request) (string, error) {
+	name := lastPathElement(req.OperationAttributes[AttributePrinterURI])
+
+	if _, ok := a.printers[name]; ok {
+		return name, nil
+	}
+	if _, ok := a.classes[name]; ok {
+		return name, nil
+	}
+
+	return "", IPPError{Status: StatusErrorNotFound, Message: "The printer or class does not exist."}
+}
+
+func (a *MockAdapter) jobFromRequest(req *Request) (*MockJob, error) {
+	jobID, ok := req.OperationAttributes[AttributeJobID].(int)
+	if !ok {
+		jobID, _ = strconv.Atoi(lastPathElement(req.OperationAttributes[AttributeJobURI]))
+	}
+
+	job, ok := a.jobs[jobID]
+	if !ok {
+		return nil, IPPError{Status: StatusErrorNotFound, Message: fmt.Sprintf("Job #%d does not exist.", jobID)}
+	}
+
+	return job, nil
+}
+
+func (j *MockJob) addDocument(req *Request, document []byte) {
+	format, ok := req.OperationAttributes[AttributeDocumentFormat].(string)
+	if !ok {
+		format = MimeTypeOctetStream
+	}
+
+	j.Documents = append(j.Documents, document)
+	j.DocumentFormats = append(j.DocumentFormats, format)
+}
+
+func (j *MockJob) attributes() Attributes {
+	attributes := Attributes{
+		AttributeJobID:                  newAttribute(AttributeJobID, j.ID),
+		AttributeJobURI:                 newAttribute(AttributeJobURI, fmt.Sprintf("ipp://localhost/jobs/%d", j.ID)),
+		AttributeJobState:               newAttribute(AttributeJobState, int(j.State)),
+		AttributeJobPrinterURI:          newAttribute(AttributeJobPrinterURI, fmt.Sprintf("ipp://localhost/printers/%s", j.Printer)),
+		AttributeJobName:                newAttribute(AttributeJobName, j.Name),
+		AttributeJobOriginatingUserName: newAttribute(AttributeJobOriginatingUserName, j.User),
+		AttributeNumberOfDocuments:      newAttribute(AttributeNumberOfDocuments, len(j.Documents)),
+	}
+
+	if j.HoldUntil != "" {
+		attributes[AttributeHoldJobUntil] = newAttribute(AttributeHoldJobUntil, j.HoldUntil)
+	}
+
+	reasons := j.StateReasons
+	if len(reasons) == 0 {
+		reasons = []string{JobStateReasonNone}
+	}
+	for _, reason := range reasons {
+		attributes[AttributeJobStateReasons] = append(attributes[AttributeJobStateReasons], Attribute{Tag: TagKeyword, Name: AttributeJobStateReasons, Value: reason})
+	}
+
+	for attr, value := range j.Attributes {
+		if _, ok := attributes[attr]; !ok {
+			attributes[attr] = newAttribute(attr, value)
+		}
+	}
+
+	return attributes
+}
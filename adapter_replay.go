@@ -0,0 +1,439 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultReplayMatchAttributes are the operation attributes compared by the ReplayAdapter if none are given
+var DefaultReplayMatchAttributes = []string{AttributePrinterURI, AttributeJobURI, AttributeJobID}
+
+// Fixture holds recorded request and response pairs
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+	// Downloads are the recorded plain http downloads, e.g. of a ppd file cups redirects to
+	Downloads []DownloadInteraction `json:"downloads,omitempty"`
+}
+
+// Interaction is a single recorded request with its response or error
+type Interaction struct {
+	URL          string            `json:"url"`
+	Operation    string            `json:"operation"`
+	Request      []byte            `json:"request"`
+	Response     []byte            `json:"response,omitempty"`
+	ResponseData []byte            `json:"response-data,omitempty"`
+	Error        *InteractionError `json:"error,omitempty"`
+}
+
+// InteractionError is a recorded ipp or http error
+type InteractionError struct {
+	Status   int16  `json:"status,omitempty"`
+	Message  string `json:"message,omitempty"`
+	HTTPCode int    `json:"http-code,omitempty"`
+	// Location is the printer-uri a cups-see-other response redirects to
+	Location string `json:"location,omitempty"`
+}
+
+// DownloadInteraction is a single recorded download with its data or error
+type DownloadInteraction struct {
+	URL   string            `json:"url"`
+	Data  []byte            `json:"data,omitempty"`
+	Error *InteractionError `json:"error,omitempty"`
+}
+
+// LoadFixture reads a fixture file
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := new(Fixture)
+	if err := json.Unmarshal(data, fixture); err != nil {
+		return nil, fmt.Errorf("unable to parse fixture %s: %w", path, err)
+	}
+
+	return fixture, nil
+}
+
+// Save writes the fixture to a file
+func (f *Fixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// RecordingAdapter wraps an Adapter and records every request and its response
+type RecordingAdapter struct {
+	adapter Adapter
+	path    string
+
+	mu      sync.Mutex
+	fixture Fixture
+}
+
+// NewRecordingAdapter creates a new adapter which records all requests sent through the given adapter.
+// the recorded interactions are written to path by Save
+func NewRecordingAdapter(adapter Adapter, path string) *RecordingAdapter {
+	return &RecordingAdapter{
+		adapter: adapter,
+		path:    path,
+	}
+}
+
+func (a *RecordingAdapter) SendRequest(url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	return a.SendRequestContext(context.Background(), url, req, additionalResponseData)
+}
+
+func (a *RecordingAdapter) SendRequestContext(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	payload, err := req.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		URL:       url,
+		Operation: OperationName(req.Operation),
+		Request:   payload,
+	}
+
+	data := new(bytes.Buffer)
+	var dataWriter io.Writer = data
+	if additionalResponseData != nil {
+		dataWriter = io.MultiWriter(additionalResponseData, data)
+	}
+
+	resp, err := a.adapter.SendRequestContext(ctx, url, req, dataWriter)
+
+	var seeOther seeOtherError
+	var ippErr IPPError
+	var httpErr HTTPError
+	switch {
+	case err == nil:
+		if interaction.Response, err = resp.Encode(); err != nil {
+			return nil, err
+		}
+		interaction.ResponseData = data.Bytes()
+	case errors.As(err, &seeOther):
+		interaction.Error = &InteractionError{Status: seeOther.Status, Message: seeOther.Message, Location: seeOther.Location}
+	case errors.As(err, &ippErr):
+		interaction.Error = &InteractionError{Status: ippErr.Status, Message: ippErr.Message}
+	case errors.As(err, &httpErr):
+		interaction.Error = &InteractionError{HTTPCode: httpErr.Code}
+	default:
+		// connection errors are not reproducible, so they are not recorded
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.fixture.Interactions = append(a.fixture.Interactions, interaction)
+	a.mu.Unlock()
+
+	return resp, err
+}
+
+func (a *RecordingAdapter) Download(ctx context.Context, url string) (io.ReadCloser, error) {
+	interaction := DownloadInteraction{URL: url}
+
+	body, err := download(ctx, a.adapter, url)
+	if err != nil {
+		var httpErr HTTPError
+		if !errors.As(err, &httpErr) {
+			// connection errors are not reproducible, so they are not recorded
+			return nil, err
+		}
+		interaction.Error = &InteractionError{HTTPCode: httpErr.Code}
+	} else {
+		defer body.Close()
+		if interaction.Data, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+
+	a.mu.Lock()
+	a.fixture.Downloads = append(a.fixture.Downloads, interaction)
+	a.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(interaction.Data)), nil
+}
+
+func (a *RecordingAdapter) GetHttpUri(namespace string, object interface{}) string {
+	return a.adapter.GetHttpUri(namespace, object)
+}
+
+func (a *RecordingAdapter) TestConnection() error {
+	return a.adapter.TestConnection()
+}
+
+func (a *RecordingAdapter) TestConnectionContext(ctx context.Context) error {
+	return testConnection(ctx, a.adapter)
+}
+
+// Save writes all recorded interactions to the fixture file
+func (a *RecordingAdapter) Save() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.fixture.Save(a.path)
+}
+
+// ReplayAdapter answers requests with previously recorded responses.
+// requests are matched on the operation and the given operation attributes, downloads on their url. every interaction
+// is replayed only once
+type ReplayAdapter struct {
+	matchAttributes []string
+
+	mu           sync.Mutex
+	interactions []replayInteraction
+	downloads    []replayDownload
+}
+
+type replayDownload struct {
+	DownloadInteraction
+	used bool
+}
+
+type replayInteraction struct {
+	Interaction
+	request *Request
+	used    bool
+}
+
+// NewReplayAdapter creates a new adapter which replays the fixture file at path. if no match attributes are given
+// DefaultReplayMatchAttributes are used
+func NewReplayAdapter(path string, matchAttributes ...string) (*ReplayAdapter, error) {
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReplayAdapterFromFixture(fixture, matchAttributes...)
+}
+
+// NewReplayAdapterFromFixture creates a new adapter which replays the given fixture
+func NewReplayAdapterFromFixture(fixture *Fixture, matchAttributes ...string) (*ReplayAdapter, error) {
+	if len(matchAttributes) == 0 {
+		matchAttributes = DefaultReplayMatchAttributes
+	}
+
+	adapter := &ReplayAdapter{
+		matchAttributes: matchAttributes,
+		interactions:    make([]replayInteraction, 0, len(fixture.Interactions)),
+		downloads:       make([]replayDownload, 0, len(fixture.Downloads)),
+	}
+
+	for _, download := range fixture.Downloads {
+		adapter.downloads = append(adapter.downloads, replayDownload{DownloadInteraction: download})
+	}
+
+	for i, interaction := range fixture.Interactions {
+		req, err := NewRequestDecoder(bytes.NewReader(interaction.Request)).Decode(nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode request of interaction %d: %w", i, err)
+		}
+
+		adapter.interactions = append(adapter.interactions, replayInteraction{
+			Interaction: interaction,
+			request:     req,
+		})
+	}
+
+	return adapter, nil
+}
+
+func (a *ReplayAdapter) SendRequest(url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	return a.SendRequestContext(context.Background(), url, req, additionalResponseData)
+}
+
+func (a *ReplayAdapter) SendRequestContext(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// encode and decode the request, so it is compared in the same form as the recorded requests
+	payload, err := req.Encode()
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := NewRequestDecoder(bytes.NewReader(payload)).Decode(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	interaction := a.match(decoded)
+	if interaction == nil {
+		return nil, fmt.Errorf("unexpected request %s to %s:\n%s", OperationName(req.Operation), url, a.diff(decoded))
+	}
+	interaction.used = true
+
+	if interaction.Error != nil {
+		if interaction.Error.HTTPCode != 0 {
+			return nil, HTTPError{Code: interaction.Error.HTTPCode}
+		}
+		return nil, fmt.Errorf("received error IPP response: %w", interaction.Error.ippError())
+	}
+
+	resp, err := NewResponseDecoder(bytes.NewReader(interaction.Response)).Decode(nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode recorded response: %w", err)
+	}
+	resp.RequestId = req.RequestId
+
+	if additionalResponseData != nil && len(interaction.ResponseData) > 0 {
+		if _, err := additionalResponseData.Write(interaction.ResponseData); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+func (a *ReplayAdapter) Download(ctx context.Context, url string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i := range a.downloads {
+		download := &a.downloads[i]
+		if download.used || download.URL != url {
+			continue
+		}
+		download.used = true
+
+		if download.Error != nil {
+			return nil, HTTPError{Code: download.Error.HTTPCode}
+		}
+		return io.NopCloser(bytes.NewReader(download.Data)), nil
+	}
+
+	return nil, fmt.Errorf("unexpected download of %s", url)
+}
+
+func (a *ReplayAdapter) GetHttpUri(namespace string, object interface{}) string {
+	return localHttpUri(namespace, object)
+}
+
+func (a *ReplayAdapter) TestConnection() error {
+	return nil
+}
+
+func (a *ReplayAdapter) TestConnectionContext(_ context.Context) error {
+	return nil
+}
+
+// Unused returns the operation names of all interactions and the urls of all downloads which have not been replayed
+func (a *ReplayAdapter) Unused() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	unused := make([]string, 0)
+	for _, interaction := range a.interactions {
+		if !interaction.used {
+			unused = append(unused, interaction.Operation)
+		}
+	}
+	for _, download := range a.downloads {
+		if !download.used {
+			unused = append(unused, "Download "+download.URL)
+		}
+	}
+
+	return unused
+}
+
+func (a *ReplayAdapter) match(req *Request) *replayInteraction {
+	for i := range a.interactions {
+		interaction := &a.interactions[i]
+		if interaction.used || interaction.request.Operation != req.Operation {
+			continue
+		}
+
+		if len(a.attributeDiff(interaction.request, req)) == 0 {
+			return interaction
+		}
+	}
+
+	return nil
+}
+
+// attributeDiff lists the match attributes which differ between the recorded and the actual request
+func (a *ReplayAdapter) attributeDiff(recorded, actual *Request) []string {
+	diff := make([]string, 0)
+
+	for _, name := range a.matchAttributes {
+		want, wantOk := recorded.OperationAttributes[name]
+		got, gotOk := actual.OperationAttributes[name]
+
+		if wantOk != gotOk || !reflect.DeepEqual(want, got) {
+			diff = append(diff, fmt.Sprintf("%s: recorded %s, got %s", name, formatReplayValue(want, wantOk), formatReplayValue(got, gotOk)))
+		}
+	}
+
+	sort.Strings(diff)
+	return diff
+}
+
+// diff describes why the remaining interactions do not match the request
+func (a *ReplayAdapter) diff(req *Request) string {
+	lines := make([]string, 0)
+
+	for i, interaction := range a.interactions {
+		if interaction.used {
+			continue
+		}
+
+		if interaction.request.Operation != req.Operation {
+			lines = append(lines, fmt.Sprintf("  #%d %s: operation differs", i, interaction.Operation))
+			continue
+		}
+
+		for _, line := range a.attributeDiff(interaction.request, req) {
+			lines = append(lines, fmt.Sprintf("  #%d %s: %s", i, interaction.Operation, line))
+		}
+	}
+
+	if len(lines) == 0 {
+		return "  no recorded interactions left"
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ippError restores the recorded ipp error, a cups-see-other response keeps its location
+func (e *InteractionError) ippError() error {
+	ippErr := IPPError{Status: e.Status, Message: e.Message}
+	if e.Status == StatusCupsSeeOther {
+		return seeOtherError{IPPError: ippErr, Location: e.Location}
+	}
+
+	return ippErr
+}
+
+func formatReplayValue(value any, ok bool) string {
+	if !ok {
+		return "<missing>"
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(value))
+}
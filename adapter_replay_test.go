@@ -0,0 +1,107 @@
+package ipp
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type printerTestAdapter struct {
+	retryTestAdapter
+}
+
+func (a *printerTestAdapter) SendRequestContext(_ context.Context, _ string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	if req.Operation == OperationCancelJob {
+		return nil, IPPError{Status: StatusErrorNotFound, Message: "job not found"}
+	}
+
+	resp := NewResponse(StatusOk, req.RequestId)
+	resp.PrinterAttributes = append(resp.PrinterAttributes, Attributes{
+		AttributePrinterName: []Attribute{{Tag: TagName, Name: AttributePrinterName, Value: "test"}},
+		"printer-type":       []Attribute{{Tag: TagEnum, Name: "printer-type", Value: 4}},
+		AttributePrinterStateReasons: []Attribute{
+			{Tag: TagKeyword, Name: AttributePrinterStateReasons, Value: "media-low"},
+			{Tag: TagKeyword, Name: AttributePrinterStateReasons, Value: "toner-low"},
+		},
+	})
+
+	if additionalResponseData != nil {
+		_, _ = additionalResponseData.Write([]byte("data"))
+	}
+
+	return resp, nil
+}
+
+func TestRecordingAdapter_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+
+	recorder := NewRecordingAdapter(&printerTestAdapter{}, path)
+	client := NewIPPClientWithAdapter("user", recorder)
+
+	recorded, err := client.GetPrinterAttributes("test", nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, client.CancelJob(1, false))
+	assert.Nil(t, recorder.Save())
+
+	replayer, err := NewReplayAdapter(path)
+	assert.Nil(t, err)
+	client = NewIPPClientWithAdapter("user", replayer)
+
+	replayed, err := client.GetPrinterAttributes("test", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, len(recorded), len(replayed))
+	for name, values := range recorded {
+		assert.Equal(t, len(values), len(replayed[name]))
+		for i, value := range values {
+			assert.Equal(t, value.Tag, replayed[name][i].Tag)
+			assert.Equal(t, value.Value, replayed[name][i].Value)
+		}
+	}
+
+	err = client.CancelJob(2, false)
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "job-uri: recorded \"ipp://localhost/jobs/1\", got \"ipp://localhost/jobs/2\""), err.Error())
+
+	assert.True(t, IsNotExistsError(client.CancelJob(1, false)))
+	assert.Empty(t, replayer.Unused())
+}
+
+func TestRecordingAdapter_ReplaySeeOther(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+
+	recorder := NewRecordingAdapter(newTestHttpAdapter(t, servePPD), path)
+	client := NewCUPSClientWithAdapter("user", recorder)
+
+	ppd, err := client.GetPPD("remote")
+	assert.Nil(t, err)
+	recorded, err := io.ReadAll(ppd)
+	assert.Nil(t, err)
+	assert.Nil(t, ppd.Close())
+	assert.Nil(t, recorder.Save())
+
+	fixture, err := LoadFixture(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(fixture.Interactions))
+	assert.Equal(t, StatusCupsSeeOther, fixture.Interactions[0].Error.Status)
+	assert.True(t, strings.HasSuffix(fixture.Interactions[0].Error.Location, "/printers/remote"))
+	assert.Equal(t, 1, len(fixture.Downloads))
+
+	replayer, err := NewReplayAdapterFromFixture(fixture)
+	assert.Nil(t, err)
+	client = NewCUPSClientWithAdapter("user", replayer)
+
+	ppd, err = client.GetPPD("remote")
+	assert.Nil(t, err)
+	replayed, err := io.ReadAll(ppd)
+	assert.Nil(t, err)
+	assert.Equal(t, "*PPD-Adobe: remote", string(replayed))
+	assert.Equal(t, recorded, replayed)
+	assert.Empty(t, replayer.Unused())
+
+	_, err = replayer.Download(context.Background(), fixture.Downloads[0].URL)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,216 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy defines when and how often a failed request is retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponentially growing delay and the delay requested by a Retry-After header
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows with each retry
+	Multiplier float64
+	// Jitter is the fraction (0.0 - 1.0) of the delay which is randomized
+	Jitter float64
+	// RetryNonIdempotent allows retrying operations like Print-Job or Send-Document after the request may have reached
+	// the server. this can result in duplicated jobs. rejected requests (busy, service unavailable) and failed dials are
+	// always retried
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a retry policy with sensible defaults
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// nonIdempotentOperations may create duplicate jobs or documents if they are sent twice
+var nonIdempotentOperations = map[int16]bool{
+	OperationPrintJob:     true,
+	OperationPrintUri:     true,
+	OperationCreateJob:    true,
+	OperationSendDocument: true,
+	OperationSendUri:      true,
+}
+
+// RetryAdapter wraps an Adapter and retries transient failures with exponential backoff
+type RetryAdapter struct {
+	adapter Adapter
+	policy  RetryPolicy
+}
+
+// NewRetryAdapter creates a new adapter which retries requests of the given adapter according to the policy
+func NewRetryAdapter(adapter Adapter, policy RetryPolicy) *RetryAdapter {
+	return &RetryAdapter{
+		adapter: adapter,
+		policy:  policy,
+	}
+}
+
+func (a *RetryAdapter) SendRequest(url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	return a.SendRequestContext(context.Background(), url, req, additionalResponseData)
+}
+
+func (a *RetryAdapter) SendRequestContext(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	// documents must be rewound before a retry, so only seekable documents can be sent more than once
+	var fileSeeker io.Seeker
+	var fileOffset int64
+	if req.File != nil {
+		if seeker, ok := req.File.(io.Seeker); ok {
+			offset, err := seeker.Seek(0, io.SeekCurrent)
+			if err == nil {
+				fileSeeker = seeker
+				fileOffset = offset
+			}
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		// the additional data of an attempt is buffered, so a failed attempt does not leave partial data in the writer
+		var data *bytes.Buffer
+		var dataWriter io.Writer
+		if additionalResponseData != nil {
+			data = new(bytes.Buffer)
+			dataWriter = data
+		}
+
+		resp, err := a.adapter.SendRequestContext(ctx, url, req, dataWriter)
+		if err == nil {
+			if data != nil {
+				if _, err := data.WriteTo(additionalResponseData); err != nil {
+					return nil, err
+				}
+			}
+			return resp, nil
+		}
+
+		if attempt >= a.policy.MaxRetries || ctx.Err() != nil || !a.shouldRetry(req, err) {
+			return nil, err
+		}
+
+		if req.File != nil {
+			if fileSeeker == nil {
+				return nil, err
+			}
+			if _, seekErr := fileSeeker.Seek(fileOffset, io.SeekStart); seekErr != nil {
+				return nil, err
+			}
+		}
+
+		timer := time.NewTimer(a.backoff(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (a *RetryAdapter) Download(ctx context.Context, url string) (io.ReadCloser, error) {
+	return download(ctx, a.adapter, url)
+}
+
+func (a *RetryAdapter) GetHttpUri(namespace string, object interface{}) string {
+	return a.adapter.GetHttpUri(namespace, object)
+}
+
+func (a *RetryAdapter) TestConnection() error {
+	return a.adapter.TestConnection()
+}
+
+func (a *RetryAdapter) TestConnectionContext(ctx context.Context) error {
+	return testConnection(ctx, a.adapter)
+}
+
+// shouldRetry checks if an error is transient and if the request can be safely sent again
+func (a *RetryAdapter) shouldRetry(req *Request, err error) bool {
+	if !IsRetryable(err) {
+		return false
+	}
+
+	// a failed dial never reached the server
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	// rejected requests (ipp or http errors) did not create a job
+	var ippErr IPPError
+	var httpErr HTTPError
+	if errors.As(err, &ippErr) || errors.As(err, &httpErr) {
+		return true
+	}
+
+	// the request may have reached the server before the connection broke, so a job may already exist
+	return a.policy.RetryNonIdempotent || !nonIdempotentOperations[req.Operation]
+}
+
+// backoff returns the delay before the next attempt. a Retry-After header sent by the server takes precedence
+func (a *RetryAdapter) backoff(attempt int, err error) time.Duration {
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		if retryAfter, ok := parseRetryAfter(httpErr.Header); ok {
+			if a.policy.MaxBackoff > 0 && retryAfter > a.policy.MaxBackoff {
+				return a.policy.MaxBackoff
+			}
+			return retryAfter
+		}
+	}
+
+	multiplier := a.policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(a.policy.InitialBackoff) * math.Pow(multiplier, float64(attempt))
+	if a.policy.MaxBackoff > 0 && delay > float64(a.policy.MaxBackoff) {
+		delay = float64(a.policy.MaxBackoff)
+	}
+
+	if a.policy.Jitter > 0 {
+		delay -= delay * a.policy.Jitter * rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
+
+// parseRetryAfter parses a Retry-After header in seconds or http date format
+func parseRetryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
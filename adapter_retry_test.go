@@ -0,0 +1,112 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type retryTestAdapter struct {
+	errs  []error
+	calls int
+}
+
+func (a *retryTestAdapter) SendRequest(url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	return a.SendRequestContext(context.Background(), url, req, additionalResponseData)
+}
+
+func (a *retryTestAdapter) SendRequestContext(_ context.Context, _ string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	a.calls++
+	if additionalResponseData != nil {
+		_, _ = fmt.Fprintf(additionalResponseData, "attempt %d;", a.calls)
+	}
+	if a.calls <= len(a.errs) {
+		return nil, a.errs[a.calls-1]
+	}
+	return NewResponse(StatusOk, req.RequestId), nil
+}
+
+func (a *retryTestAdapter) GetHttpUri(_ string, _ interface{}) string {
+	return ""
+}
+
+func (a *retryTestAdapter) TestConnection() error {
+	return nil
+}
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	return policy
+}
+
+func TestRetryAdapter_SendRequest(t *testing.T) {
+	resetErr := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	certErr := &url.Error{Op: "Post", URL: "https://localhost:631", Err: x509.UnknownAuthorityError{}}
+
+	cases := []struct {
+		Operation          int16
+		Errs               []error
+		RetryNonIdempotent bool
+		Calls              int
+		Success            bool
+	}{
+		{OperationGetJobs, []error{IPPError{Status: StatusErrorBusy}}, false, 2, true},
+		{OperationGetJobs, []error{HTTPError{Code: http.StatusServiceUnavailable}}, false, 2, true},
+		{OperationGetJobs, []error{IPPError{Status: StatusErrorNotFound}}, false, 1, false},
+		{OperationGetJobs, []error{resetErr, resetErr, resetErr, resetErr}, false, 4, false},
+		{OperationPrintJob, []error{resetErr}, false, 1, false},
+		{OperationPrintJob, []error{resetErr}, true, 2, true},
+		{OperationPrintJob, []error{io.ErrUnexpectedEOF}, false, 1, false},
+		{OperationGetJobs, []error{certErr}, false, 1, false},
+		{OperationPrintJob, []error{&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}, false, 2, true},
+		{OperationPrintJob, []error{IPPError{Status: StatusErrorServiceUnavailable}}, false, 2, true},
+	}
+
+	for _, c := range cases {
+		adapter := &retryTestAdapter{errs: c.Errs}
+		policy := testRetryPolicy()
+		policy.RetryNonIdempotent = c.RetryNonIdempotent
+
+		_, err := NewRetryAdapter(adapter, policy).SendRequest("", NewRequest(c.Operation, 1), nil)
+		assert.Equal(t, c.Success, err == nil)
+		assert.Equal(t, c.Calls, adapter.calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	delay, ok := parseRetryAfter(http.Header{"Retry-After": []string{"5"}})
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, delay)
+
+	_, ok = parseRetryAfter(http.Header{})
+	assert.False(t, ok)
+}
+
+func TestRetryAdapter_AdditionalResponseData(t *testing.T) {
+	adapter := &retryTestAdapter{errs: []error{IPPError{Status: StatusErrorBusy}}}
+
+	data := new(bytes.Buffer)
+	_, err := NewRetryAdapter(adapter, testRetryPolicy()).SendRequest("", NewRequest(OperationCupsGetDocument, 1), data)
+	assert.NoError(t, err)
+	assert.Equal(t, "attempt 2;", data.String())
+}
+
+func TestRetryAdapter_Backoff(t *testing.T) {
+	policy := testRetryPolicy()
+	policy.MaxBackoff = 10 * time.Second
+	adapter := NewRetryAdapter(&retryTestAdapter{}, policy)
+
+	assert.Equal(t, 5*time.Second, adapter.backoff(0, HTTPError{Code: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": []string{"5"}}}))
+	assert.Equal(t, 10*time.Second, adapter.backoff(0, HTTPError{Code: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": []string{"3600"}}}))
+}
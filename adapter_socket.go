@@ -48,24 +48,14 @@ func (a *SocketAdapter) SendRequest(url string, r *Request, additionalData io.Wr
 }
 
 func (a *SocketAdapter) SendRequestContext(ctx context.Context, url string, r *Request, additionalData io.Writer) (*Response, error) {
+	var lastErr error = HTTPError{Code: http.StatusUnauthorized}
+
 	for i := 0; i < a.RequestRetryLimit; i++ {
-		// encode request
-		payload, err := r.Encode()
+		body, size, err := encodeRequestBody(ctx, r)
 		if err != nil {
 			return nil, fmt.Errorf("unable to encode IPP request: %w", err)
 		}
 
-		var body io.Reader
-		size := len(payload)
-
-		if r.File != nil && r.FileSize != -1 {
-			size += r.FileSize
-
-			body = io.MultiReader(bytes.NewBuffer(payload), r.File)
-		} else {
-			body = bytes.NewBuffer(payload)
-		}
-
 		req, err := http.NewRequestWithContext(ctx, "POST", url, body)
 		if err != nil {
 			return nil, fmt.Errorf("unable to create HTTP request: %w", err)
@@ -82,14 +72,18 @@ func (a *SocketAdapter) SendRequestContext(ctx context.Context, url string, r *R
 			return nil, err
 		}
 
-		req.Header.Set("Content-Length", strconv.Itoa(size))
+		if size != -1 {
+			req.ContentLength = int64(size)
+			req.Header.Set("Content-Length", strconv.Itoa(size))
+		}
 		req.Header.Set("Content-Type", ContentTypeIPP)
 		req.Header.Set("Authorization", fmt.Sprintf("Local %s", cert))
 
 		unixClient := http.Client{
 			Transport: &http.Transport{
 				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-					return net.Dial("unix", sock)
+					dialer := net.Dialer{Timeout: DefaultDialTimeout}
+					return dialer.DialContext(ctx, "unix", sock)
 				},
 			},
 		}
@@ -102,13 +96,15 @@ func (a *SocketAdapter) SendRequestContext(ctx context.Context, url string, r *R
 
 		if httpResp.StatusCode == http.StatusUnauthorized {
 			// retry with newly generated cert
+			lastErr = newHTTPError(httpResp)
 			httpResp.Body.Close()
 			continue
 		}
 
 		if httpResp.StatusCode != http.StatusOK {
+			httpErr := newHTTPError(httpResp)
 			httpResp.Body.Close()
-			return nil, fmt.Errorf("server did not return Status OK: %d", httpResp.StatusCode)
+			return nil, httpErr
 		}
 
 		// buffer response to avoid read issues
@@ -116,7 +112,7 @@ func (a *SocketAdapter) SendRequestContext(ctx context.Context, url string, r *R
 		if httpResp.ContentLength > 0 {
 			buf.Grow(int(httpResp.ContentLength))
 		}
-		if _, err := io.Copy(buf, httpResp.Body); err != nil {
+		if _, err := io.Copy(buf, countReceivedBytes(ctx, httpResp.Body)); err != nil {
 			httpResp.Body.Close()
 			return nil, fmt.Errorf("unable to buffer response: %w", err)
 		}
@@ -129,14 +125,14 @@ func (a *SocketAdapter) SendRequestContext(ctx context.Context, url string, r *R
 			return nil, fmt.Errorf("unable to decode IPP response: %w", err)
 		}
 
-		if err = ippResp.CheckForErrors(); err != nil {
+		if err = checkResponse(ippResp); err != nil {
 			return nil, fmt.Errorf("received error IPP response: %w", err)
 		}
 
 		return ippResp, nil
 	}
 
-	return nil, errors.New("request retry limit exceeded")
+	return nil, fmt.Errorf("request retry limit exceeded: %w", lastErr)
 }
 
 // GetSocket returns the path to the cupsd socket by searching SocketSearchPaths
@@ -204,11 +200,17 @@ func (a *SocketAdapter) GetHttpUri(namespace string, object interface{}) string
 }
 
 func (a *SocketAdapter) TestConnection() error {
+	return a.TestConnectionContext(context.Background())
+}
+
+// TestConnectionContext tests if a connection to the cups socket is possible
+func (a *SocketAdapter) TestConnectionContext(ctx context.Context) error {
 	sock, err := a.GetSocket()
 	if err != nil {
 		return err
 	}
-	conn, err := net.Dial("unix", sock)
+	dialer := net.Dialer{Timeout: DefaultDialTimeout}
+	conn, err := dialer.DialContext(ctx, "unix", sock)
 	if err != nil {
 		return err
 	}
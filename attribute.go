@@ -15,3 +15,75 @@ type Resolution struct {
 }
 
 type Collection map[string][]Attribute
+
+// newAttribute converts a request attribute value into response attributes, the tag is taken from AttributeTagMapping
+func newAttribute(name string, value any) []Attribute {
+	tag, ok := AttributeTagMapping[name]
+	if !ok {
+		tag = TagKeyword
+	}
+
+	var values []any
+	switch v := value.(type) {
+	case []Attribute:
+		return v
+	case []Collection:
+		for _, c := range v {
+			values = append(values, c)
+		}
+	case []string:
+		for _, s := range v {
+			values = append(values, s)
+		}
+	case []int:
+		for _, i := range v {
+			values = append(values, i)
+		}
+	case []bool:
+		for _, b := range v {
+			values = append(values, b)
+		}
+	case int8:
+		values = append(values, int(v))
+	case int16:
+		values = append(values, int(v))
+	case int32:
+		values = append(values, int(v))
+	default:
+		values = append(values, v)
+	}
+
+	attributes := make([]Attribute, 0, len(values))
+	for _, v := range values {
+		attributes = append(attributes, Attribute{Tag: tag, Name: name, Value: v})
+	}
+
+	return attributes
+}
+
+// filterAttributes returns only the attributes listed in requested-attributes of a request
+func filterAttributes(attributes Attributes, req *Request) Attributes {
+	var requested []string
+	switch v := req.OperationAttributes[AttributeRequestedAttributes].(type) {
+	case string:
+		requested = []string{v}
+	case []string:
+		requested = v
+	}
+
+	if len(requested) == 0 {
+		return attributes
+	}
+
+	filtered := make(Attributes)
+	for _, name := range requested {
+		if name == "all" {
+			return attributes
+		}
+		if values, ok := attributes[name]; ok {
+			filtered[name] = values
+		}
+	}
+
+	return filtered
+}
@@ -110,8 +110,8 @@ func (d *AttributeDecoder) decodeString() (string, error) {
 	}
 
 	bs := make([]byte, length)
-	if _, err := d.reader.Read(bs); err != nil {
-		return "", nil
+	if _, err := io.ReadFull(d.reader, bs); err != nil {
+		return "", err
 	}
 
 	return string(bs), nil
@@ -231,7 +231,7 @@ func (d *AttributeDecoder) decodeCollection() (Collection, error) {
 
 			// Read the member attribute name from the value field
 			memberNameBytes := make([]byte, memberNameLen)
-			if _, err := d.reader.Read(memberNameBytes); err != nil {
+			if _, err := io.ReadFull(d.reader, memberNameBytes); err != nil {
 				return nil, err
 			}
 			memberName := string(memberNameBytes)
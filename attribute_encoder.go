@@ -17,8 +17,12 @@ func NewAttributeEncoder(w io.Writer) *AttributeEncoder {
 }
 
 // Encode encodes a attribute and its value to a io.Writer
-// the tag is determined by the AttributeTagMapping map
+// the tag is determined by the AttributeTagMapping map, unless the value is a []Attribute with explicit tags
 func (e *AttributeEncoder) Encode(attribute string, value any) error {
+	if attrs, ok := value.([]Attribute); ok {
+		return e.EncodeAttributes(attribute, attrs)
+	}
+
 	tag, ok := AttributeTagMapping[attribute]
 	if !ok {
 		return fmt.Errorf("cannot get tag of attribute %s", attribute)
@@ -535,3 +539,112 @@ func (e *AttributeEncoder) encodeMemberAttributes(memberName string, attrs []Att
 
 	return nil
 }
+
+// EncodeAttributes encodes all values of an attribute with the tags stored in the attributes.
+// if an attribute has no tag, the tag is determined by the AttributeTagMapping map
+func (e *AttributeEncoder) EncodeAttributes(name string, attrs []Attribute) error {
+	for index, attr := range attrs {
+		tag := attr.Tag
+		if tag == 0 {
+			mappedTag, ok := AttributeTagMapping[name]
+			if !ok {
+				return fmt.Errorf("cannot get tag of attribute %s", name)
+			}
+			tag = mappedTag
+		}
+
+		if tag == TagBeginCollection {
+			col, ok := attr.Value.(Collection)
+			if !ok {
+				return fmt.Errorf("collection attribute %s has unsupported type %T", name, attr.Value)
+			}
+
+			if index == 0 {
+				if err := e.encodeCollection(name, col); err != nil {
+					return err
+				}
+			} else if err := e.encodeAdditionalCollection(col); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := e.encodeTag(tag); err != nil {
+			return err
+		}
+
+		if index == 0 {
+			if err := e.encodeString(name); err != nil {
+				return err
+			}
+		} else if err := e.writeNullByte(); err != nil {
+			return err
+		}
+
+		if err := e.encodeValue(tag, attr.Value); err != nil {
+			return fmt.Errorf("unable to encode attribute %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// encodeValue encodes a single value based on its tag, the value types match the ones returned by the AttributeDecoder
+func (e *AttributeEncoder) encodeValue(tag int8, value any) error {
+	if tag >= TagUnsupported && tag < TagInteger {
+		// out-of-band values have no value
+		return e.writeNullByte()
+	}
+
+	switch tag {
+	case TagInteger, TagEnum:
+		switch v := value.(type) {
+		case int:
+			return e.encodeInteger(int32(v))
+		case int8:
+			return e.encodeInteger(int32(v))
+		case int16:
+			return e.encodeInteger(int32(v))
+		case int32:
+			return e.encodeInteger(v)
+		case int64:
+			return e.encodeInteger(int32(v))
+		}
+	case TagBoolean:
+		if v, ok := value.(bool); ok {
+			return e.encodeBoolean(v)
+		}
+	case TagDate:
+		if v, ok := value.([]int); ok {
+			if err := binary.Write(e.writer, binary.BigEndian, int16(len(v))); err != nil {
+				return err
+			}
+			for _, i := range v {
+				if err := binary.Write(e.writer, binary.BigEndian, int8(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case TagRange:
+		if v, ok := value.([]int32); ok {
+			if err := binary.Write(e.writer, binary.BigEndian, int16(len(v)*4)); err != nil {
+				return err
+			}
+			return binary.Write(e.writer, binary.BigEndian, v)
+		}
+	case TagResolution:
+		if v, ok := value.(Resolution); ok {
+			if err := binary.Write(e.writer, binary.BigEndian, int16(9)); err != nil {
+				return err
+			}
+			return binary.Write(e.writer, binary.BigEndian, v)
+		}
+	default:
+		if v, ok := value.(string); ok {
+			return e.encodeString(v)
+		}
+	}
+
+	return fmt.Errorf("value type %T does not match tag 0x%02x", value, tag)
+}
@@ -3,6 +3,12 @@ package ipp
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
 	"strings"
 )
 
@@ -29,7 +35,7 @@ func (c *CUPSClient) GetDevices() (map[string]Attributes, error) {
 }
 
 func (c *CUPSClient) GetDevicesContext(ctx context.Context) (map[string]Attributes, error) {
-	req := NewRequest(OperationCupsGetDevices, 1)
+	req := NewRequest(OperationCupsGetDevices, c.nextRequestID())
 
 	resp, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("", nil), req, nil)
 	if err != nil {
@@ -51,7 +57,7 @@ func (c *CUPSClient) MoveJob(jobID int, destPrinter string) error {
 }
 
 func (c *CUPSClient) MoveJobContext(ctx context.Context, jobID int, destPrinter string) error {
-	req := NewRequest(OperationCupsMoveJob, 1)
+	req := NewRequest(OperationCupsMoveJob, c.nextRequestID())
 	req.OperationAttributes[AttributeJobURI] = c.getJobUri(jobID)
 	req.PrinterAttributes[AttributeJobPrinterURI] = c.getPrinterUri(destPrinter)
 
@@ -65,7 +71,7 @@ func (c *CUPSClient) MoveAllJob(srcPrinter, destPrinter string) error {
 }
 
 func (c *CUPSClient) MoveAllJobContext(ctx context.Context, srcPrinter, destPrinter string) error {
-	req := NewRequest(OperationCupsMoveJob, 1)
+	req := NewRequest(OperationCupsMoveJob, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(srcPrinter)
 	req.PrinterAttributes[AttributeJobPrinterURI] = c.getPrinterUri(destPrinter)
 
@@ -79,7 +85,7 @@ func (c *CUPSClient) GetPPDs() (map[string]Attributes, error) {
 }
 
 func (c *CUPSClient) GetPPDsContext(ctx context.Context) (map[string]Attributes, error) {
-	req := NewRequest(OperationCupsGetPPDs, 1)
+	req := NewRequest(OperationCupsGetPPDs, c.nextRequestID())
 
 	resp, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("", nil), req, nil)
 	if err != nil {
@@ -95,13 +101,99 @@ func (c *CUPSClient) GetPPDsContext(ctx context.Context) (map[string]Attributes,
 	return ppdNameMap, nil
 }
 
+// GetPPD returns the ppd file of an installed printer or of a ppd listed by GetPPDs. names containing a slash or colon
+// like drv:///sample.drv/generic.ppd and names ending with .ppd or .ppd.gz are treated as ppd names, all other names
+// as printers. use GetPrinterPPD or GetPPDByName for names like everywhere which can be both. if cups redirects to
+// another server with cups-see-other the file is downloaded from there. the returned reader must be closed
+func (c *CUPSClient) GetPPD(name string) (io.ReadCloser, error) {
+	return c.GetPPDContext(context.Background(), name)
+}
+
+func (c *CUPSClient) GetPPDContext(ctx context.Context, name string) (io.ReadCloser, error) {
+	if strings.ContainsAny(name, "/:") || strings.HasSuffix(name, ".ppd") || strings.HasSuffix(name, ".ppd.gz") {
+		return c.GetPPDByNameContext(ctx, name)
+	}
+	return c.GetPrinterPPDContext(ctx, name)
+}
+
+// GetPrinterPPD returns the ppd file of an installed printer, the returned reader must be closed
+func (c *CUPSClient) GetPrinterPPD(printer string) (io.ReadCloser, error) {
+	return c.GetPrinterPPDContext(context.Background(), printer)
+}
+
+func (c *CUPSClient) GetPrinterPPDContext(ctx context.Context, printer string) (io.ReadCloser, error) {
+	return c.getPPD(ctx, AttributePrinterURI, c.getPrinterUri(printer))
+}
+
+// GetPPDByName returns a ppd file listed by GetPPDs, the returned reader must be closed
+func (c *CUPSClient) GetPPDByName(ppdName string) (io.ReadCloser, error) {
+	return c.GetPPDByNameContext(context.Background(), ppdName)
+}
+
+func (c *CUPSClient) GetPPDByNameContext(ctx context.Context, ppdName string) (io.ReadCloser, error) {
+	return c.getPPD(ctx, AttributePPDName, ppdName)
+}
+
+// getPPD sends a CUPS-Get-PPD request for a printer-uri or ppd-name
+func (c *CUPSClient) getPPD(ctx context.Context, attribute, value string) (io.ReadCloser, error) {
+	req := NewRequest(OperationCupsGetPpd, c.nextRequestID())
+	req.OperationAttributes[attribute] = value
+
+	ppd := new(bytes.Buffer)
+	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("", nil), req, ppd)
+
+	// cups redirects to the ppd file of a remote printer with cups-see-other
+	var seeOther seeOtherError
+	if errors.As(err, &seeOther) {
+		location, err := ppdDownloadUrl(seeOther.Location)
+		if err != nil {
+			return nil, err
+		}
+		return download(ctx, c.adapter, location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(ppd), nil
+}
+
+// ppdDownloadUrl returns the http url of the ppd file a cups-see-other response points to
+func ppdDownloadUrl(location string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("cups-see-other response without %s", AttributePrinterURI)
+	}
+
+	uri, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid ppd location %s: %w", location, err)
+	}
+
+	switch uri.Scheme {
+	case "ipp", "http":
+		uri.Scheme = "http"
+	case "ipps", "https":
+		uri.Scheme = "https"
+	default:
+		return "", fmt.Errorf("unsupported ppd location %s", location)
+	}
+	if uri.Port() == "" {
+		uri.Host = net.JoinHostPort(uri.Hostname(), "631")
+	}
+	if !strings.HasSuffix(uri.Path, ".ppd") {
+		uri.Path += ".ppd"
+	}
+
+	return uri.String(), nil
+}
+
 // AcceptJobs lets a printer accept jobs again
 func (c *CUPSClient) AcceptJobs(printer string) error {
 	return c.AcceptJobsContext(context.Background(), printer)
 }
 
 func (c *CUPSClient) AcceptJobsContext(ctx context.Context, printer string) error {
-	req := NewRequest(OperationCupsAcceptJobs, 1)
+	req := NewRequest(OperationCupsAcceptJobs, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 
 	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("admin", ""), req, nil)
@@ -114,7 +206,7 @@ func (c *CUPSClient) RejectJobs(printer string) error {
 }
 
 func (c *CUPSClient) RejectJobsContext(ctx context.Context, printer string) error {
-	req := NewRequest(OperationCupsRejectJobs, 1)
+	req := NewRequest(OperationCupsRejectJobs, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 
 	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("admin", ""), req, nil)
@@ -149,7 +241,7 @@ func (c *CUPSClient) AddPrinterToClassContext(ctx context.Context, class, printe
 
 	memberURIList = append(memberURIList, c.getPrinterUri(printer))
 
-	req := NewRequest(OperationCupsAddModifyClass, 1)
+	req := NewRequest(OperationCupsAddModifyClass, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getClassUri(class)
 	req.PrinterAttributes[AttributeMemberURIs] = memberURIList
 
@@ -183,7 +275,7 @@ func (c *CUPSClient) DeletePrinterFromClassContext(ctx context.Context, class, p
 		return c.DeleteClassContext(ctx, class)
 	}
 
-	req := NewRequest(OperationCupsAddModifyClass, 1)
+	req := NewRequest(OperationCupsAddModifyClass, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getClassUri(class)
 	req.PrinterAttributes[AttributeMemberURIs] = memberURIList
 
@@ -197,7 +289,7 @@ func (c *CUPSClient) DeleteClass(class string) error {
 }
 
 func (c *CUPSClient) DeleteClassContext(ctx context.Context, class string) error {
-	req := NewRequest(OperationCupsDeleteClass, 1)
+	req := NewRequest(OperationCupsDeleteClass, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getClassUri(class)
 
 	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("admin", ""), req, nil)
@@ -210,7 +302,7 @@ func (c *CUPSClient) CreatePrinter(name, deviceURI, ppd string, shared bool, err
 }
 
 func (c *CUPSClient) CreatePrinterContext(ctx context.Context, name, deviceURI, ppd string, shared bool, errorPolicy string, information, location string) error {
-	req := NewRequest(OperationCupsAddModifyPrinter, 1)
+	req := NewRequest(OperationCupsAddModifyPrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(name)
 	req.OperationAttributes[AttributePPDName] = ppd
 	req.OperationAttributes[AttributePrinterIsShared] = shared
@@ -224,13 +316,65 @@ func (c *CUPSClient) CreatePrinterContext(ctx context.Context, name, deviceURI,
 	return err
 }
 
+// CreateLocalPrinter creates a temporary driverless queue for an ipp everywhere printer like lpadmin -m everywhere and
+// returns the uri of the created printer. the device uri must be an ipp or ipps uri of a network printer, cups removes
+// the queue again if it is not used
+func (c *CUPSClient) CreateLocalPrinter(name, deviceURI, information, location string) (string, error) {
+	return c.CreateLocalPrinterContext(context.Background(), name, deviceURI, information, location)
+}
+
+func (c *CUPSClient) CreateLocalPrinterContext(ctx context.Context, name, deviceURI, information, location string) (string, error) {
+	req := NewRequest(OperationCupsCreateLocalPrinter, c.nextRequestID())
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/"
+	req.PrinterAttributes[AttributePrinterName] = name
+	req.PrinterAttributes[AttributeDeviceURI] = deviceURI
+	if information != "" {
+		req.PrinterAttributes[AttributePrinterInfo] = information
+	}
+	if location != "" {
+		req.PrinterAttributes[AttributePrinterLocation] = location
+	}
+
+	resp, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("", nil), req, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.PrinterAttributes) == 0 {
+		return "", fmt.Errorf("no printer returned for local printer %s", name)
+	}
+
+	uri := attributeString(resp.PrinterAttributes[0], AttributePrinterUriSupported)
+	if uri == "" {
+		return "", fmt.Errorf("no printer uri returned for local printer %s", name)
+	}
+
+	return uri, nil
+}
+
+// ApplyPrinterConfig creates a printer or modifies an existing printer with the settings of the config. the ppd file
+// of the config is uploaded with the request
+func (c *CUPSClient) ApplyPrinterConfig(config PrinterConfig) error {
+	return c.ApplyPrinterConfigContext(context.Background(), config)
+}
+
+func (c *CUPSClient) ApplyPrinterConfigContext(ctx context.Context, config PrinterConfig) error {
+	req, err := config.request(c.getPrinterUri(config.Name), c.nextRequestID())
+	if err != nil {
+		return err
+	}
+
+	_, err = c.SendRequestContext(ctx, c.adapter.GetHttpUri("admin", ""), req, nil)
+	return err
+}
+
 // SetPrinterPPD sets the ppd for a printer
 func (c *CUPSClient) SetPrinterPPD(printer, ppd string) error {
 	return c.SetPrinterPPDContext(context.Background(), printer, ppd)
 }
 
 func (c *CUPSClient) SetPrinterPPDContext(ctx context.Context, printer, ppd string) error {
-	req := NewRequest(OperationCupsAddModifyPrinter, 1)
+	req := NewRequest(OperationCupsAddModifyPrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 	req.OperationAttributes[AttributePPDName] = ppd
 
@@ -244,7 +388,7 @@ func (c *CUPSClient) SetPrinterDeviceURI(printer, deviceURI string) error {
 }
 
 func (c *CUPSClient) SetPrinterDeviceURIContext(ctx context.Context, printer, deviceURI string) error {
-	req := NewRequest(OperationCupsAddModifyPrinter, 1)
+	req := NewRequest(OperationCupsAddModifyPrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 	req.PrinterAttributes[AttributeDeviceURI] = deviceURI
 
@@ -258,7 +402,7 @@ func (c *CUPSClient) SetPrinterIsShared(printer string, shared bool) error {
 }
 
 func (c *CUPSClient) SetPrinterIsSharedContext(ctx context.Context, printer string, shared bool) error {
-	req := NewRequest(OperationCupsAddModifyPrinter, 1)
+	req := NewRequest(OperationCupsAddModifyPrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 	req.OperationAttributes[AttributePrinterIsShared] = shared
 
@@ -272,7 +416,7 @@ func (c *CUPSClient) SetPrinterErrorPolicy(printer string, errorPolicy string) e
 }
 
 func (c *CUPSClient) SetPrinterErrorPolicyContext(ctx context.Context, printer string, errorPolicy string) error {
-	req := NewRequest(OperationCupsAddModifyPrinter, 1)
+	req := NewRequest(OperationCupsAddModifyPrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 	req.PrinterAttributes[AttributePrinterErrorPolicy] = errorPolicy
 
@@ -286,7 +430,7 @@ func (c *CUPSClient) SetPrinterInformation(printer, information string) error {
 }
 
 func (c *CUPSClient) SetPrinterInformationContext(ctx context.Context, printer, information string) error {
-	req := NewRequest(OperationCupsAddModifyPrinter, 1)
+	req := NewRequest(OperationCupsAddModifyPrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 	req.PrinterAttributes[AttributePrinterInfo] = information
 
@@ -300,7 +444,7 @@ func (c *CUPSClient) SetPrinterLocation(printer, location string) error {
 }
 
 func (c *CUPSClient) SetPrinterLocationContext(ctx context.Context, printer, location string) error {
-	req := NewRequest(OperationCupsAddModifyPrinter, 1)
+	req := NewRequest(OperationCupsAddModifyPrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 	req.PrinterAttributes[AttributePrinterLocation] = location
 
@@ -314,20 +458,115 @@ func (c *CUPSClient) DeletePrinter(printer string) error {
 }
 
 func (c *CUPSClient) DeletePrinterContext(ctx context.Context, printer string) error {
-	req := NewRequest(OperationCupsDeletePrinter, 1)
+	req := NewRequest(OperationCupsDeletePrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 
 	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("admin", ""), req, nil)
 	return err
 }
 
+// GetDefaultPrinter returns the default printer or class of the cups server
+func (c *CUPSClient) GetDefaultPrinter() (*Printer, error) {
+	return c.GetDefaultPrinterContext(context.Background())
+}
+
+func (c *CUPSClient) GetDefaultPrinterContext(ctx context.Context) (*Printer, error) {
+	req := NewRequest(OperationCupsGetDefault, c.nextRequestID())
+	req.OperationAttributes[AttributeRequestedAttributes] = DefaultPrinterAttributes
+
+	resp, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("", nil), req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.PrinterAttributes) == 0 {
+		return nil, IPPError{Status: StatusErrorNotFound, Message: "no default printer returned"}
+	}
+
+	return NewPrinterFromAttributes(resp.PrinterAttributes[0]), nil
+}
+
+// SetDefaultPrinter sets the default printer or class of the cups server
+func (c *CUPSClient) SetDefaultPrinter(printer string) error {
+	return c.SetDefaultPrinterContext(context.Background(), printer)
+}
+
+func (c *CUPSClient) SetDefaultPrinterContext(ctx context.Context, printer string) error {
+	req := NewRequest(OperationCupsSetDefault, c.nextRequestID())
+	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
+
+	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("admin", ""), req, nil)
+	return err
+}
+
+// ResolveDefaultPrinter returns the name of the printer cups clients like lp print to if no printer is given.
+// like cups the LPDEST and PRINTER environment variables are checked first, then the Default line of the user and
+// system lpoptions files and at last the default printer of the server. an instance suffix (printer/instance) is
+// removed from the name
+func (c *CUPSClient) ResolveDefaultPrinter() (string, error) {
+	return c.ResolveDefaultPrinterContext(context.Background())
+}
+
+func (c *CUPSClient) ResolveDefaultPrinterContext(ctx context.Context) (string, error) {
+	if name := defaultPrinterFromEnvironment(); name != "" {
+		return name, nil
+	}
+
+	if name := defaultPrinterFromLpoptions(); name != "" {
+		return name, nil
+	}
+
+	printer, err := c.GetDefaultPrinterContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return printer.Name, nil
+}
+
+// defaultPrinterFromEnvironment returns the printer of LPDEST or PRINTER, cups ignores PRINTER=lp
+func defaultPrinterFromEnvironment() string {
+	if name := os.Getenv("LPDEST"); name != "" {
+		return stripInstance(name)
+	}
+
+	if name := os.Getenv("PRINTER"); name != "" && name != "lp" {
+		return stripInstance(name)
+	}
+
+	return ""
+}
+
+// defaultPrinterFromLpoptions returns the default printer of the lpoptions files, the user file overrides the
+// system file. the files are read one by one, so a file which can not be parsed does not hide the default of the other
+func defaultPrinterFromLpoptions() string {
+	name := ""
+
+	for _, path := range lpOptionsPaths() {
+		options, err := ReadLpOptionsFile(path)
+		if err != nil {
+			continue
+		}
+		if dest := options.Default(); dest != nil {
+			name = dest.Name
+		}
+	}
+
+	return name
+}
+
+func stripInstance(name string) string {
+	name, _, _ = strings.Cut(name, "/")
+	return name
+}
+
 // GetPrinters returns a map of printer names and attributes
 func (c *CUPSClient) GetPrinters(attributes []string) (map[string]Attributes, error) {
 	return c.GetPrintersContext(context.Background(), attributes)
 }
 
 func (c *CUPSClient) GetPrintersContext(ctx context.Context, attributes []string) (map[string]Attributes, error) {
-	req := NewRequest(OperationCupsGetPrinters, 1)
+	req := NewRequest(OperationCupsGetPrinters, c.nextRequestID())
 
 	if attributes == nil {
 		req.OperationAttributes[AttributeRequestedAttributes] = DefaultPrinterAttributes
@@ -355,7 +594,7 @@ func (c *CUPSClient) GetClasses(attributes []string) (map[string]Attributes, err
 }
 
 func (c *CUPSClient) GetClassesContext(ctx context.Context, attributes []string) (map[string]Attributes, error) {
-	req := NewRequest(OperationCupsGetClasses, 1)
+	req := NewRequest(OperationCupsGetClasses, c.nextRequestID())
 
 	if attributes == nil {
 		req.OperationAttributes[AttributeRequestedAttributes] = DefaultClassAttributes
@@ -401,3 +640,72 @@ func (c *CUPSClient) PrintTestPageContext(ctx context.Context, printer string) (
 		AttributeJobName: "Test Page",
 	})
 }
+
+// JobDocument is the metadata of a document returned by GetDocument
+type JobDocument struct {
+	Number int
+	Format string
+	Name   string
+}
+
+// GetDocument writes the content of a document of a job to w and returns its metadata. documents are numbered from 1,
+// cups only keeps the documents of finished jobs if PreserveJobFiles is enabled
+func (c *CUPSClient) GetDocument(jobID, documentNumber int, w io.Writer) (*JobDocument, error) {
+	return c.GetDocumentContext(context.Background(), jobID, documentNumber, w)
+}
+
+func (c *CUPSClient) GetDocumentContext(ctx context.Context, jobID, documentNumber int, w io.Writer) (*JobDocument, error) {
+	req := NewRequest(OperationCupsGetDocument, c.nextRequestID())
+	req.OperationAttributes[AttributeJobURI] = c.getJobUri(jobID)
+	req.OperationAttributes[AttributeDocumentNumber] = documentNumber
+
+	resp, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("jobs", ""), req, w)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &JobDocument{
+		Number: documentNumber,
+		Format: attributeString(resp.OperationAttributes, AttributeDocumentFormat),
+		Name:   attributeString(resp.OperationAttributes, AttributeDocumentName),
+	}
+	if number := attributeInt(resp.OperationAttributes, AttributeDocumentNumber); number > 0 {
+		document.Number = number
+	}
+
+	return document, nil
+}
+
+// AuthenticateJob sends the credentials of a job held for authentication and releases it. the values of authInfo
+// must match the auth-info-required attribute of the printer, e.g. username and password
+func (c *CUPSClient) AuthenticateJob(jobID int, authInfo []string) error {
+	return c.AuthenticateJobContext(context.Background(), jobID, authInfo)
+}
+
+func (c *CUPSClient) AuthenticateJobContext(ctx context.Context, jobID int, authInfo []string) error {
+	if len(authInfo) == 0 {
+		return fmt.Errorf("auth info must not be empty")
+	}
+
+	req := NewRequest(OperationCupsAuthenticateJob, c.nextRequestID())
+	req.OperationAttributes[AttributeJobURI] = c.getJobUri(jobID)
+	req.OperationAttributes[AttributeAuthInfo] = authInfo
+
+	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("jobs", ""), req, nil)
+	return err
+}
+
+// IsJobHeldForAuthentication reports if a job is held until its credentials are sent with AuthenticateJob. the job
+// attributes must contain job-state and job-state-reasons
+func IsJobHeldForAuthentication(jobAttributes Attributes) bool {
+	if int8(attributeInt(jobAttributes, AttributeJobState)) != JobStateHeld {
+		return false
+	}
+
+	for _, reason := range attributeStrings(jobAttributes, AttributeJobStateReasons) {
+		if reason == JobStateReasonHeldForAuthentication {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,460 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockCUPSClient() (*CUPSClient, *MockAdapter) {
+	adapter := NewMockAdapter()
+	adapter.AddPrinter("printer-1", nil)
+	adapter.AddPrinter("printer-2", nil)
+
+	return NewCUPSClientWithAdapter("user", adapter), adapter
+}
+
+func TestCUPSClient_Jobs(t *testing.T) {
+	client, adapter := newMockCUPSClient()
+
+	jobID, err := client.PrintJob(Document{
+		Document: bytes.NewBufferString("test"),
+		Size:     4,
+		Name:     "test.txt",
+		MimeType: MimeTypeOctetStream,
+	}, "printer-1", map[string]any{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, jobID)
+
+	job, ok := adapter.Job(jobID)
+	assert.True(t, ok)
+	assert.Equal(t, [][]byte{[]byte("test")}, job.Documents)
+
+	jobID, err = client.PrintTestPage("printer-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, jobID)
+
+	jobs, err := client.GetJobs("printer-1", "", JobStateFilterNotCompleted, false, 0, 0, nil)
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 2)
+
+	assert.Nil(t, client.CancelJob(1, false))
+	assert.NotNil(t, client.CancelJob(1, false))
+
+	attributes, err := client.GetJobAttributes(1, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int(JobStateCanceled), attributes[AttributeJobState][0].Value)
+
+	assert.Nil(t, client.MoveJob(2, "printer-2"))
+	job, _ = adapter.Job(2)
+	assert.Equal(t, "printer-2", job.Printer)
+
+	assert.Nil(t, client.HoldJobUntil(2, "indefinite"))
+	job, _ = adapter.Job(2)
+	assert.Equal(t, JobStateHeld, job.State)
+
+	assert.Nil(t, client.CancelAllJob("printer-2", true))
+	jobs, err = client.GetJobs("", "", JobStateFilterCompleted, false, 0, 0, nil)
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 2)
+
+	assert.Nil(t, client.RejectJobs("printer-1"))
+	_, err = client.PrintTestPage("printer-1")
+	assert.True(t, errors.Is(err, ErrNotAcceptingJobs))
+}
+
+func TestCUPSClient_Printers(t *testing.T) {
+	client, _ := newMockCUPSClient()
+
+	assert.Nil(t, client.CreatePrinter("printer-3", "socket://10.0.0.1", "everywhere", true, ErrorPolicyAbortJob, "info", "location"))
+	assert.Nil(t, client.SetPrinterLocation("printer-3", "basement"))
+
+	printers, err := client.GetPrinters(nil)
+	assert.Nil(t, err)
+	assert.Len(t, printers, 3)
+	assert.Equal(t, "basement", printers["printer-3"][AttributePrinterLocation][0].Value)
+	assert.Equal(t, "socket://10.0.0.1", printers["printer-3"][AttributeDeviceURI][0].Value)
+
+	assert.Nil(t, client.AddPrinterToClass("class", "printer-1"))
+	assert.Nil(t, client.AddPrinterToClass("class", "printer-2"))
+
+	classes, err := client.GetClasses(nil)
+	assert.Nil(t, err)
+	assert.Len(t, classes["class"][AttributeMemberNames], 2)
+
+	assert.Nil(t, client.DeletePrinterFromClass("class", "printer-1"))
+	assert.Nil(t, client.DeletePrinterFromClass("class", "printer-2"))
+	classes, err = client.GetClasses(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, classes)
+
+	assert.Nil(t, client.DeletePrinter("printer-3"))
+	_, err = client.GetPrinterAttributes("printer-3", nil)
+	assert.True(t, IsNotExistsError(err))
+}
+
+func TestCUPSClient_ApplyPrinterConfig(t *testing.T) {
+	client, adapter := newMockCUPSClient()
+	shared := false
+	quotaPeriod := 24 * time.Hour
+	pageLimit := 100
+	kLimit := 0
+
+	assert.NoError(t, client.ApplyPrinterConfig(PrinterConfig{
+		Name:             "printer-3",
+		DeviceURI:        "socket://10.0.0.1",
+		PPD:              bytes.NewBufferString("*PPD-Adobe: \"4.3\"\n"),
+		Location:         "basement",
+		OpPolicy:         "authenticated",
+		PortMonitor:      "tbcp",
+		Shared:           &shared,
+		JobSheetsDefault: []string{"standard", "none"},
+		UsersAllowed:     []string{"alice", "@staff"},
+		JobQuotaPeriod:   &quotaPeriod,
+		JobPageLimit:     &pageLimit,
+		JobKLimit:        &kLimit,
+		Defaults: map[string]any{
+			AttributeSides:    "two-sided-long-edge",
+			AttributeNumberUp: 2,
+			"Staple":          "True",
+		},
+	}))
+
+	req := adapter.Requests()[0]
+	assert.Equal(t, OperationCupsAddModifyPrinter, req.Operation)
+	assert.Equal(t, false, req.OperationAttributes[AttributePrinterIsShared])
+	assert.NotContains(t, req.OperationAttributes, AttributePPDName)
+	assert.Equal(t, 0, req.PrinterAttributes[AttributeJobKLimit])
+	assert.Equal(t, 86400, req.PrinterAttributes[AttributeJobQuotaPeriod])
+
+	ppd, ok := adapter.PrinterPPD("printer-3")
+	assert.True(t, ok)
+	assert.Equal(t, "*PPD-Adobe: \"4.3\"\n", string(ppd))
+
+	attributes, err := client.GetPrinterAttributes("printer-3", []string{
+		AttributeDeviceURI, AttributeRequestingUserNameAllowed, AttributeJobSheetsDefault, AttributeJobPageLimit,
+		"sides-default", "number-up-default", "Staple-default",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "socket://10.0.0.1", attributes[AttributeDeviceURI][0].Value)
+	assert.Len(t, attributes[AttributeRequestingUserNameAllowed], 2)
+	assert.Len(t, attributes[AttributeJobSheetsDefault], 2)
+	assert.Equal(t, 100, attributes[AttributeJobPageLimit][0].Value)
+	assert.Equal(t, []Attribute{{Tag: TagKeyword, Name: "sides-default", Value: "two-sided-long-edge"}}, attributes["sides-default"])
+	assert.Equal(t, []Attribute{{Tag: TagInteger, Name: "number-up-default", Value: 2}}, attributes["number-up-default"])
+	assert.Equal(t, []Attribute{{Tag: TagName, Name: "Staple-default", Value: "True"}}, attributes["Staple-default"])
+
+	assert.Error(t, client.ApplyPrinterConfig(PrinterConfig{DeviceURI: "socket://10.0.0.1"}))
+	assert.Error(t, client.ApplyPrinterConfig(PrinterConfig{Name: "printer-3", UsersAllowed: []string{"alice"}, UsersDenied: []string{"bob"}}))
+	assert.Error(t, client.ApplyPrinterConfig(PrinterConfig{Name: "printer-3", Defaults: map[string]any{"Staple": 1.5}}))
+}
+
+func TestCUPSClient_CreateLocalPrinter(t *testing.T) {
+	client, adapter := newMockCUPSClient()
+
+	uri, err := client.CreateLocalPrinter("office", "ipps://office.local:631/ipp/print", "Office Printer", "2nd floor")
+	assert.NoError(t, err)
+	assert.Equal(t, "ipp://localhost/printers/office", uri)
+
+	req := adapter.Requests()[0]
+	assert.Equal(t, OperationCupsCreateLocalPrinter, req.Operation)
+	assert.Equal(t, "ipp://localhost/", req.OperationAttributes[AttributePrinterURI])
+	assert.Equal(t, map[string]any{
+		AttributePrinterName:     "office",
+		AttributeDeviceURI:       "ipps://office.local:631/ipp/print",
+		AttributePrinterInfo:     "Office Printer",
+		AttributePrinterLocation: "2nd floor",
+	}, req.PrinterAttributes)
+
+	attributes, err := client.GetPrinterAttributes("office", []string{AttributePrinterIsTemporary, AttributePrinterLocation})
+	assert.NoError(t, err)
+	assert.Equal(t, true, attributes[AttributePrinterIsTemporary][0].Value)
+	assert.Equal(t, "2nd floor", attributes[AttributePrinterLocation][0].Value)
+
+	_, err = client.CreateLocalPrinter("usb", "usb://Example/Laser", "", "")
+	assert.True(t, errors.Is(err, ErrBadRequest))
+}
+
+func TestCUPSClient_GetDocument(t *testing.T) {
+	client, _ := newMockCUPSClient()
+
+	jobID, err := client.PrintDocuments([]Document{
+		{Document: bytes.NewBufferString("%PDF-1.7"), Size: 8, Name: "first.pdf", MimeType: MimeTypePDF},
+		{Document: bytes.NewBufferString("plain text"), Size: 10, Name: "second.txt", MimeType: MimeTypeOctetStream},
+	}, "printer-1", map[string]any{})
+	assert.NoError(t, err)
+
+	var content bytes.Buffer
+	document, err := client.GetDocument(jobID, 1, &content)
+	assert.NoError(t, err)
+	assert.Equal(t, &JobDocument{Number: 1, Format: MimeTypePDF, Name: "first.pdf"}, document)
+	assert.Equal(t, "%PDF-1.7", content.String())
+
+	content.Reset()
+	document, err = client.GetDocument(jobID, 2, &content)
+	assert.NoError(t, err)
+	assert.Equal(t, MimeTypeOctetStream, document.Format)
+	assert.Equal(t, "plain text", content.String())
+
+	_, err = client.GetDocument(jobID, 3, io.Discard)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, err = client.GetDocument(42, 1, io.Discard)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestCUPSClient_AuthenticateJob(t *testing.T) {
+	client, adapter := newMockCUPSClient()
+	adapter.AddPrinter("secure", Attributes{
+		AttributeAuthInfoRequired: []Attribute{
+			{Tag: TagKeyword, Name: AttributeAuthInfoRequired, Value: "username"},
+			{Tag: TagKeyword, Name: AttributeAuthInfoRequired, Value: "password"},
+		},
+	})
+
+	jobID, err := client.PrintJob(Document{Document: bytes.NewBufferString("test"), Size: 4, Name: "test.txt", MimeType: MimeTypeOctetStream}, "secure", nil)
+	assert.NoError(t, err)
+
+	attributes, err := client.GetJobAttributes(jobID, nil)
+	assert.NoError(t, err)
+	assert.True(t, IsJobHeldForAuthentication(attributes))
+
+	assert.Error(t, client.AuthenticateJob(jobID, nil))
+	assert.NoError(t, client.AuthenticateJob(jobID, []string{"user", "secret"}))
+
+	requests := adapter.Requests()
+	assert.Equal(t, []string{"user", "secret"}, requests[len(requests)-1].OperationAttributes[AttributeAuthInfo])
+
+	job, _ := adapter.Job(jobID)
+	assert.Equal(t, JobStatePending, job.State)
+	assert.Equal(t, []string{"user", "secret"}, job.AuthInfo)
+
+	attributes, err = client.GetJobAttributes(jobID, nil)
+	assert.NoError(t, err)
+	assert.False(t, IsJobHeldForAuthentication(attributes))
+
+	var ippErr IPPError
+	err = client.AuthenticateJob(jobID, []string{"user", "secret"})
+	assert.True(t, errors.As(err, &ippErr))
+	assert.Equal(t, StatusErrorNotPossible, ippErr.Status)
+
+	jobID, err = client.PrintJob(Document{Document: bytes.NewBufferString("test"), Size: 4, Name: "test.txt", MimeType: MimeTypeOctetStream}, "printer-1", map[string]any{
+		AttributeHoldJobUntil: "indefinite",
+	})
+	assert.NoError(t, err)
+	attributes, err = client.GetJobAttributes(jobID, nil)
+	assert.NoError(t, err)
+	assert.False(t, IsJobHeldForAuthentication(attributes))
+}
+
+func TestCUPSClient_DefaultPrinter(t *testing.T) {
+	client, _ := newMockCUPSClient()
+
+	_, err := client.GetDefaultPrinter()
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	assert.NoError(t, client.SetDefaultPrinter("printer-2"))
+	printer, err := client.GetDefaultPrinter()
+	assert.NoError(t, err)
+	assert.Equal(t, "printer-2", printer.Name)
+	assert.Equal(t, "ipp://localhost/printers/printer-2", printer.URI)
+	assert.Equal(t, PrinterStateIdle, printer.State)
+	assert.Equal(t, []string{"none"}, printer.StateReasons)
+
+	assert.Error(t, client.SetDefaultPrinter("unknown"))
+}
+
+func TestCUPSClient_ResolveDefaultPrinter(t *testing.T) {
+	client, _ := newMockCUPSClient()
+	assert.NoError(t, client.SetDefaultPrinter("printer-1"))
+
+	home, serverRoot := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CUPS_SERVERROOT", serverRoot)
+	t.Setenv("LPDEST", "")
+	t.Setenv("PRINTER", "lp")
+
+	name, err := client.ResolveDefaultPrinter()
+	assert.NoError(t, err)
+	assert.Equal(t, "printer-1", name)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(serverRoot, "lpoptions"), []byte("Default system-printer\n"), 0o644))
+	name, err = client.ResolveDefaultPrinter()
+	assert.NoError(t, err)
+	assert.Equal(t, "system-printer", name)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(home, ".cups"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(home, ".cups", "lpoptions"), []byte("Dest other sides=two-sided-long-edge\nDefault user-printer/draft media=a4\n"), 0o644))
+	name, err = client.ResolveDefaultPrinter()
+	assert.NoError(t, err)
+	assert.Equal(t, "user-printer", name)
+
+	// a broken file does not hide the default of the other file
+	assert.NoError(t, os.WriteFile(filepath.Join(serverRoot, "lpoptions"), []byte("Default system-printer\nDest broken media=\"iso_a4\n"), 0o644))
+	name, err = client.ResolveDefaultPrinter()
+	assert.NoError(t, err)
+	assert.Equal(t, "user-printer", name)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(serverRoot, "lpoptions"), []byte("Default system-printer\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(home, ".cups", "lpoptions"), []byte("Dest broken media=\"iso_a4\n"), 0o644))
+	name, err = client.ResolveDefaultPrinter()
+	assert.NoError(t, err)
+	assert.Equal(t, "system-printer", name)
+	assert.NoError(t, os.WriteFile(filepath.Join(home, ".cups", "lpoptions"), []byte("Default user-printer\n"), 0o644))
+
+	t.Setenv("PRINTER", "env-printer")
+	name, err = client.ResolveDefaultPrinter()
+	assert.NoError(t, err)
+	assert.Equal(t, "env-printer", name)
+
+	t.Setenv("LPDEST", "lpdest-printer")
+	name, err = client.ResolveDefaultPrinter()
+	assert.NoError(t, err)
+	assert.Equal(t, "lpdest-printer", name)
+}
+
+// servePPD answers CUPS-Get-PPD requests like cups, the ppd of the remote printer is served by a cups-see-other
+// redirect
+func servePPD(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		if r.URL.Path != "/printers/remote.ppd" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, "*PPD-Adobe: remote")
+		return
+	}
+
+	req, err := NewRequestDecoder(r.Body).Decode(nil)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resp := NewResponse(StatusOk, req.RequestId)
+	var ppd string
+	switch {
+	case req.OperationAttributes[AttributePPDName] == "drv:///sample.drv/generic.ppd":
+		ppd = "*PPD-Adobe: generic"
+	case req.OperationAttributes[AttributePrinterURI] == "ipp://localhost/printers/local":
+		ppd = "*PPD-Adobe: local"
+	case req.OperationAttributes[AttributePrinterURI] == "ipp://localhost/printers/remote":
+		resp.StatusCode = StatusCupsSeeOther
+		resp.OperationAttributes[AttributePrinterURI] = []Attribute{{
+			Tag:   TagUri,
+			Name:  AttributePrinterURI,
+			Value: "ipp://" + r.Host + "/printers/remote",
+		}}
+	default:
+		resp.StatusCode = StatusErrorNotFound
+	}
+
+	data, err := resp.Encode()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentTypeIPP)
+	_, _ = w.Write(append(data, ppd...))
+}
+
+func TestCUPSClient_GetPPD(t *testing.T) {
+	adapter := newTestHttpAdapter(t, servePPD)
+	client := NewCUPSClientWithAdapter("user", NewRetryAdapter(adapter, DefaultRetryPolicy()))
+
+	for name, expected := range map[string]string{
+		"local":                         "*PPD-Adobe: local",
+		"remote":                        "*PPD-Adobe: remote",
+		"drv:///sample.drv/generic.ppd": "*PPD-Adobe: generic",
+	} {
+		ppd, err := client.GetPPD(name)
+		assert.NoError(t, err, name)
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(ppd)
+		assert.NoError(t, err)
+		assert.NoError(t, ppd.Close())
+		assert.Equal(t, expected, string(content))
+	}
+
+	_, err := client.GetPPD("unknown")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestCUPSClient_InjectedErrors(t *testing.T) {
+	client, adapter := newMockCUPSClient()
+
+	adapter.InjectError(OperationPausePrinter, IPPError{Status: StatusErrorNotAuthorized})
+	assert.True(t, errors.Is(client.PausePrinter("printer-1"), ErrNotAuthorized))
+	assert.Nil(t, client.PausePrinter("printer-1"))
+
+	adapter.InjectDelay(OperationGetPrinterAttributes, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetPrinterAttributesContext(ctx, "printer-1", nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestCUPSClient_GetPPDMock(t *testing.T) {
+	client, adapter := newMockCUPSClient()
+	adapter.SetPrinterPPD("printer-1", []byte("*PPD-Adobe: mock"))
+
+	ppd, err := client.GetPPD("printer-1")
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(ppd)
+	assert.Equal(t, "*PPD-Adobe: mock", string(content))
+
+	_, err = client.GetPPD("printer-2")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	ppd, err = client.GetPrinterPPD("printer-1")
+	assert.NoError(t, err)
+	content, _ = io.ReadAll(ppd)
+	assert.Equal(t, "*PPD-Adobe: mock", string(content))
+}
+
+func TestCUPSClient_GetPPDName(t *testing.T) {
+	client, adapter := newMockCUPSClient()
+
+	for name, ppdName := range map[string]bool{
+		"drv:///sample.drv/generic.ppd": true,
+		"laserjet.ppd":                  true,
+		"laserjet.ppd.gz":               true,
+		"everywhere":                    false,
+		"printer-1":                     false,
+	} {
+		_, _ = client.GetPPD(name)
+		req := adapter.Requests()[len(adapter.Requests())-1]
+		if ppdName {
+			assert.Equal(t, name, req.OperationAttributes[AttributePPDName], name)
+		} else {
+			assert.Equal(t, "ipp://localhost/printers/"+name, req.OperationAttributes[AttributePrinterURI], name)
+		}
+	}
+
+	_, _ = client.GetPPDByName("everywhere")
+	req := adapter.Requests()[len(adapter.Requests())-1]
+	assert.Equal(t, "everywhere", req.OperationAttributes[AttributePPDName])
+	assert.NotContains(t, req.OperationAttributes, AttributePrinterURI)
+}
+
+func TestCUPSClient_DeletePrinterFromClasses(t *testing.T) {
+	client, _ := newMockCUPSClient()
+	assert.NoError(t, client.AddPrinterToClass("class", "printer-1"))
+	assert.NoError(t, client.AddPrinterToClass("class", "printer-2"))
+
+	assert.NoError(t, client.DeletePrinter("printer-1"))
+
+	classes, err := client.GetClasses([]string{AttributeMemberNames})
+	assert.NoError(t, err)
+	assert.Equal(t, []Attribute{{Tag: TagName, Name: AttributeMemberNames, Value: "printer-2"}}, classes["class"][AttributeMemberNames])
+}
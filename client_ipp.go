@@ -7,6 +7,9 @@ import (
 	"io"
 	"os"
 	"path"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Document wraps an io.Reader with more information, needed for encoding
@@ -15,12 +18,19 @@ type Document struct {
 	Size     int
 	Name     string
 	MimeType string
+	// Compression compresses the document on the fly while uploading (gzip or deflate).
+	// it is only applied if the printer lists it in compression-supported, otherwise the document is sent uncompressed
+	Compression string
 }
 
 // IPPClient implements a generic ipp client
 type IPPClient struct {
-	username string
-	adapter  Adapter
+	username          string
+	adapter           Adapter
+	requestID         atomic.Int32
+	timeout           time.Duration
+	operationTimeouts map[int16]time.Duration
+	lpOptions         *LpOptions
 }
 
 // NewIPPClient creates a new generic ipp client (used HttpAdapter internally)
@@ -41,6 +51,61 @@ func NewIPPClientWithAdapter(username string, adapter Adapter) *IPPClient {
 	}
 }
 
+// Use wraps the adapter of the client with the given middlewares. it must be called before the client is used
+func (c *IPPClient) Use(middlewares ...Middleware) {
+	c.adapter = NewMiddlewareAdapter(c.adapter, middlewares...)
+}
+
+// SetTimeout sets the timeout for every operation, 0 disables the timeout. it must be called before the client is used
+func (c *IPPClient) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// SetOperationTimeout sets the timeout for a single operation, it overrides the timeout set by SetTimeout.
+// it must be called before the client is used
+func (c *IPPClient) SetOperationTimeout(operation int16, timeout time.Duration) {
+	if c.operationTimeouts == nil {
+		c.operationTimeouts = make(map[int16]time.Duration)
+	}
+	c.operationTimeouts[operation] = timeout
+}
+
+// SetLpOptions sets the saved destination options applied by PrintJob and PrintDocuments like lp does, see
+// LoadLpOptions. the printer may be given as printer/instance then. it must be called before the client is used
+func (c *IPPClient) SetLpOptions(options *LpOptions) {
+	c.lpOptions = options
+}
+
+// applyLpOptions returns the printer without instance and the job attributes merged with the saved options of the
+// destination, the given job attributes take precedence
+func (c *IPPClient) applyLpOptions(printer string, jobAttributes map[string]any) (string, map[string]any, error) {
+	if c.lpOptions == nil {
+		return printer, jobAttributes, nil
+	}
+
+	name, instance, _ := strings.Cut(printer, "/")
+	attributes, err := c.lpOptions.JobAttributes(name, instance)
+	if err != nil {
+		return "", nil, err
+	}
+	for key, value := range jobAttributes {
+		attributes[key] = value
+	}
+
+	return name, attributes, nil
+}
+
+// nextRequestID allocates a new request id, ids are unique per client and start again at 1 after an overflow
+func (c *IPPClient) nextRequestID() int32 {
+	for {
+		id := c.requestID.Add(1)
+		if id > 0 {
+			return id
+		}
+		c.requestID.CompareAndSwap(id, 0)
+	}
+}
+
 func (c *IPPClient) getPrinterUri(printer string) string {
 	return fmt.Sprintf("ipp://localhost/printers/%s", printer)
 }
@@ -53,7 +118,41 @@ func (c *IPPClient) getClassUri(printer string) string {
 	return fmt.Sprintf("ipp://localhost/classes/%s", printer)
 }
 
-// SendRequest sends a request to a remote uri end returns the response
+// getCompressionAttributes fetches the compression-supported attribute of a printer if any document requests a compression
+func (c *IPPClient) getCompressionAttributes(ctx context.Context, printer string, docs []Document) (Attributes, error) {
+	for _, doc := range docs {
+		if doc.Compression != "" && doc.Compression != CompressionNone {
+			return c.GetPrinterAttributesContext(ctx, printer, []string{AttributeCompressionSupported})
+		}
+	}
+
+	return nil, nil
+}
+
+// setRequestDocument attaches a document to a request and compresses it if the printer supports the requested compression.
+// the returned closer must be closed after the request was sent, it is nil if the document is not compressed
+func setRequestDocument(req *Request, doc Document, printerAttributes Attributes) (io.Closer, error) {
+	req.File = doc.Document
+	req.FileSize = doc.Size
+
+	if doc.Compression == "" || doc.Compression == CompressionNone || !IsCompressionSupported(printerAttributes, doc.Compression) {
+		return nil, nil
+	}
+
+	compressed, err := NewCompressReader(doc.Document, doc.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	req.OperationAttributes[AttributeCompression] = doc.Compression
+	req.File = compressed
+	req.FileSize = -1
+
+	return compressed, nil
+}
+
+// SendRequest sends a request to a remote uri end returns the response.
+// a request id is allocated if the request has none, the response must carry the same request id
 func (c *IPPClient) SendRequest(url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
 	return c.SendRequestContext(context.Background(), url, req, additionalResponseData)
 }
@@ -63,7 +162,34 @@ func (c *IPPClient) SendRequestContext(ctx context.Context, url string, req *Req
 		req.OperationAttributes[AttributeRequestingUserName] = c.username
 	}
 
-	return c.adapter.SendRequest(url, req, additionalResponseData)
+	if req.RequestId == 0 {
+		req.RequestId = c.nextRequestID()
+	}
+
+	timeout := c.timeout
+	if operationTimeout, ok := c.operationTimeouts[req.Operation]; ok {
+		timeout = operationTimeout
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	resp, err := c.adapter.SendRequestContext(ctx, url, req, additionalResponseData)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.RequestId != req.RequestId {
+		return nil, RequestIDMismatchError{
+			RequestID:  req.RequestId,
+			ResponseID: resp.RequestId,
+		}
+	}
+
+	return resp, nil
 }
 
 // PrintDocuments prints one or more documents using a Create-Job operation followed by one or more Send-Document operation(s). custom job settings can be specified via the jobAttributes parameter
@@ -72,9 +198,18 @@ func (c *IPPClient) PrintDocuments(docs []Document, printer string, jobAttribute
 }
 
 func (c *IPPClient) PrintDocumentsContext(ctx context.Context, docs []Document, printer string, jobAttributes map[string]any) (int, error) {
+	printer, jobAttributes, err := c.applyLpOptions(printer, jobAttributes)
+	if err != nil {
+		return -1, err
+	}
 	printerURI := c.getPrinterUri(printer)
 
-	req := NewRequest(OperationCreateJob, 1)
+	printerAttributes, err := c.getCompressionAttributes(ctx, printer, docs)
+	if err != nil {
+		return -1, err
+	}
+
+	req := NewRequest(OperationCreateJob, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = printerURI
 	req.OperationAttributes[AttributeRequestingUserName] = c.username
 
@@ -101,17 +236,23 @@ func (c *IPPClient) PrintDocumentsContext(ctx context.Context, docs []Document,
 	documentCount := len(docs) - 1
 
 	for docID, doc := range docs {
-		req = NewRequest(OperationSendDocument, 2)
+		req = NewRequest(OperationSendDocument, c.nextRequestID())
 		req.OperationAttributes[AttributePrinterURI] = printerURI
 		req.OperationAttributes[AttributeRequestingUserName] = c.username
 		req.OperationAttributes[AttributeJobID] = jobID
 		req.OperationAttributes[AttributeDocumentName] = doc.Name
 		req.OperationAttributes[AttributeDocumentFormat] = doc.MimeType
 		req.OperationAttributes[AttributeLastDocument] = docID == documentCount
-		req.File = doc.Document
-		req.FileSize = doc.Size
+
+		closer, err := setRequestDocument(req, doc, printerAttributes)
+		if err != nil {
+			return -1, err
+		}
 
 		_, err = c.SendRequestContext(ctx, c.adapter.GetHttpUri("printers", printer), req, nil)
+		if closer != nil {
+			_ = closer.Close()
+		}
 		if err != nil {
 			return -1, err
 		}
@@ -126,9 +267,18 @@ func (c *IPPClient) PrintJob(doc Document, printer string, jobAttributes map[str
 }
 
 func (c *IPPClient) PrintJobContext(ctx context.Context, doc Document, printer string, jobAttributes map[string]any) (int, error) {
+	printer, jobAttributes, err := c.applyLpOptions(printer, jobAttributes)
+	if err != nil {
+		return -1, err
+	}
 	printerURI := c.getPrinterUri(printer)
 
-	req := NewRequest(OperationPrintJob, 1)
+	printerAttributes, err := c.getCompressionAttributes(ctx, printer, []Document{doc})
+	if err != nil {
+		return -1, err
+	}
+
+	req := NewRequest(OperationPrintJob, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = printerURI
 	req.OperationAttributes[AttributeRequestingUserName] = c.username
 	req.OperationAttributes[AttributeJobName] = doc.Name
@@ -142,8 +292,13 @@ func (c *IPPClient) PrintJobContext(ctx context.Context, doc Document, printer s
 		req.JobAttributes[key] = value
 	}
 
-	req.File = doc.Document
-	req.FileSize = doc.Size
+	closer, err := setRequestDocument(req, doc, printerAttributes)
+	if err != nil {
+		return -1, err
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
 
 	resp, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("printers", printer), req, nil)
 	if err != nil {
@@ -196,7 +351,7 @@ func (c *IPPClient) GetPrinterAttributes(printer string, attributes []string) (A
 }
 
 func (c *IPPClient) GetPrinterAttributesContext(ctx context.Context, printer string, attributes []string) (Attributes, error) {
-	req := NewRequest(OperationGetPrinterAttributes, 1)
+	req := NewRequest(OperationGetPrinterAttributes, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 	req.OperationAttributes[AttributeRequestingUserName] = c.username
 
@@ -224,7 +379,7 @@ func (c *IPPClient) ResumePrinter(printer string) error {
 }
 
 func (c *IPPClient) ResumePrinterContext(ctx context.Context, printer string) error {
-	req := NewRequest(OperationResumePrinter, 1)
+	req := NewRequest(OperationResumePrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 
 	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("admin", ""), req, nil)
@@ -237,7 +392,7 @@ func (c *IPPClient) PausePrinter(printer string) error {
 }
 
 func (c *IPPClient) PausePrinterContext(ctx context.Context, printer string) error {
-	req := NewRequest(OperationPausePrinter, 1)
+	req := NewRequest(OperationPausePrinter, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 
 	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("admin", ""), req, nil)
@@ -250,7 +405,7 @@ func (c *IPPClient) GetJobAttributes(jobID int, attributes []string) (Attributes
 }
 
 func (c *IPPClient) GetJobAttributesContext(ctx context.Context, jobID int, attributes []string) (Attributes, error) {
-	req := NewRequest(OperationGetJobAttributes, 1)
+	req := NewRequest(OperationGetJobAttributes, c.nextRequestID())
 	req.OperationAttributes[AttributeJobURI] = c.getJobUri(jobID)
 
 	if attributes == nil {
@@ -277,7 +432,7 @@ func (c *IPPClient) GetJobs(printer, class string, whichJobs string, myJobs bool
 }
 
 func (c *IPPClient) GetJobsContext(ctx context.Context, printer, class string, whichJobs string, myJobs bool, firstJobId, limit int, attributes []string) (map[int]Attributes, error) {
-	req := NewRequest(OperationGetJobs, 1)
+	req := NewRequest(OperationGetJobs, c.nextRequestID())
 	req.OperationAttributes[AttributeWhichJobs] = whichJobs
 	req.OperationAttributes[AttributeMyJobs] = myJobs
 
@@ -327,7 +482,7 @@ func (c *IPPClient) CancelJob(jobID int, purge bool) error {
 }
 
 func (c *IPPClient) CancelJobContext(ctx context.Context, jobID int, purge bool) error {
-	req := NewRequest(OperationCancelJob, 1)
+	req := NewRequest(OperationCancelJob, c.nextRequestID())
 	req.OperationAttributes[AttributeJobURI] = c.getJobUri(jobID)
 	req.OperationAttributes[AttributePurgeJobs] = purge
 
@@ -341,7 +496,7 @@ func (c *IPPClient) CancelAllJob(printer string, purge bool) error {
 }
 
 func (c *IPPClient) CancelAllJobContext(ctx context.Context, printer string, purge bool) error {
-	req := NewRequest(OperationCancelJobs, 1)
+	req := NewRequest(OperationCancelJobs, c.nextRequestID())
 	req.OperationAttributes[AttributePrinterURI] = c.getPrinterUri(printer)
 	req.OperationAttributes[AttributePurgeJobs] = purge
 
@@ -355,7 +510,7 @@ func (c *IPPClient) RestartJob(jobID int) error {
 }
 
 func (c *IPPClient) RestartJobContext(ctx context.Context, jobID int) error {
-	req := NewRequest(OperationRestartJob, 1)
+	req := NewRequest(OperationRestartJob, c.nextRequestID())
 	req.OperationAttributes[AttributeJobURI] = c.getJobUri(jobID)
 
 	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("jobs", ""), req, nil)
@@ -368,7 +523,7 @@ func (c *IPPClient) HoldJobUntil(jobID int, holdUntil string) error {
 }
 
 func (c *IPPClient) HoldJobUntilContext(ctx context.Context, jobID int, holdUntil string) error {
-	req := NewRequest(OperationRestartJob, 1)
+	req := NewRequest(OperationRestartJob, c.nextRequestID())
 	req.OperationAttributes[AttributeJobURI] = c.getJobUri(jobID)
 	req.JobAttributes[AttributeHoldJobUntil] = holdUntil
 
@@ -378,5 +533,11 @@ func (c *IPPClient) HoldJobUntilContext(ctx context.Context, jobID int, holdUnti
 
 // TestConnection tests if a tcp connection to the remote server is possible
 func (c *IPPClient) TestConnection() error {
-	return c.adapter.TestConnection()
+	return c.TestConnectionContext(context.Background())
+}
+
+// TestConnectionContext tests the connection to the server, the context is only honored by adapters which implement
+// TestConnectionContext
+func (c *IPPClient) TestConnectionContext(ctx context.Context) error {
+	return testConnection(ctx, c.adapter)
 }
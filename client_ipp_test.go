@@ -0,0 +1,56 @@
+package ipp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPPClient_RequestIDs(t *testing.T) {
+	client := NewIPPClientWithAdapter("user", &retryTestAdapter{})
+
+	first := NewRequest(OperationGetJobs, 0)
+	_, err := client.SendRequest("", first, nil)
+	assert.Nil(t, err)
+
+	second := NewRequest(OperationGetJobs, 0)
+	_, err = client.SendRequest("", second, nil)
+	assert.Nil(t, err)
+
+	assert.Equal(t, int32(1), first.RequestId)
+	assert.Equal(t, int32(2), second.RequestId)
+
+	client.requestID.Store(1<<31 - 1)
+	assert.Equal(t, int32(1), client.nextRequestID())
+}
+
+func TestIPPClient_RequestIDMismatch(t *testing.T) {
+	client := NewIPPClientWithAdapter("user", NewMiddlewareAdapter(&retryTestAdapter{}, func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+			resp, err := next.RoundTrip(ctx, url, req, additionalResponseData)
+			resp.RequestId++
+			return resp, err
+		})
+	}))
+
+	_, err := client.SendRequest("", NewRequest(OperationGetJobs, 7), nil)
+
+	var mismatchErr RequestIDMismatchError
+	assert.True(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, int32(7), mismatchErr.RequestID)
+	assert.Equal(t, int32(8), mismatchErr.ResponseID)
+}
+
+func TestIPPClient_TestConnectionContext(t *testing.T) {
+	// adapters without TestConnectionContext are tested with TestConnection
+	client := NewIPPClientWithAdapter("user", NewRetryAdapter(&retryTestAdapter{}, DefaultRetryPolicy()))
+	assert.NoError(t, client.TestConnectionContext(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client = NewIPPClientWithAdapter("user", newTestHttpAdapter(t, nil))
+	assert.ErrorIs(t, client.TestConnectionContext(ctx), context.Canceled)
+}
@@ -0,0 +1,34 @@
+// virtual-printer serves a virtual IPP Everywhere printer which writes all received jobs to a spool directory
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/phin1x/go-ipp"
+)
+
+func main() {
+	listen := flag.String("listen", ":8631", "address to listen on")
+	name := flag.String("name", "virtual-printer", "printer name")
+	spool := flag.String("spool", "spool", "spool directory for received jobs")
+	processingTime := flag.Duration("processing-time", 0, "time a job stays in the processing state")
+	flag.Parse()
+
+	printer, err := ipp.NewVirtualPrinter(ipp.VirtualPrinterConfig{
+		Name:           *name,
+		SpoolDir:       *spool,
+		ProcessingTime: *processingTime,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("serving %s on %s, spooling to %s", *name, *listen, *spool)
+	err = http.ListenAndServe(*listen, printer)
+
+	// log.Fatal skips deferred calls, so the printer is closed before exiting
+	printer.Close()
+	log.Fatal(err)
+}
@@ -0,0 +1,55 @@
+package ipp
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// IsCompressionSupported checks whether the given compression is listed in a compression-supported attribute
+func IsCompressionSupported(attributes Attributes, compression string) bool {
+	for _, attr := range attributes[AttributeCompressionSupported] {
+		if value, ok := attr.Value.(string); ok && value == compression {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewCompressReader returns a reader which compresses r on the fly with the given compression.
+// the returned reader must be closed to release the compressing goroutine
+func NewCompressReader(r io.Reader, compression string) (io.ReadCloser, error) {
+	var newWriter func(w io.Writer) (io.WriteCloser, error)
+
+	switch compression {
+	case CompressionGzip:
+		newWriter = func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		}
+	case CompressionDeflate:
+		newWriter = func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, flate.DefaultCompression)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %s", compression)
+	}
+
+	pr, pw := io.Pipe()
+
+	cw, err := newWriter(pw)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if _, err := io.Copy(cw, r); err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+		_ = pw.CloseWithError(cw.Close())
+	}()
+
+	return pr, nil
+}
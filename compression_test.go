@@ -0,0 +1,49 @@
+package ipp
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCompressReader(t *testing.T) {
+	data := bytes.Repeat([]byte("%PDF-1.7 go-ipp compression test "), 1024)
+
+	gz, err := NewCompressReader(bytes.NewReader(data), CompressionGzip)
+	assert.Nil(t, err)
+	defer gz.Close()
+
+	gzReader, err := gzip.NewReader(gz)
+	assert.Nil(t, err)
+	decompressed, err := io.ReadAll(gzReader)
+	assert.Nil(t, err)
+	assert.Equal(t, data, decompressed)
+
+	deflate, err := NewCompressReader(bytes.NewReader(data), CompressionDeflate)
+	assert.Nil(t, err)
+	defer deflate.Close()
+
+	decompressed, err = io.ReadAll(flate.NewReader(deflate))
+	assert.Nil(t, err)
+	assert.Equal(t, data, decompressed)
+
+	_, err = NewCompressReader(bytes.NewReader(data), "compress")
+	assert.NotNil(t, err)
+}
+
+func TestIsCompressionSupported(t *testing.T) {
+	attributes := Attributes{
+		AttributeCompressionSupported: []Attribute{
+			{Tag: TagKeyword, Name: AttributeCompressionSupported, Value: CompressionNone},
+			{Tag: TagKeyword, Name: AttributeCompressionSupported, Value: CompressionGzip},
+		},
+	}
+
+	assert.True(t, IsCompressionSupported(attributes, CompressionGzip))
+	assert.False(t, IsCompressionSupported(attributes, CompressionDeflate))
+	assert.False(t, IsCompressionSupported(nil, CompressionGzip))
+}
@@ -67,6 +67,20 @@ const (
 	StatusErrorCupsUpgradeRequired            int16 = 0x1002
 )
 
+// status codes of the ipp registry between 0x040f and 0x0420 used by this package. the exported constants of this
+// range are shifted by one against the registry and kept for compatibility
+const (
+	statusErrorCompressionNotSupported    int16 = 0x040f
+	statusErrorCompressionError           int16 = 0x0410
+	statusErrorDocumentFormatError        int16 = 0x0411
+	statusErrorDocumentAccessError        int16 = 0x0412
+	statusErrorDocumentUnprintableError   int16 = 0x041b
+	statusErrorAccountInfoNeeded          int16 = 0x041c
+	statusErrorAccountClosed              int16 = 0x041d
+	statusErrorAccountLimitReached        int16 = 0x041e
+	statusErrorAccountAuthorizationFailed int16 = 0x041f
+)
+
 // ipp operations
 const (
 	OperationCupsInvalid                     int16 = -0x0001
@@ -260,6 +274,13 @@ const (
 	PrinterStateStopped    int8 = 0x0005
 )
 
+// job state reasons
+const (
+	JobStateReasonNone                  = "none"
+	JobStateReasonJobHoldUntilSpecified = "job-hold-until-specified"
+	JobStateReasonHeldForAuthentication = "cups-held-for-authentication"
+)
+
 // job state filter
 const (
 	JobStateFilterNotCompleted = "not-completed"
@@ -289,6 +310,17 @@ const (
 const (
 	MimeTypePostscript  = "application/postscript"
 	MimeTypeOctetStream = "application/octet-stream"
+	MimeTypePDF         = "application/pdf"
+	MimeTypePWGRaster   = "image/pwg-raster"
+	MimeTypeURF         = "image/urf"
+	MimeTypeJPEG        = "image/jpeg"
+)
+
+// document compressions
+const (
+	CompressionNone    = "none"
+	CompressionGzip    = "gzip"
+	CompressionDeflate = "deflate"
 )
 
 // ipp content types
@@ -298,84 +330,117 @@ const (
 
 // known ipp attributes
 const (
-	AttributeCopies                  = "copies"
-	AttributeDocumentFormat          = "document-format"
-	AttributeDocumentName            = "document-name"
-	AttributeJobID                   = "job-id"
-	AttributeJobName                 = "job-name"
-	AttributeJobPriority             = "job-priority"
-	AttributeJobURI                  = "job-uri"
-	AttributeLastDocument            = "last-document"
-	AttributeMyJobs                  = "my-jobs"
-	AttributePPDName                 = "ppd-name"
-	AttributePPDMakeAndModel         = "ppd-make-and-model"
-	AttributePrinterIsShared         = "printer-is-shared"
-	AttributePrinterIsTemporary      = "printer-is-temporary"
-	AttributePrinterURI              = "printer-uri"
-	AttributePurgeJobs               = "purge-jobs"
-	AttributeRequestedAttributes     = "requested-attributes"
-	AttributeRequestingUserName      = "requesting-user-name"
-	AttributeWhichJobs               = "which-jobs"
-	AttributeFirstJobID              = "first-job-id"
-	AttributeLimit                   = "limit"
-	AttributeStatusMessage           = "status-message"
-	AttributeCharset                 = "attributes-charset"
-	AttributeNaturalLanguage         = "attributes-natural-language"
-	AttributeDeviceURI               = "device-uri"
-	AttributeHoldJobUntil            = "job-hold-until"
-	AttributePrinterErrorPolicy      = "printer-error-policy"
-	AttributePrinterInfo             = "printer-info"
-	AttributePrinterLocation         = "printer-location"
-	AttributePrinterName             = "printer-name"
-	AttributePrinterStateReasons     = "printer-state-reasons"
-	AttributeJobPrinterURI           = "job-printer-uri"
-	AttributeMemberURIs              = "member-uris"
-	AttributeDocumentNumber          = "document-number"
-	AttributeDocumentState           = "document-state"
-	AttributeFinishings              = "finishings"
-	AttributeJobHoldUntil            = "hold-job-until"
-	AttributeJobSheets               = "job-sheets"
-	AttributeJobState                = "job-state"
-	AttributeJobStateReason          = "job-state-reason"
-	AttributeMedia                   = "media"
-	AttributeSides                   = "sides"
-	AttributeNumberUp                = "number-up"
-	AttributeOrientationRequested    = "orientation-requested"
-	AttributePrintQuality            = "print-quality"
-	AttributePrinterIsAcceptingJobs  = "printer-is-accepting-jobs"
-	AttributePrinterResolution       = "printer-resolution"
-	AttributePrinterState            = "printer-state"
-	AttributeMemberNames             = "member-names"
-	AttributePrinterType             = "printer-type"
-	AttributePrinterMakeAndModel     = "printer-make-and-model"
-	AttributePrinterStateMessage     = "printer-state-message"
-	AttributePrinterUriSupported     = "printer-uri-supported"
-	AttributeJobMediaProgress        = "job-media-progress"
-	AttributeJobKilobyteOctets       = "job-k-octets"
-	AttributeNumberOfDocuments       = "number-of-documents"
-	AttributeJobOriginatingUserName  = "job-originating-user-name"
-	AttributeOutputOrder             = "outputorder"
-	AttributeJobStateReasons         = "job-state-reasons"
-	AttributeJobStateMessage         = "job-state-message"
-	AttributeJobPrinterStateReasons  = "job-printer-state-reasons"
-	AttributeJobPrinterStateMessage  = "job-printer-state-message"
-	AttributeJobImpressionsCompleted = "job-impressions-completed"
-	AttributePrintScaling            = "print-scaling"
-	AttributeMediaCol                = "media-col"
-	AttributeMediaColReady           = "media-col-ready"
-	AttributeMediaColDatabase        = "media-col-database"
-	AttributeMediaColDefault         = "media-col-default"
-	AttributeMediaSize               = "media-size"
-	AttributeMediaSizeName           = "media-size-name"
-	AttributeMediaSource             = "media-source"
-	AttributeMediaType               = "media-type"
-	AttributeMediaColor              = "media-color"
-	AttributeMediaLeftMargin         = "media-left-margin"
-	AttributeMediaRightMargin        = "media-right-margin"
-	AttributeMediaTopMargin          = "media-top-margin"
-	AttributeMediaBottomMargin       = "media-bottom-margin"
-	AttributeXDimension              = "x-dimension"
-	AttributeYDimension              = "y-dimension"
+	AttributeCopies                            = "copies"
+	AttributeDocumentFormat                    = "document-format"
+	AttributeDocumentName                      = "document-name"
+	AttributeJobID                             = "job-id"
+	AttributeJobName                           = "job-name"
+	AttributeJobPriority                       = "job-priority"
+	AttributeJobURI                            = "job-uri"
+	AttributeLastDocument                      = "last-document"
+	AttributeMyJobs                            = "my-jobs"
+	AttributePPDName                           = "ppd-name"
+	AttributePPDMakeAndModel                   = "ppd-make-and-model"
+	AttributePrinterIsShared                   = "printer-is-shared"
+	AttributePrinterIsTemporary                = "printer-is-temporary"
+	AttributePrinterURI                        = "printer-uri"
+	AttributePurgeJobs                         = "purge-jobs"
+	AttributeRequestedAttributes               = "requested-attributes"
+	AttributeRequestingUserName                = "requesting-user-name"
+	AttributeWhichJobs                         = "which-jobs"
+	AttributeFirstJobID                        = "first-job-id"
+	AttributeLimit                             = "limit"
+	AttributeStatusMessage                     = "status-message"
+	AttributeCharset                           = "attributes-charset"
+	AttributeNaturalLanguage                   = "attributes-natural-language"
+	AttributeDeviceURI                         = "device-uri"
+	AttributeHoldJobUntil                      = "job-hold-until"
+	AttributePrinterErrorPolicy                = "printer-error-policy"
+	AttributePrinterInfo                       = "printer-info"
+	AttributePrinterLocation                   = "printer-location"
+	AttributePrinterName                       = "printer-name"
+	AttributePrinterStateReasons               = "printer-state-reasons"
+	AttributeJobPrinterURI                     = "job-printer-uri"
+	AttributeMemberURIs                        = "member-uris"
+	AttributeDocumentNumber                    = "document-number"
+	AttributeDocumentState                     = "document-state"
+	AttributeFinishings                        = "finishings"
+	AttributeJobHoldUntil                      = "hold-job-until"
+	AttributeJobSheets                         = "job-sheets"
+	AttributeJobState                          = "job-state"
+	AttributeJobStateReason                    = "job-state-reason"
+	AttributeMedia                             = "media"
+	AttributeSides                             = "sides"
+	AttributeNumberUp                          = "number-up"
+	AttributeOrientationRequested              = "orientation-requested"
+	AttributePrintQuality                      = "print-quality"
+	AttributePrinterIsAcceptingJobs            = "printer-is-accepting-jobs"
+	AttributePrinterResolution                 = "printer-resolution"
+	AttributePrinterState                      = "printer-state"
+	AttributeMemberNames                       = "member-names"
+	AttributePrinterType                       = "printer-type"
+	AttributePrinterMakeAndModel               = "printer-make-and-model"
+	AttributePrinterStateMessage               = "printer-state-message"
+	AttributePrinterUriSupported               = "printer-uri-supported"
+	AttributeJobMediaProgress                  = "job-media-progress"
+	AttributeJobKilobyteOctets                 = "job-k-octets"
+	AttributeNumberOfDocuments                 = "number-of-documents"
+	AttributeJobOriginatingUserName            = "job-originating-user-name"
+	AttributeOutputOrder                       = "outputorder"
+	AttributeJobStateReasons                   = "job-state-reasons"
+	AttributeJobStateMessage                   = "job-state-message"
+	AttributeJobPrinterStateReasons            = "job-printer-state-reasons"
+	AttributeJobPrinterStateMessage            = "job-printer-state-message"
+	AttributeJobImpressionsCompleted           = "job-impressions-completed"
+	AttributePrintScaling                      = "print-scaling"
+	AttributeMediaCol                          = "media-col"
+	AttributeMediaColReady                     = "media-col-ready"
+	AttributeMediaColDatabase                  = "media-col-database"
+	AttributeMediaColDefault                   = "media-col-default"
+	AttributeMediaSize                         = "media-size"
+	AttributeMediaSizeName                     = "media-size-name"
+	AttributeMediaSource                       = "media-source"
+	AttributeMediaType                         = "media-type"
+	AttributeMediaColor                        = "media-color"
+	AttributeOutputBin                         = "output-bin"
+	AttributePrintColorMode                    = "print-color-mode"
+	AttributeMediaLeftMargin                   = "media-left-margin"
+	AttributeMediaRightMargin                  = "media-right-margin"
+	AttributeMediaTopMargin                    = "media-top-margin"
+	AttributeMediaBottomMargin                 = "media-bottom-margin"
+	AttributeXDimension                        = "x-dimension"
+	AttributeYDimension                        = "y-dimension"
+	AttributeCompression                       = "compression"
+	AttributeCompressionSupported              = "compression-supported"
+	AttributeOperationsSupported               = "operations-supported"
+	AttributeDocumentFormatSupported           = "document-format-supported"
+	AttributeDocumentFormatDefault             = "document-format-default"
+	AttributeIppVersionsSupported              = "ipp-versions-supported"
+	AttributeCharsetConfigured                 = "charset-configured"
+	AttributeCharsetSupported                  = "charset-supported"
+	AttributeNaturalLanguageConfigured         = "natural-language-configured"
+	AttributeGeneratedNaturalLanguageSupported = "generated-natural-language-supported"
+	AttributeUriSecuritySupported              = "uri-security-supported"
+	AttributeUriAuthenticationSupported        = "uri-authentication-supported"
+	AttributePdlOverrideSupported              = "pdl-override-supported"
+	AttributeQueuedJobCount                    = "queued-job-count"
+	AttributePrinterUpTime                     = "printer-up-time"
+	AttributeMediaSupported                    = "media-supported"
+	AttributeMediaDefault                      = "media-default"
+	AttributeSidesSupported                    = "sides-supported"
+	AttributeSidesDefault                      = "sides-default"
+	AttributeCopiesSupported                   = "copies-supported"
+	AttributeCopiesDefault                     = "copies-default"
+	AttributeAuthInfo                          = "auth-info"
+	AttributeAuthInfoRequired                  = "auth-info-required"
+	AttributeJobSheetsDefault                  = "job-sheets-default"
+	AttributeRequestingUserNameAllowed         = "requesting-user-name-allowed"
+	AttributeRequestingUserNameDenied          = "requesting-user-name-denied"
+	AttributePrinterOpPolicy                   = "printer-op-policy"
+	AttributePortMonitor                       = "port-monitor"
+	AttributeJobQuotaPeriod                    = "job-quota-period"
+	AttributeJobPageLimit                      = "job-page-limit"
+	AttributeJobKLimit                         = "job-k-limit"
 )
 
 // Default attributes
@@ -389,80 +454,121 @@ var (
 	DefaultJobAttributes = []string{
 		AttributeJobID, AttributeJobName, AttributePrinterURI, AttributeJobState, AttributeJobStateReason,
 		AttributeJobHoldUntil, AttributeJobMediaProgress, AttributeJobKilobyteOctets, AttributeNumberOfDocuments, AttributeCopies,
-		AttributeJobOriginatingUserName,
+		AttributeJobOriginatingUserName, AttributeJobStateReasons,
 	}
 )
 
 // Attribute to Tag mapping
 var AttributeTagMapping = map[string]int8{
-	AttributeCharset:                 TagCharset,
-	AttributeNaturalLanguage:         TagLanguage,
-	AttributeCopies:                  TagInteger,
-	AttributeDeviceURI:               TagUri,
-	AttributeDocumentFormat:          TagMimeType,
-	AttributeDocumentName:            TagName,
-	AttributeDocumentNumber:          TagInteger,
-	AttributeDocumentState:           TagEnum,
-	AttributeFinishings:              TagEnum,
-	AttributeJobHoldUntil:            TagKeyword,
-	AttributeHoldJobUntil:            TagKeyword,
-	AttributeJobID:                   TagInteger,
-	AttributeJobName:                 TagName,
-	AttributeJobPrinterURI:           TagUri,
-	AttributeJobPriority:             TagInteger,
-	AttributeJobSheets:               TagName,
-	AttributeJobState:                TagEnum,
-	AttributeJobStateReason:          TagKeyword,
-	AttributeJobURI:                  TagUri,
-	AttributeLastDocument:            TagBoolean,
-	AttributeMedia:                   TagKeyword,
-	AttributeSides:                   TagKeyword,
-	AttributeMemberURIs:              TagUri,
-	AttributeMyJobs:                  TagBoolean,
-	AttributeNumberUp:                TagInteger,
-	AttributeOrientationRequested:    TagEnum,
-	AttributePPDName:                 TagName,
-	AttributePPDMakeAndModel:         TagText,
-	AttributeNumberOfDocuments:       TagInteger,
-	AttributePrintQuality:            TagEnum,
-	AttributePrinterErrorPolicy:      TagName,
-	AttributePrinterInfo:             TagText,
-	AttributePrinterIsAcceptingJobs:  TagBoolean,
-	AttributePrinterIsShared:         TagBoolean,
-	AttributePrinterIsTemporary:      TagBoolean,
-	AttributePrinterName:             TagName,
-	AttributePrinterLocation:         TagText,
-	AttributePrinterResolution:       TagResolution,
-	AttributePrinterState:            TagEnum,
-	AttributePrinterStateReasons:     TagKeyword,
-	AttributePrinterURI:              TagUri,
-	AttributePurgeJobs:               TagBoolean,
-	AttributeRequestedAttributes:     TagKeyword,
-	AttributeRequestingUserName:      TagName,
-	AttributeWhichJobs:               TagKeyword,
-	AttributeFirstJobID:              TagInteger,
-	AttributeStatusMessage:           TagText,
-	AttributeLimit:                   TagInteger,
-	AttributeOutputOrder:             TagName,
-	AttributeJobStateReasons:         TagString,
-	AttributeJobStateMessage:         TagString,
-	AttributeJobPrinterStateReasons:  TagString,
-	AttributeJobPrinterStateMessage:  TagString,
-	AttributeJobImpressionsCompleted: TagInteger,
-	AttributePrintScaling:            TagKeyword,
-	AttributeMediaCol:                TagBeginCollection,
-	AttributeMediaColReady:           TagBeginCollection,
-	AttributeMediaColDatabase:        TagBeginCollection,
-	AttributeMediaColDefault:         TagBeginCollection,
-	AttributeMediaSize:               TagBeginCollection,
-	AttributeMediaSizeName:           TagKeyword,
-	AttributeMediaSource:             TagKeyword,
-	AttributeMediaType:               TagKeyword,
-	AttributeMediaColor:              TagKeyword,
-	AttributeMediaLeftMargin:         TagInteger,
-	AttributeMediaRightMargin:        TagInteger,
-	AttributeMediaTopMargin:          TagInteger,
-	AttributeMediaBottomMargin:       TagInteger,
-	AttributeXDimension:              TagInteger,
-	AttributeYDimension:              TagInteger,
+	AttributeCharset:                           TagCharset,
+	AttributeNaturalLanguage:                   TagLanguage,
+	AttributeCopies:                            TagInteger,
+	AttributeDeviceURI:                         TagUri,
+	AttributeDocumentFormat:                    TagMimeType,
+	AttributeDocumentName:                      TagName,
+	AttributeDocumentNumber:                    TagInteger,
+	AttributeDocumentState:                     TagEnum,
+	AttributeFinishings:                        TagEnum,
+	AttributeJobHoldUntil:                      TagKeyword,
+	AttributeHoldJobUntil:                      TagKeyword,
+	AttributeJobID:                             TagInteger,
+	AttributeJobName:                           TagName,
+	AttributeJobPrinterURI:                     TagUri,
+	AttributeJobPriority:                       TagInteger,
+	AttributeJobSheets:                         TagName,
+	AttributeJobState:                          TagEnum,
+	AttributeJobStateReason:                    TagKeyword,
+	AttributeJobURI:                            TagUri,
+	AttributeLastDocument:                      TagBoolean,
+	AttributeMedia:                             TagKeyword,
+	AttributeSides:                             TagKeyword,
+	AttributeMemberURIs:                        TagUri,
+	AttributeMyJobs:                            TagBoolean,
+	AttributeNumberUp:                          TagInteger,
+	AttributeOrientationRequested:              TagEnum,
+	AttributePPDName:                           TagName,
+	AttributePPDMakeAndModel:                   TagText,
+	AttributeNumberOfDocuments:                 TagInteger,
+	AttributePrintQuality:                      TagEnum,
+	AttributePrinterErrorPolicy:                TagName,
+	AttributePrinterInfo:                       TagText,
+	AttributePrinterIsAcceptingJobs:            TagBoolean,
+	AttributePrinterIsShared:                   TagBoolean,
+	AttributePrinterIsTemporary:                TagBoolean,
+	AttributePrinterName:                       TagName,
+	AttributePrinterLocation:                   TagText,
+	AttributePrinterResolution:                 TagResolution,
+	AttributePrinterState:                      TagEnum,
+	AttributePrinterStateReasons:               TagKeyword,
+	AttributePrinterURI:                        TagUri,
+	AttributePurgeJobs:                         TagBoolean,
+	AttributeRequestedAttributes:               TagKeyword,
+	AttributeRequestingUserName:                TagName,
+	AttributeWhichJobs:                         TagKeyword,
+	AttributeFirstJobID:                        TagInteger,
+	AttributeStatusMessage:                     TagText,
+	AttributeLimit:                             TagInteger,
+	AttributeOutputOrder:                       TagName,
+	AttributeJobStateReasons:                   TagString,
+	AttributeJobStateMessage:                   TagString,
+	AttributeJobPrinterStateReasons:            TagString,
+	AttributeJobPrinterStateMessage:            TagString,
+	AttributeJobImpressionsCompleted:           TagInteger,
+	AttributePrintScaling:                      TagKeyword,
+	AttributeMediaCol:                          TagBeginCollection,
+	AttributeMediaColReady:                     TagBeginCollection,
+	AttributeMediaColDatabase:                  TagBeginCollection,
+	AttributeMediaColDefault:                   TagBeginCollection,
+	AttributeMediaSize:                         TagBeginCollection,
+	AttributeMediaSizeName:                     TagKeyword,
+	AttributeMediaSource:                       TagKeyword,
+	AttributeMediaType:                         TagKeyword,
+	AttributeMediaColor:                        TagKeyword,
+	AttributeOutputBin:                         TagKeyword,
+	AttributePrintColorMode:                    TagKeyword,
+	AttributeMediaLeftMargin:                   TagInteger,
+	AttributeMediaRightMargin:                  TagInteger,
+	AttributeMediaTopMargin:                    TagInteger,
+	AttributeMediaBottomMargin:                 TagInteger,
+	AttributeXDimension:                        TagInteger,
+	AttributeYDimension:                        TagInteger,
+	AttributeCompression:                       TagKeyword,
+	AttributeCompressionSupported:              TagKeyword,
+	AttributeMemberNames:                       TagName,
+	AttributePrinterType:                       TagEnum,
+	AttributePrinterMakeAndModel:               TagText,
+	AttributePrinterStateMessage:               TagText,
+	AttributePrinterUriSupported:               TagUri,
+	AttributeJobMediaProgress:                  TagInteger,
+	AttributeJobKilobyteOctets:                 TagInteger,
+	AttributeJobOriginatingUserName:            TagName,
+	AttributeOperationsSupported:               TagEnum,
+	AttributeDocumentFormatSupported:           TagMimeType,
+	AttributeDocumentFormatDefault:             TagMimeType,
+	AttributeIppVersionsSupported:              TagKeyword,
+	AttributeCharsetConfigured:                 TagCharset,
+	AttributeCharsetSupported:                  TagCharset,
+	AttributeNaturalLanguageConfigured:         TagLanguage,
+	AttributeGeneratedNaturalLanguageSupported: TagLanguage,
+	AttributeUriSecuritySupported:              TagKeyword,
+	AttributeUriAuthenticationSupported:        TagKeyword,
+	AttributePdlOverrideSupported:              TagKeyword,
+	AttributeQueuedJobCount:                    TagInteger,
+	AttributePrinterUpTime:                     TagInteger,
+	AttributeMediaSupported:                    TagKeyword,
+	AttributeMediaDefault:                      TagKeyword,
+	AttributeSidesSupported:                    TagKeyword,
+	AttributeSidesDefault:                      TagKeyword,
+	AttributeCopiesSupported:                   TagRange,
+	AttributeCopiesDefault:                     TagInteger,
+	AttributeAuthInfo:                          TagText,
+	AttributeAuthInfoRequired:                  TagKeyword,
+	AttributeJobSheetsDefault:                  TagName,
+	AttributeRequestingUserNameAllowed:         TagName,
+	AttributeRequestingUserNameDenied:          TagName,
+	AttributePrinterOpPolicy:                   TagName,
+	AttributePortMonitor:                       TagName,
+	AttributeJobQuotaPeriod:                    TagInteger,
+	AttributeJobPageLimit:                      TagInteger,
+	AttributeJobKLimit:                         TagInteger,
 }
@@ -0,0 +1,464 @@
+package ipp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CupsConf is the content of the printers.conf or classes.conf file of a cups server. the files are only read and
+// written by cupsd on startup and shutdown, they should not be modified while cupsd is running
+type CupsConf struct {
+	NextPrinterID int
+	Printers      []*ConfPrinter
+}
+
+// ConfPrinter is a printer section of printers.conf or a class section of classes.conf
+type ConfPrinter struct {
+	Name      string
+	IsClass   bool
+	IsDefault bool
+
+	ID           int
+	UUID         string
+	Info         string
+	Location     string
+	MakeModel    string
+	DeviceURI    string
+	State        int8
+	StateMessage string
+	StateTime    time.Time
+	ConfigTime   time.Time
+	Reasons      []string
+	Type         int
+	Accepting    bool
+	Shared       bool
+	JobSheets    []string
+
+	QuotaPeriod time.Duration
+	PageLimit   int
+	KLimit      int
+	AllowUsers  []string
+	DenyUsers   []string
+
+	OpPolicy    string
+	ErrorPolicy string
+	PortMonitor string
+
+	// Members are the printers of a class
+	Members []string
+	// Options are the default job options of the Option lines, e.g. sides two-sided-long-edge
+	Options map[string]string
+	// Attributes are the cached printer attributes of the Attribute lines, e.g. marker-levels
+	Attributes map[string]string
+	// Directives are the lines unknown by the parser, they are written back unchanged
+	Directives []ConfDirective
+}
+
+// ConfDirective is a line of a cups configuration file
+type ConfDirective struct {
+	Name  string
+	Value string
+}
+
+var confPrinterStates = map[string]int8{
+	"Idle":       PrinterStateIdle,
+	"Processing": PrinterStateProcessing,
+	"Stopped":    PrinterStateStopped,
+}
+
+// ReadCupsConfFile reads a printers.conf or classes.conf file, e.g. /etc/cups/printers.conf
+func ReadCupsConfFile(path string) (*CupsConf, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseCupsConf(f)
+}
+
+// ParseCupsConf parses the content of a printers.conf or classes.conf file
+func ParseCupsConf(r io.Reader) (*CupsConf, error) {
+	conf := &CupsConf{Printers: make([]*ConfPrinter, 0)}
+
+	var printer *ConfPrinter
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		name, value := parseConfLine(scanner.Text())
+		if name == "" {
+			continue
+		}
+
+		if strings.HasPrefix(name, "<") {
+			section := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(name+" "+value), "<"), ">"))
+
+			switch {
+			case section == "/Printer" || section == "/Class":
+				if printer == nil || printer.IsClass != (section == "/Class") {
+					return nil, fmt.Errorf("line %d: unexpected %s", lineNumber, name)
+				}
+				conf.Printers = append(conf.Printers, printer)
+				printer = nil
+			case printer != nil:
+				return nil, fmt.Errorf("line %d: missing </Printer> or </Class> of %s", lineNumber, printer.Name)
+			default:
+				kind, printerName, _ := strings.Cut(section, " ")
+				printerName = strings.TrimSpace(printerName)
+				if printerName == "" {
+					return nil, fmt.Errorf("line %d: missing name in %s", lineNumber, name)
+				}
+
+				printer = &ConfPrinter{Name: printerName, Options: make(map[string]string), Attributes: make(map[string]string)}
+				switch kind {
+				case "Printer":
+				case "DefaultPrinter":
+					printer.IsDefault = true
+				case "Class":
+					printer.IsClass = true
+				case "DefaultClass":
+					printer.IsClass = true
+					printer.IsDefault = true
+				default:
+					return nil, fmt.Errorf("line %d: unknown section %s", lineNumber, kind)
+				}
+			}
+			continue
+		}
+
+		if printer == nil {
+			if name == "NextPrinterId" {
+				id, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid %s %s", lineNumber, name, value)
+				}
+				conf.NextPrinterID = id
+			}
+			continue
+		}
+
+		if err := printer.setDirective(name, value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if printer != nil {
+		return nil, fmt.Errorf("missing </Printer> or </Class> of %s", printer.Name)
+	}
+
+	return conf, nil
+}
+
+// parseConfLine splits a line into directive and value like cups, comments start with an unescaped #
+func parseConfLine(line string) (string, string) {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && line[i+1] == '#' {
+			b.WriteByte('#')
+			i++
+			continue
+		}
+		if line[i] == '#' {
+			break
+		}
+		b.WriteByte(line[i])
+	}
+
+	name, value, _ := strings.Cut(strings.TrimSpace(b.String()), " ")
+	return name, strings.TrimSpace(value)
+}
+
+func (p *ConfPrinter) setDirective(name, value string) error {
+	var err error
+
+	switch name {
+	case "PrinterId":
+		p.ID, err = strconv.Atoi(value)
+	case "UUID":
+		p.UUID = value
+	case "Info":
+		p.Info = value
+	case "Location":
+		p.Location = value
+	case "MakeModel":
+		p.MakeModel = value
+	case "DeviceURI":
+		p.DeviceURI = value
+	case "State":
+		state, ok := confPrinterStates[value]
+		if !ok {
+			return fmt.Errorf("invalid State %s", value)
+		}
+		p.State = state
+	case "StateMessage":
+		p.StateMessage = value
+	case "StateTime":
+		p.StateTime, err = parseConfTime(value)
+	case "ConfigTime":
+		p.ConfigTime, err = parseConfTime(value)
+	case "Reason":
+		p.Reasons = append(p.Reasons, value)
+	case "Type":
+		p.Type, err = strconv.Atoi(value)
+	case "Accepting":
+		p.Accepting = parseConfBool(value)
+	case "Shared":
+		p.Shared = parseConfBool(value)
+	case "JobSheets":
+		p.JobSheets = strings.Fields(value)
+	case "QuotaPeriod":
+		var seconds int
+		seconds, err = strconv.Atoi(value)
+		p.QuotaPeriod = time.Duration(seconds) * time.Second
+	case "PageLimit":
+		p.PageLimit, err = strconv.Atoi(value)
+	case "KLimit":
+		p.KLimit, err = strconv.Atoi(value)
+	case "AllowUser":
+		p.AllowUsers = append(p.AllowUsers, value)
+	case "DenyUser":
+		p.DenyUsers = append(p.DenyUsers, value)
+	case "OpPolicy":
+		p.OpPolicy = value
+	case "ErrorPolicy":
+		p.ErrorPolicy = value
+	case "PortMonitor":
+		p.PortMonitor = value
+	case "Printer":
+		// cupsd saves the members of a class as Printer lines, the directive is unknown in printer sections
+		if !p.IsClass {
+			p.Directives = append(p.Directives, ConfDirective{Name: name, Value: value})
+			break
+		}
+		p.Members = append(p.Members, value)
+	case "Option", "Attribute":
+		key, val, _ := strings.Cut(value, " ")
+		if key == "" {
+			return fmt.Errorf("missing name of %s", name)
+		}
+		if name == "Option" {
+			p.Options[key] = strings.TrimSpace(val)
+		} else {
+			p.Attributes[key] = strings.TrimSpace(val)
+		}
+	default:
+		p.Directives = append(p.Directives, ConfDirective{Name: name, Value: value})
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s %s", name, value)
+	}
+	return nil
+}
+
+func parseConfTime(value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+func parseConfBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "yes", "on", "true":
+		return true
+	}
+	return false
+}
+
+// Default returns the default printer or class or nil
+func (c *CupsConf) Default() *ConfPrinter {
+	for _, printer := range c.Printers {
+		if printer.IsDefault {
+			return printer
+		}
+	}
+	return nil
+}
+
+// Printer returns the printer or class with the name or nil
+func (c *CupsConf) Printer(name string) *ConfPrinter {
+	for _, printer := range c.Printers {
+		if printer.Name == name {
+			return printer
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the configuration to a file readable by root only, like cupsd does
+func (c *CupsConf) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// Write writes the configuration in the format of cupsd, options and attributes are sorted by name
+func (c *CupsConf) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	kind := "Printer"
+	if len(c.Printers) > 0 && c.Printers[0].IsClass {
+		kind = "Class"
+	}
+	fmt.Fprintf(bw, "# %s configuration file for CUPS\n", kind)
+	fmt.Fprintf(bw, "# DO NOT EDIT THIS FILE WHEN CUPSD IS RUNNING\n")
+	if c.NextPrinterID > 0 {
+		fmt.Fprintf(bw, "NextPrinterId %d\n", c.NextPrinterID)
+	}
+
+	for _, printer := range c.Printers {
+		printer.write(bw)
+	}
+
+	return bw.Flush()
+}
+
+func (p *ConfPrinter) write(w *bufio.Writer) {
+	section := "Printer"
+	if p.IsClass {
+		section = "Class"
+	}
+	if p.IsDefault {
+		fmt.Fprintf(w, "<Default%s %s>\n", section, p.Name)
+	} else {
+		fmt.Fprintf(w, "<%s %s>\n", section, p.Name)
+	}
+
+	directive := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%s %s\n", name, strings.ReplaceAll(value, "#", "\\#"))
+		}
+	}
+
+	if p.ID > 0 {
+		directive("PrinterId", strconv.Itoa(p.ID))
+	}
+	directive("UUID", p.UUID)
+	directive("Info", p.Info)
+	directive("Location", p.Location)
+	directive("MakeModel", p.MakeModel)
+	directive("DeviceURI", p.DeviceURI)
+	switch p.State {
+	case PrinterStateStopped:
+		directive("State", "Stopped")
+	case PrinterStateIdle, PrinterStateProcessing:
+		// processing printers are saved as idle by cupsd
+		directive("State", "Idle")
+	}
+	directive("StateMessage", p.StateMessage)
+	if !p.StateTime.IsZero() {
+		directive("StateTime", strconv.FormatInt(p.StateTime.Unix(), 10))
+	}
+	if !p.ConfigTime.IsZero() {
+		directive("ConfigTime", strconv.FormatInt(p.ConfigTime.Unix(), 10))
+	}
+	for _, reason := range p.Reasons {
+		directive("Reason", reason)
+	}
+	if !p.IsClass && p.Type != 0 {
+		directive("Type", strconv.Itoa(p.Type))
+	}
+	directive("Accepting", formatConfBool(p.Accepting))
+	directive("Shared", formatConfBool(p.Shared))
+	directive("JobSheets", strings.Join(p.JobSheets, " "))
+	for _, member := range p.Members {
+		directive("Printer", member)
+	}
+	directive("QuotaPeriod", strconv.Itoa(int(p.QuotaPeriod/time.Second)))
+	directive("PageLimit", strconv.Itoa(p.PageLimit))
+	directive("KLimit", strconv.Itoa(p.KLimit))
+	for _, user := range p.AllowUsers {
+		directive("AllowUser", user)
+	}
+	for _, user := range p.DenyUsers {
+		directive("DenyUser", user)
+	}
+	directive("OpPolicy", p.OpPolicy)
+	directive("ErrorPolicy", p.ErrorPolicy)
+	directive("PortMonitor", p.PortMonitor)
+	for _, name := range sortedKeys(p.Options) {
+		directive("Option", name+" "+p.Options[name])
+	}
+	for _, name := range sortedKeys(p.Attributes) {
+		directive("Attribute", name+" "+p.Attributes[name])
+	}
+	for _, d := range p.Directives {
+		directive(d.Name, d.Value)
+	}
+
+	fmt.Fprintf(w, "</%s>\n", section)
+}
+
+func formatConfBool(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
+
+// PrinterConfig converts a printer section into a config for CUPSClient.ApplyPrinterConfig, e.g. to migrate printers
+// to another server. the ppd file of the printer is not part of the config
+func (p *ConfPrinter) PrinterConfig() PrinterConfig {
+	shared := p.Shared
+	accepting := p.Accepting
+	quotaPeriod := p.QuotaPeriod
+	pageLimit := p.PageLimit
+	kLimit := p.KLimit
+
+	config := PrinterConfig{
+		Name:             p.Name,
+		DeviceURI:        p.DeviceURI,
+		Info:             p.Info,
+		Location:         p.Location,
+		ErrorPolicy:      p.ErrorPolicy,
+		OpPolicy:         p.OpPolicy,
+		PortMonitor:      p.PortMonitor,
+		Shared:           &shared,
+		AcceptingJobs:    &accepting,
+		JobSheetsDefault: p.JobSheets,
+		UsersAllowed:     p.AllowUsers,
+		UsersDenied:      p.DenyUsers,
+		JobQuotaPeriod:   &quotaPeriod,
+		JobPageLimit:     &pageLimit,
+		JobKLimit:        &kLimit,
+	}
+
+	if len(p.Options) > 0 {
+		config.Defaults = make(map[string]any, len(p.Options))
+		for name, value := range p.Options {
+			config.Defaults[name] = value
+
+			// values of integer options like copies must be sent as integers
+			switch AttributeTagMapping[name] {
+			case TagInteger, TagEnum:
+				if i, err := strconv.Atoi(value); err == nil {
+					config.Defaults[name] = i
+				}
+			}
+		}
+	}
+
+	return config
+}
+
+// ClassConfig converts a class section into a config for CUPSClient.Reconcile
+func (p *ConfPrinter) ClassConfig() ClassConfig {
+	return ClassConfig{Name: p.Name, Members: append([]string(nil), p.Members...)}
+}
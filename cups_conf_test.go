@@ -0,0 +1,181 @@
+package ipp
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPrintersConf = `# Printer configuration file for CUPS v2.4.2
+# Written by cupsd
+# DO NOT EDIT THIS FILE WHEN CUPSD IS RUNNING
+NextPrinterId 3
+<DefaultPrinter office>
+PrinterId 1
+UUID urn:uuid:9a3b1c4e-2f6d-3a8b-5c7e-1d2f3a4b5c6d
+AuthInfoRequired none
+Info Office Printer
+Location Room \#204
+MakeModel Example Laser 1000
+DeviceURI ipp://10.0.0.1/ipp/print
+State Idle
+StateTime 1700000000
+ConfigTime 1700000100
+Type 8392732
+Accepting Yes
+Shared No
+JobSheets none none
+QuotaPeriod 86400
+PageLimit 100
+KLimit 0
+AllowUser alice
+AllowUser @staff
+OpPolicy default
+ErrorPolicy retry-job
+Attribute marker-colors \#000000
+Option sides two-sided-long-edge
+Option copies 2
+</Printer>
+<Printer lab>
+PrinterId 2
+DeviceURI socket://10.0.0.2
+State Stopped
+StateMessage Paper jam
+Reason paused
+Reason media-jam-error
+Accepting No
+Shared Yes
+DenyUser bob
+</Printer>
+`
+
+const testClassesConf = `# Class configuration file for CUPS v2.4.2
+# Written by cupsd
+# DO NOT EDIT THIS FILE WHEN CUPSD IS RUNNING
+<Class all>
+UUID urn:uuid:4c5d6e7f-8a9b-3c0d-9e1f-2a3b4c5d6e7f
+Info All printers
+State Idle
+StateTime 1700000000
+ConfigTime 1700000100
+Accepting Yes
+Shared Yes
+JobSheets none none
+Printer office
+Printer lab
+QuotaPeriod 0
+PageLimit 0
+KLimit 0
+OpPolicy default
+ErrorPolicy retry-current-job
+</Class>
+`
+
+func TestParseCupsConf(t *testing.T) {
+	conf, err := ParseCupsConf(strings.NewReader(testPrintersConf))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, conf.NextPrinterID)
+	assert.Len(t, conf.Printers, 2)
+
+	office := conf.Default()
+	assert.Equal(t, &ConfPrinter{
+		Name:        "office",
+		IsDefault:   true,
+		ID:          1,
+		UUID:        "urn:uuid:9a3b1c4e-2f6d-3a8b-5c7e-1d2f3a4b5c6d",
+		Info:        "Office Printer",
+		Location:    "Room #204",
+		MakeModel:   "Example Laser 1000",
+		DeviceURI:   "ipp://10.0.0.1/ipp/print",
+		State:       PrinterStateIdle,
+		StateTime:   time.Unix(1700000000, 0),
+		ConfigTime:  time.Unix(1700000100, 0),
+		Type:        8392732,
+		Accepting:   true,
+		JobSheets:   []string{"none", "none"},
+		QuotaPeriod: 24 * time.Hour,
+		PageLimit:   100,
+		AllowUsers:  []string{"alice", "@staff"},
+		OpPolicy:    "default",
+		ErrorPolicy: ErrorPolicyRetryJob,
+		Options:     map[string]string{"sides": "two-sided-long-edge", "copies": "2"},
+		Attributes:  map[string]string{"marker-colors": "#000000"},
+		Directives:  []ConfDirective{{Name: "AuthInfoRequired", Value: "none"}},
+	}, office)
+
+	lab := conf.Printer("lab")
+	assert.Equal(t, PrinterStateStopped, lab.State)
+	assert.Equal(t, "Paper jam", lab.StateMessage)
+	assert.Equal(t, []string{"paused", "media-jam-error"}, lab.Reasons)
+	assert.False(t, lab.Accepting)
+	assert.True(t, lab.Shared)
+	assert.Equal(t, []string{"bob"}, lab.DenyUsers)
+
+	classes, err := ParseCupsConf(strings.NewReader(testClassesConf))
+	assert.NoError(t, err)
+	assert.True(t, classes.Printers[0].IsClass)
+	assert.Equal(t, ClassConfig{Name: "all", Members: []string{"office", "lab"}}, classes.Printers[0].ClassConfig())
+	assert.Empty(t, classes.Printers[0].Directives)
+}
+
+func TestParseCupsConf_Invalid(t *testing.T) {
+	for _, invalid := range []string{
+		"<Printer office>\n",
+		"</Printer>\n",
+		"<Printer office>\n<Printer lab>\n",
+		"<Printer>\n</Printer>\n",
+		"<Class all>\n</Printer>\n",
+		"<Queue office>\n</Queue>\n",
+		"<Printer office>\nState Busy\n</Printer>\n",
+		"<Printer office>\nPageLimit many\n</Printer>\n",
+	} {
+		_, err := ParseCupsConf(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestCupsConf_Write(t *testing.T) {
+	for _, content := range []string{testPrintersConf, testClassesConf} {
+		conf, err := ParseCupsConf(strings.NewReader(content))
+		assert.NoError(t, err)
+
+		var buf bytes.Buffer
+		assert.NoError(t, conf.Write(&buf))
+		assert.Contains(t, buf.String(), "DO NOT EDIT THIS FILE WHEN CUPSD IS RUNNING")
+
+		written, err := ParseCupsConf(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, conf, written)
+	}
+
+	conf, err := ParseCupsConf(strings.NewReader(testPrintersConf))
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "printers.conf")
+	assert.NoError(t, conf.WriteFile(path))
+	read, err := ReadCupsConfFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, conf, read)
+}
+
+func TestConfPrinter_PrinterConfig(t *testing.T) {
+	conf, err := ParseCupsConf(strings.NewReader(testPrintersConf))
+	assert.NoError(t, err)
+
+	config := conf.Printer("office").PrinterConfig()
+	assert.Equal(t, "office", config.Name)
+	assert.Equal(t, "ipp://10.0.0.1/ipp/print", config.DeviceURI)
+	assert.False(t, *config.Shared)
+	assert.True(t, *config.AcceptingJobs)
+	assert.Equal(t, 24*time.Hour, *config.JobQuotaPeriod)
+	assert.Equal(t, 0, *config.JobKLimit)
+	assert.Equal(t, map[string]any{AttributeSides: "two-sided-long-edge", AttributeCopies: 2}, config.Defaults)
+
+	client, adapter := newMockCUPSClient()
+	assert.NoError(t, client.ApplyPrinterConfig(config))
+	assert.Equal(t, []string{"alice", "@staff"}, adapter.Requests()[0].PrinterAttributes[AttributeRequestingUserNameAllowed])
+}
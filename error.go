@@ -1,6 +1,64 @@
 package ipp
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// error classes, use errors.Is to check if an IPPError belongs to a class
+var (
+	ErrNotFound              = errors.New("ipp: not found")
+	ErrNotAuthorized         = errors.New("ipp: not authorized")
+	ErrBusy                  = errors.New("ipp: busy")
+	ErrNotAcceptingJobs      = errors.New("ipp: not accepting jobs")
+	ErrDocumentFormat        = errors.New("ipp: document format error")
+	ErrTemporary             = errors.New("ipp: temporary error")
+	ErrBadRequest            = errors.New("ipp: bad request")
+	ErrOperationNotSupported = errors.New("ipp: operation not supported")
+	ErrAccount               = errors.New("ipp: account error")
+)
+
+// statusErrorClasses maps ipp status codes to their error class, codes between 0x040f and 0x0420 follow the ipp
+// registry
+var statusErrorClasses = map[int16]error{
+	StatusErrorNotFound:                       ErrNotFound,
+	StatusErrorGone:                           ErrNotFound,
+	StatusErrorForbidden:                      ErrNotAuthorized,
+	StatusErrorNotAuthenticated:               ErrNotAuthorized,
+	StatusErrorNotAuthorized:                  ErrNotAuthorized,
+	StatusErrorCupsAuthenticationCanceled:     ErrNotAuthorized,
+	StatusErrorBusy:                           ErrBusy,
+	StatusErrorNotAcceptingJobs:               ErrNotAcceptingJobs,
+	StatusErrorPrinterIsDeactivated:           ErrNotAcceptingJobs,
+	StatusErrorDocumentFormatNotSupported:     ErrDocumentFormat,
+	statusErrorCompressionNotSupported:        ErrDocumentFormat,
+	statusErrorCompressionError:               ErrDocumentFormat,
+	statusErrorDocumentFormatError:            ErrDocumentFormat,
+	statusErrorDocumentUnprintableError:       ErrDocumentFormat,
+	StatusErrorTemporary:                      ErrTemporary,
+	StatusErrorServiceUnavailable:             ErrTemporary,
+	StatusErrorBadRequest:                     ErrBadRequest,
+	StatusErrorRequestValue:                   ErrBadRequest,
+	StatusErrorAttributesOrValues:             ErrBadRequest,
+	StatusErrorConflicting:                    ErrBadRequest,
+	StatusErrorCharset:                        ErrBadRequest,
+	StatusErrorUriScheme:                      ErrBadRequest,
+	StatusErrorOperationNotSupported:          ErrOperationNotSupported,
+	StatusErrorVersionNotSupported:            ErrOperationNotSupported,
+	statusErrorAccountInfoNeeded:              ErrAccount,
+	statusErrorAccountClosed:                  ErrAccount,
+	statusErrorAccountLimitReached:            ErrAccount,
+	statusErrorAccountAuthorizationFailed:     ErrAccount,
+	StatusErrorCupsAccountInfoNeeded:          ErrAccount,
+	StatusErrorCupsAccountClosed:              ErrAccount,
+	StatusErrorCupsAccountLimitReached:        ErrAccount,
+	StatusErrorCupsAccountAuthorizationFailed: ErrAccount,
+}
 
 // IsNotExistsError checks a given error whether a printer or class does not exist
 func IsNotExistsError(err error) bool {
@@ -8,24 +66,114 @@ func IsNotExistsError(err error) bool {
 		return false
 	}
 
-	return err.Error() == "The printer or class does not exist."
+	return errors.Is(err, ErrNotFound) || err.Error() == "The printer or class does not exist."
+}
+
+// IsRetryable checks whether an error is transient and the request may succeed if it is sent again.
+// it does not consider if an operation is idempotent, see RetryAdapter
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var ippErr IPPError
+	if errors.As(err, &ippErr) {
+		return errors.Is(ippErr, ErrBusy) || errors.Is(ippErr, ErrTemporary)
+	}
+
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code == http.StatusServiceUnavailable || httpErr.Code == http.StatusTooManyRequests
+	}
+
+	// only timeouts and connection failures are transient, other network errors like certificate errors are not
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial" || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsClientError checks whether an error was caused by the request, e.g. a bad request or missing authorization
+func IsClientError(err error) bool {
+	var ippErr IPPError
+	if errors.As(err, &ippErr) {
+		return ippErr.Status >= 0x0400 && ippErr.Status <= 0x04ff
+	}
+
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code >= 400 && httpErr.Code <= 499
+	}
+
+	return false
 }
 
 // IPPError used for non ok ipp status codes
 type IPPError struct {
 	Status  int16
 	Message string
+	// Response is the response received from the server, nil if the error was not decoded from a response
+	Response *Response
 }
 
 func (e IPPError) Error() string {
 	return fmt.Sprintf("ipp status: %d, message: %s", e.Status, e.Message)
 }
 
+// Is reports whether the status code of the error belongs to the given error class
+func (e IPPError) Is(target error) bool {
+	class, ok := statusErrorClasses[e.Status]
+	return ok && class == target
+}
+
+// seeOtherError is returned for a cups-see-other response, Location is the printer-uri the server redirects to
+type seeOtherError struct {
+	IPPError
+	Location string
+}
+
+func (e seeOtherError) Unwrap() error {
+	return e.IPPError
+}
+
 // HTTPError used for non 200 http codes
 type HTTPError struct {
-	Code int
+	Code   int
+	Header http.Header
+	// Body contains the beginning of the response body
+	Body string
 }
 
 func (e HTTPError) Error() string {
 	return fmt.Sprintf("got http code %d", e.Code)
 }
+
+// httpErrorBodyLimit is the maximum number of bytes of a response body stored in a HTTPError
+const httpErrorBodyLimit = 512
+
+func newHTTPError(resp *http.Response) HTTPError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, httpErrorBodyLimit))
+
+	return HTTPError{
+		Code:   resp.StatusCode,
+		Header: resp.Header,
+		Body:   string(body),
+	}
+}
+
+// RequestIDMismatchError is returned if the request id of a response does not match the one of the request
+type RequestIDMismatchError struct {
+	RequestID  int32
+	ResponseID int32
+}
+
+func (e RequestIDMismatchError) Error() string {
+	return fmt.Sprintf("response request id %d does not match request id %d", e.ResponseID, e.RequestID)
+}
@@ -0,0 +1,81 @@
+package ipp
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPPError_Is(t *testing.T) {
+	err := fmt.Errorf("received error IPP response: %w", IPPError{Status: StatusErrorNotFound, Message: "The printer or class does not exist."})
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(err, ErrBusy))
+	assert.True(t, IsNotExistsError(err))
+	assert.True(t, IsClientError(err))
+	assert.False(t, IsRetryable(err))
+
+	assert.True(t, errors.Is(IPPError{Status: StatusErrorNotAuthenticated}, ErrNotAuthorized))
+	assert.True(t, errors.Is(IPPError{Status: StatusErrorDocumentFormatNotSupported}, ErrDocumentFormat))
+	assert.True(t, errors.Is(IPPError{Status: StatusErrorNotAcceptingJobs}, ErrNotAcceptingJobs))
+}
+
+func TestIPPError_IsRegistryCodes(t *testing.T) {
+	for status, class := range map[int16]error{
+		0x0400: ErrBadRequest,
+		0x0401: ErrNotAuthorized,
+		0x0402: ErrNotAuthorized,
+		0x0403: ErrNotAuthorized,
+		0x0406: ErrNotFound,
+		0x0407: ErrNotFound,
+		0x040a: ErrDocumentFormat,
+		0x040f: ErrDocumentFormat,
+		0x0410: ErrDocumentFormat,
+		0x0411: ErrDocumentFormat,
+		0x041b: ErrDocumentFormat,
+		0x041c: ErrAccount,
+		0x041d: ErrAccount,
+		0x041e: ErrAccount,
+		0x041f: ErrAccount,
+		0x0501: ErrOperationNotSupported,
+		0x0502: ErrTemporary,
+		0x0505: ErrTemporary,
+		0x0506: ErrNotAcceptingJobs,
+		0x0507: ErrBusy,
+	} {
+		assert.True(t, errors.Is(IPPError{Status: status}, class), StatusName(status))
+	}
+
+	for _, status := range []int16{0x0404, 0x0412, 0x0413, 0x0420, 0x0500} {
+		_, ok := statusErrorClasses[status]
+		assert.False(t, ok, StatusName(status))
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(IPPError{Status: StatusErrorBusy}))
+	assert.True(t, IsRetryable(IPPError{Status: StatusErrorServiceUnavailable}))
+	assert.True(t, IsRetryable(HTTPError{Code: http.StatusServiceUnavailable}))
+	assert.False(t, IsRetryable(HTTPError{Code: http.StatusUnauthorized}))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.False(t, IsRetryable(nil))
+
+	assert.True(t, IsRetryable(&url.Error{Op: "Post", URL: "http://localhost:631", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}))
+	assert.True(t, IsRetryable(&url.Error{Op: "Post", URL: "http://localhost:631", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}))
+	assert.True(t, IsRetryable(&url.Error{Op: "Post", URL: "http://localhost:631", Err: io.ErrUnexpectedEOF}))
+	assert.True(t, IsRetryable(&net.DNSError{Err: "timeout", IsTimeout: true}))
+	assert.False(t, IsRetryable(&url.Error{Op: "Post", URL: "https://localhost:631", Err: x509.UnknownAuthorityError{}}))
+	assert.False(t, IsRetryable(&url.Error{Op: "Post", URL: "foo://localhost:631", Err: errors.New("unsupported protocol scheme \"foo\"")}))
+
+	assert.True(t, IsClientError(HTTPError{Code: http.StatusUnauthorized}))
+	assert.False(t, IsClientError(IPPError{Status: StatusErrorInternal}))
+}
@@ -1,6 +1,6 @@
 module github.com/phin1x/go-ipp
 
-go 1.18
+go 1.21
 
 require github.com/stretchr/testify v1.11.1
 
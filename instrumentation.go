@@ -0,0 +1,119 @@
+package ipp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// OperationInfo describes an ipp operation which is about to be sent
+type OperationInfo struct {
+	Operation     int16
+	OperationName string
+	URL           string
+	PrinterURI    string
+	RequestId     int32
+}
+
+// OperationResult describes the outcome of an ipp operation
+type OperationResult struct {
+	// StatusCode is the ipp status code of the response, StatusCupsInvalid if no response was received
+	StatusCode int16
+	// BytesSent and BytesReceived are the sizes of the transferred http bodies, they are counted by the HttpAdapter and
+	// the SocketAdapter and are zero for other adapters
+	BytesSent     int64
+	BytesReceived int64
+	Duration      time.Duration
+	Err           error
+}
+
+// Instrumentation receives telemetry about ipp operations, e.g. to record traces and metrics.
+// StartOperation is called before a request is sent, the returned context is passed down to the adapter and
+// the returned function is called once the operation has finished
+type Instrumentation interface {
+	StartOperation(ctx context.Context, info OperationInfo) (context.Context, func(OperationResult))
+}
+
+// InstrumentationMiddleware reports every request to the given instrumentation
+func InstrumentationMiddleware(instrumentation Instrumentation) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, url string, req *Request, additionalResponseData io.Writer) (*Response, error) {
+			info := OperationInfo{
+				Operation:     req.Operation,
+				OperationName: OperationName(req.Operation),
+				URL:           url,
+				RequestId:     req.RequestId,
+			}
+			if printerURI, ok := req.OperationAttributes[AttributePrinterURI].(string); ok {
+				info.PrinterURI = printerURI
+			}
+
+			ctx, finish := instrumentation.StartOperation(ctx, info)
+
+			// the adapters count the bytes of the http bodies, so only bytes which were actually transferred are reported
+			ctx, counter := withWireCounter(ctx)
+
+			start := time.Now()
+			resp, err := next.RoundTrip(ctx, url, req, additionalResponseData)
+			result := OperationResult{
+				StatusCode:    StatusCupsInvalid,
+				BytesSent:     counter.sent.Load(),
+				BytesReceived: counter.received.Load(),
+				Duration:      time.Since(start),
+				Err:           err,
+			}
+
+			var ippErr IPPError
+			if resp != nil {
+				result.StatusCode = resp.StatusCode
+			} else if errors.As(err, &ippErr) {
+				result.StatusCode = ippErr.Status
+			}
+
+			finish(result)
+			return resp, err
+		})
+	}
+}
+
+type wireCounterKey struct{}
+
+// wireCounter collects the bytes sent and received by the adapters of an operation
+type wireCounter struct {
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+func withWireCounter(ctx context.Context) (context.Context, *wireCounter) {
+	counter := &wireCounter{}
+	return context.WithValue(ctx, wireCounterKey{}, counter), counter
+}
+
+// countSentBytes counts the bytes read from the request body if the operation is instrumented
+func countSentBytes(ctx context.Context, body io.Reader) io.Reader {
+	if counter, ok := ctx.Value(wireCounterKey{}).(*wireCounter); ok {
+		return &countingReader{reader: body, count: &counter.sent}
+	}
+	return body
+}
+
+// countReceivedBytes counts the bytes read from the response body if the operation is instrumented
+func countReceivedBytes(ctx context.Context, body io.Reader) io.Reader {
+	if counter, ok := ctx.Value(wireCounterKey{}).(*wireCounter); ok {
+		return &countingReader{reader: body, count: &counter.received}
+	}
+	return body
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  *atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
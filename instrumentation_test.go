@@ -0,0 +1,56 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testInstrumentation struct {
+	info   OperationInfo
+	result OperationResult
+}
+
+func (i *testInstrumentation) StartOperation(ctx context.Context, info OperationInfo) (context.Context, func(OperationResult)) {
+	i.info = info
+	return ctx, func(result OperationResult) {
+		i.result = result
+	}
+}
+
+func TestInstrumentationMiddleware(t *testing.T) {
+	server := NewServer()
+	server.HandleFunc(OperationPrintJob, func(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+		_, _ = io.Copy(io.Discard, req.File)
+		return nil, IPPError{Status: StatusErrorNotFound, Message: "not found"}
+	})
+
+	instrumentation := &testInstrumentation{}
+	adapter := NewMiddlewareAdapter(newTestHttpAdapter(t, server.ServeHTTP), InstrumentationMiddleware(instrumentation))
+
+	req := NewRequest(OperationPrintJob, 42)
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/test"
+	document := bytes.NewBufferString("document")
+	req.File = document
+	req.FileSize = document.Len()
+
+	payload, err := req.Encode()
+	assert.Nil(t, err)
+	resp, err := newErrorResponse(req, IPPError{Status: StatusErrorNotFound, Message: "not found"}).Encode()
+	assert.Nil(t, err)
+
+	_, err = adapter.SendRequest(adapter.GetHttpUri("printers", "test"), req, nil)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "Print-Job", instrumentation.info.OperationName)
+	assert.Equal(t, "ipp://localhost/printers/test", instrumentation.info.PrinterURI)
+	assert.Equal(t, int32(42), instrumentation.info.RequestId)
+	assert.Equal(t, StatusErrorNotFound, instrumentation.result.StatusCode)
+	assert.Equal(t, err, instrumentation.result.Err)
+	assert.Equal(t, int64(len(payload)+len("document")), instrumentation.result.BytesSent)
+	assert.Equal(t, int64(len(resp)), instrumentation.result.BytesReceived)
+	assert.Equal(t, document, req.File)
+}
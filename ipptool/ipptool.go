@@ -0,0 +1,138 @@
+// Package ipptool parses and runs test files written in the syntax of the CUPS ipptool utility.
+//
+// a test file consists of tests in braces and file level directives:
+//
+//	DEFINE media iso_a4_210x297mm
+//
+//	{
+//		NAME "Print a document"
+//		OPERATION Print-Job
+//		GROUP operation-attributes-tag
+//		ATTR charset attributes-charset utf-8
+//		ATTR naturalLanguage attributes-natural-language en
+//		ATTR uri printer-uri $uri
+//		ATTR mimeMediaType document-format application/pdf
+//		GROUP job-attributes-tag
+//		ATTR keyword media $media
+//		FILE document.pdf
+//		STATUS successful-ok
+//		EXPECT job-id OF-TYPE integer WITH-VALUE >0
+//	}
+//
+// the tests are executed by a Runner against any ipp.Adapter
+package ipptool
+
+import (
+	"time"
+)
+
+// File is a parsed test file
+type File struct {
+	Path  string
+	Tests []*Test
+	// Warnings lists the directives which are parsed but not supported, e.g. PAUSE or MONITOR-PRINTER-STATE
+	Warnings []string
+}
+
+// Define sets a variable before a test is run
+type Define struct {
+	Name  string
+	Value string
+	// Default only sets the variable if it is not already defined
+	Default bool
+}
+
+// Test is a single request with its expected response
+type Test struct {
+	Name string
+	// ID is the identifier set by TEST-ID, e.g. the section of a certification test
+	ID string
+	// Path and Line locate the test in its file, relative FILE paths are resolved against the directory of Path
+	Path string
+	Line int
+
+	Operation   int16
+	Version     string
+	RequestID   int32
+	Resource    string
+	Groups      []Group
+	File        string
+	Compression string
+
+	Statuses []Status
+	Expects  []Expect
+	Displays []string
+
+	IgnoreErrors      bool
+	SkipPreviousError bool
+	SkipIfDefined     string
+	SkipIfNotDefined  string
+	SkipIfMissing     string
+	// PassIfDefined and PassIfNotDefined pass the test without sending the request depending on a variable
+	PassIfDefined    string
+	PassIfNotDefined string
+	Delay            time.Duration
+	RepeatDelay      time.Duration
+
+	// Defines are the file level variables defined before the test and the variables defined by the test
+	Defines []Define
+}
+
+// Group is an attribute group of a request
+type Group struct {
+	Tag        int8
+	Attributes []Attr
+}
+
+// Attr is an attribute of a request. values are kept as written in the test file, variables are expanded when the
+// test is run
+type Attr struct {
+	Name   string
+	Tag    int8
+	Values []string
+	// Collections are the member attributes of each collection value
+	Collections [][]Attr
+}
+
+// Control holds the conditions and actions shared by STATUS and EXPECT
+type Control struct {
+	IfDefined     string
+	IfNotDefined  string
+	RepeatMatch   bool
+	RepeatNoMatch bool
+	RepeatLimit   int
+	DefineMatch   string
+	DefineNoMatch string
+}
+
+// Status is an expected status code
+type Status struct {
+	Control
+	Code int16
+}
+
+// Expect is an expected response attribute
+type Expect struct {
+	Control
+	Name string
+	Line int
+
+	// NotExpected is set for !name, the attribute must not be returned
+	NotExpected bool
+	// Optional is set for ?name, the predicates are only checked if the attribute is returned
+	Optional bool
+	// All is set by EXPECT-ALL, the predicates are checked for every occurrence of the attribute, e.g. in each job of
+	// a Get-Jobs response, instead of the first one
+	All bool
+
+	OfType  []int8
+	InGroup int8
+	// Count is the expected number of values, 0 if the number is not checked
+	Count              int
+	SameCountAs        string
+	WithValue          string
+	WithAllValues      bool
+	WithValueFrom      string
+	WithDistinctValues bool
+	DefineValue        string
+}
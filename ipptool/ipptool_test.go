@@ -0,0 +1,227 @@
+package ipptool
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phin1x/go-ipp"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRunner(t *testing.T) *Runner {
+	printer, err := ipp.NewVirtualPrinter(ipp.VirtualPrinterConfig{
+		SpoolDir:       t.TempDir(),
+		ProcessingTime: 50 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+	t.Cleanup(printer.Close)
+
+	server := httptest.NewServer(printer)
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.Nil(t, err)
+	portNumber, err := strconv.Atoi(port)
+	assert.Nil(t, err)
+
+	return &Runner{
+		Adapter:   ipp.NewHttpAdapter(host, portNumber, "", "", false),
+		URI:       "ipp://" + server.Listener.Addr().String() + "/ipp/print",
+		Variables: map[string]string{"user": "tester"},
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	file, err := ParseFile("testdata/print.test")
+	assert.Nil(t, err)
+	assert.Len(t, file.Tests, 4)
+
+	report, err := newTestRunner(t).Run(context.Background(), file)
+	assert.Nil(t, err)
+	assert.True(t, report.Passed(), report.String())
+	assert.Len(t, report.Results, 4)
+	assert.Equal(t, []string{"virtual-printer"}, report.Results[0].Displays["printer-name"])
+	assert.Greater(t, report.Results[2].Repeats, 0)
+}
+
+func TestRunner_Failures(t *testing.T) {
+	file, err := Parse(strings.NewReader(`
+{
+	OPERATION Get-Printer-Attributes
+	GROUP operation-attributes-tag
+	ATTR uri printer-uri $uri
+	STATUS client-error-not-found
+	EXPECT printer-name WITH-VALUE other
+}
+
+{
+	OPERATION Get-Jobs
+	GROUP operation-attributes-tag
+	ATTR uri printer-uri $uri
+}
+`), "inline.test")
+	assert.Nil(t, err)
+
+	runner := newTestRunner(t)
+
+	report, err := runner.Run(context.Background(), file)
+	assert.Nil(t, err)
+	assert.False(t, report.Passed())
+	assert.Len(t, report.Results, 1, "run must stop after the first failure")
+	assert.Equal(t, []string{
+		"EXPECTED: STATUS client-error-not-found (got successful-ok)",
+		"EXPECTED: printer-name WITH-VALUE other (got virtual-printer)",
+	}, report.Results[0].Failures)
+
+	runner.ContinueOnError = true
+	report, err = runner.Run(context.Background(), file)
+	assert.Nil(t, err)
+	assert.Len(t, report.Results, 2)
+	assert.True(t, report.Results[1].Passed)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"{\n\tNAME test\n}":                        "inline.test: line 1: test \"test\" has no OPERATION",
+		"{\n\tOPERATION Unknown-Operation\n}":      "inline.test: line 2: unknown operation Unknown-Operation",
+		"{\n\tOPERATION Get-Jobs\n\tFOO bar\n}":    "inline.test: line 3: unknown test directive FOO",
+		"{\n\tOPERATION Get-Jobs\n\tATTR x y z\n}": "inline.test: line 3: unknown value tag x",
+		"UNKNOWN":                   "inline.test: line 1: unknown directive UNKNOWN",
+		"{\n\tOPERATION Get-Jobs\n": "inline.test: line 1: unterminated test",
+	}
+
+	for input, expected := range tests {
+		_, err := Parse(strings.NewReader(input), "inline.test")
+		if assert.NotNil(t, err, input) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+}
+
+func TestMatchValue(t *testing.T) {
+	for _, tc := range []struct {
+		value    any
+		pattern  string
+		expected bool
+	}{
+		{10, "10", true},
+		{10, "0x000a", true},
+		{10, ">=0x0a", true},
+		{10, "<0x0a", false},
+		{10, "0x0001-0x000a", true},
+		{11, "1-0x000a", false},
+		{10, "3,4,10", true},
+		{"idle", "idle", true},
+		{"idle", "/^id/", true},
+	} {
+		ok, err := matchValue(tc.value, tc.pattern)
+		assert.NoError(t, err, tc.pattern)
+		assert.Equal(t, tc.expected, ok, tc.pattern)
+	}
+
+	_, err := matchValue(10, "0xzz")
+	assert.Error(t, err)
+}
+
+func TestRunner_ErrorResponse(t *testing.T) {
+	file, err := Parse(strings.NewReader(`
+{
+	OPERATION Get-Job-Attributes
+	GROUP operation-attributes-tag
+	ATTR charset attributes-charset utf-8
+	ATTR naturalLanguage attributes-natural-language en
+	ATTR uri printer-uri $uri
+	ATTR integer job-id 4711
+	STATUS client-error-not-found
+	EXPECT attributes-charset OF-TYPE charset WITH-VALUE utf-8
+	EXPECT attributes-natural-language
+	DISPLAY attributes-charset
+}
+`), "inline.test")
+	assert.Nil(t, err)
+
+	report, err := newTestRunner(t).Run(context.Background(), file)
+	assert.Nil(t, err)
+	assert.True(t, report.Passed(), report.String())
+	assert.Equal(t, []string{"utf-8"}, report.Results[0].Displays["attributes-charset"])
+}
+
+func TestParseFile_SelfCertification(t *testing.T) {
+	file, err := ParseFile("testdata/ipp-everywhere.test")
+	assert.Nil(t, err)
+	assert.Len(t, file.Tests, 5)
+
+	assert.Equal(t, "5.1-1", file.Tests[0].ID)
+	assert.Equal(t, "PDF_SUPPORTED", file.Tests[2].PassIfNotDefined)
+	assert.Equal(t, []Define{{Name: "JOB_STATE_COMPLETED", Value: "9"}}, file.Tests[3].Defines)
+	assert.True(t, file.Tests[3].Expects[0].All)
+	assert.Len(t, file.Tests[1].Expects, 2, "the expects of MONITOR-PRINTER-STATE must be skipped")
+	assert.Equal(t, []string{
+		"testdata/ipp-everywhere.test: line 73: MONITOR-PRINTER-STATE is not supported and ignored",
+		"testdata/ipp-everywhere.test: line 113: GET-VARIABLES is not supported and ignored",
+		"testdata/ipp-everywhere.test: line 120: PAUSE is not supported and ignored",
+	}, file.Warnings)
+}
+
+func TestRunner_TestDirectives(t *testing.T) {
+	printTest := `
+{
+	NAME "Print"
+	OPERATION Print-Job
+	GROUP operation-attributes-tag
+	ATTR uri printer-uri $uri
+	ATTR name requesting-user-name $user
+	ATTR mimeMediaType document-format application/pdf
+	FILE testdata/document.pdf
+}
+`
+	file, err := Parse(strings.NewReader(`
+{
+	NAME "Passed without request"
+	TEST-ID "1"
+	PASS-IF-NOT-DEFINED UNDEFINED
+	OPERATION Get-Jobs
+	STATUS client-error-not-found
+}
+`+printTest+printTest+`
+{
+	NAME "Every job has an id"
+	OPERATION Get-Jobs
+	GROUP operation-attributes-tag
+	ATTR uri printer-uri $uri
+	ATTR keyword which-jobs all
+	ATTR keyword requested-attributes job-id
+	EXPECT-ALL job-id WITH-VALUE >0
+}
+
+{
+	NAME "Every job is the first job"
+	TEST-ID "5"
+	OPERATION Get-Jobs
+	GROUP operation-attributes-tag
+	ATTR uri printer-uri $uri
+	ATTR keyword which-jobs all
+	ATTR keyword requested-attributes job-id
+	DEFINE FIRST_JOB 1
+	EXPECT job-id
+	EXPECT-ALL job-id WITH-VALUE $FIRST_JOB
+}
+`), "inline.test")
+	assert.Nil(t, err)
+
+	runner := newTestRunner(t)
+	runner.ContinueOnError = true
+	report, err := runner.Run(context.Background(), file)
+	assert.Nil(t, err)
+	assert.Len(t, report.Results, 5)
+	assert.True(t, report.Results[0].Passed)
+	assert.Equal(t, "1", report.Results[0].ID)
+	assert.True(t, report.Results[3].Passed, report.String())
+	assert.Equal(t, "5", report.Results[4].ID)
+	assert.Equal(t, []string{"EXPECTED: job-id WITH-VALUE 1 (got 2)"}, report.Results[4].Failures)
+}
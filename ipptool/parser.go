@@ -0,0 +1,857 @@
+package ipptool
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phin1x/go-ipp"
+)
+
+// maxIncludeDepth limits nested INCLUDE directives to detect include cycles
+const maxIncludeDepth = 16
+
+type token struct {
+	value  string
+	line   int
+	quoted bool
+}
+
+// tokenizer splits a test file into words, quoted strings and braces. comments start with # and end at the line end
+type tokenizer struct {
+	r      *bufio.Reader
+	line   int
+	peeked *token
+}
+
+func newTokenizer(r io.Reader) *tokenizer {
+	return &tokenizer{r: bufio.NewReader(r), line: 1}
+}
+
+func (t *tokenizer) peek() (token, error) {
+	if t.peeked == nil {
+		tok, err := t.read()
+		if err != nil {
+			return token{}, err
+		}
+		t.peeked = &tok
+	}
+
+	return *t.peeked, nil
+}
+
+func (t *tokenizer) next() (token, error) {
+	if t.peeked != nil {
+		tok := *t.peeked
+		t.peeked = nil
+		return tok, nil
+	}
+
+	return t.read()
+}
+
+func (t *tokenizer) read() (token, error) {
+	// skip whitespace and comments
+	var c rune
+	for {
+		var err error
+		if c, _, err = t.r.ReadRune(); err != nil {
+			return token{}, err
+		}
+
+		switch {
+		case c == '\n':
+			t.line++
+		case c == '#':
+			if _, err := t.r.ReadString('\n'); err != nil {
+				return token{}, err
+			}
+			t.line++
+		case c == ' ' || c == '\t' || c == '\r':
+		default:
+			goto start
+		}
+	}
+
+start:
+	tok := token{line: t.line}
+
+	switch c {
+	case '{', '}':
+		tok.value = string(c)
+		return tok, nil
+	case '"', '\'':
+		value, err := t.readQuoted(c)
+		if err != nil {
+			return token{}, fmt.Errorf("line %d: unterminated string", tok.line)
+		}
+		tok.value = value
+		tok.quoted = true
+		return tok, nil
+	}
+
+	// read a word until whitespace or a brace, braces of ${name} variables are part of the word
+	var sb strings.Builder
+	inVariable := false
+	for {
+		switch {
+		case c == '\\':
+			sb.WriteRune(c)
+			next, _, err := t.r.ReadRune()
+			if err != nil {
+				return tok, nil
+			}
+			c = next
+		case c == '{' && strings.HasSuffix(sb.String(), "$"):
+			inVariable = true
+		case c == '}' && inVariable:
+			inVariable = false
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '{' || c == '}':
+			_ = t.r.UnreadRune()
+			tok.value = unescape(sb.String())
+			return tok, nil
+		}
+
+		sb.WriteRune(c)
+
+		next, _, err := t.r.ReadRune()
+		if err != nil {
+			tok.value = unescape(sb.String())
+			return tok, nil
+		}
+		c = next
+	}
+}
+
+func (t *tokenizer) readQuoted(quote rune) (string, error) {
+	var sb strings.Builder
+	for {
+		c, _, err := t.r.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch c {
+		case quote:
+			return unescape(sb.String()), nil
+		case '\\':
+			sb.WriteRune(c)
+			if c, _, err = t.r.ReadRune(); err != nil {
+				return "", err
+			}
+		case '\n':
+			t.line++
+		}
+
+		sb.WriteRune(c)
+	}
+}
+
+// unescape removes backslashes before quotes and backslashes, escaped commas are kept to be handled by splitValues
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && s[i+1] != ',' {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+
+	return sb.String()
+}
+
+// splitValues splits a comma separated list of values, commas can be escaped with a backslash
+func splitValues(s string) []string {
+	values := make([]string, 0, 1)
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == ',':
+			sb.WriteByte(',')
+			i++
+		case s[i] == ',':
+			values = append(values, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+
+	return append(values, sb.String())
+}
+
+type parser struct {
+	tests        []*Test
+	defines      []Define
+	ignoreErrors bool
+	version      string
+	depth        int
+	warnings     []string
+}
+
+// ParseFile parses the test file at path and all files included by it
+func ParseFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f, path)
+}
+
+// Parse parses a test file. path is used for error messages and to resolve INCLUDE and FILE paths
+func Parse(r io.Reader, path string) (*File, error) {
+	p := &parser{version: "2.0"}
+	if err := p.parse(newTokenizer(r), path); err != nil {
+		return nil, err
+	}
+
+	return &File{Path: path, Tests: p.tests, Warnings: p.warnings}, nil
+}
+
+func (p *parser) parse(t *tokenizer, path string) error {
+	for {
+		tok, err := t.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := p.parseDirective(t, path, tok); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+}
+
+func (p *parser) parseDirective(t *tokenizer, path string, tok token) error {
+	if tok.value == "{" {
+		test, err := p.parseTest(t, path, tok.line)
+		if err != nil {
+			return err
+		}
+		p.tests = append(p.tests, test)
+		p.defines = nil
+		return nil
+	}
+
+	if err := p.parseFileDirective(t, path, tok); err != nil {
+		return lineError(tok, err)
+	}
+	return nil
+}
+
+// parseFileDirective parses a directive outside of tests
+func (p *parser) parseFileDirective(t *tokenizer, path string, tok token) error {
+	switch tok.value {
+	case "DEFINE", "DEFINE-DEFAULT":
+		args, err := nextValues(t, 2)
+		if err != nil {
+			return err
+		}
+		p.defines = append(p.defines, Define{Name: args[0], Value: args[1], Default: tok.value == "DEFINE-DEFAULT"})
+	case "INCLUDE":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		return p.include(resolvePath(path, args[0]))
+	case "IGNORE-ERRORS":
+		value, err := nextBool(t)
+		if err != nil {
+			return err
+		}
+		p.ignoreErrors = value
+	case "VERSION":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		p.version = args[0]
+	case "TRANSFER":
+		// the transfer encoding is chosen by the adapter
+		_, err := nextValues(t, 1)
+		return err
+	default:
+		return fmt.Errorf("unknown directive %s", tok.value)
+	}
+
+	return nil
+}
+
+func (p *parser) include(path string) error {
+	if p.depth >= maxIncludeDepth {
+		return fmt.Errorf("too many nested includes at %s", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p.depth++
+	defer func() { p.depth-- }()
+
+	return p.parse(newTokenizer(f), path)
+}
+
+func (p *parser) parseTest(t *tokenizer, path string, line int) (*Test, error) {
+	test := &Test{
+		Path:         path,
+		Line:         line,
+		Operation:    ipp.OperationCupsInvalid,
+		Version:      p.version,
+		IgnoreErrors: p.ignoreErrors,
+		Defines:      append([]Define(nil), p.defines...),
+	}
+	groupIndex := -1
+
+	for {
+		tok, err := t.next()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: unterminated test", line)
+		}
+
+		if tok.value == "}" {
+			if test.Operation == ipp.OperationCupsInvalid {
+				return nil, fmt.Errorf("line %d: test %q has no OPERATION", line, test.Name)
+			}
+			if test.Name == "" {
+				test.Name = ipp.OperationName(test.Operation)
+			}
+			return test, nil
+		}
+
+		if err := p.parseTestDirective(t, path, test, tok, &groupIndex); err != nil {
+			return nil, lineError(tok, err)
+		}
+	}
+}
+
+// parseTestDirective parses a single directive of a test, groupIndex is the index of the current attribute group
+func (p *parser) parseTestDirective(t *tokenizer, path string, test *Test, tok token, groupIndex *int) error {
+	var err error
+
+	switch tok.value {
+	case "NAME":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		test.Name = args[0]
+	case "TEST-ID":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		test.ID = args[0]
+	case "DEFINE", "DEFINE-DEFAULT":
+		args, err := nextValues(t, 2)
+		if err != nil {
+			return err
+		}
+		test.Defines = append(test.Defines, Define{Name: args[0], Value: args[1], Default: tok.value == "DEFINE-DEFAULT"})
+	case "OPERATION":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		if test.Operation, err = parseOperation(args[0]); err != nil {
+			return err
+		}
+	case "VERSION":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		test.Version = args[0]
+	case "REQUEST-ID":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		if args[0] != "random" {
+			id, err := strconv.ParseInt(args[0], 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid request id %s", args[0])
+			}
+			test.RequestID = int32(id)
+		}
+	case "RESOURCE":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		test.Resource = args[0]
+	case "GROUP":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		tag, ok := ipp.TagByName(args[0])
+		if !ok || tag >= ipp.TagUnsupported {
+			return fmt.Errorf("unknown group %s", args[0])
+		}
+		test.Groups = append(test.Groups, Group{Tag: tag})
+		*groupIndex = len(test.Groups) - 1
+	case "ATTR":
+		if *groupIndex < 0 {
+			test.Groups = append(test.Groups, Group{Tag: ipp.TagDelimiterOperation})
+			*groupIndex = 0
+		}
+		attr, err := parseAttr(t)
+		if err != nil {
+			return err
+		}
+		test.Groups[*groupIndex].Attributes = append(test.Groups[*groupIndex].Attributes, attr)
+	case "FILE":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		test.File = args[0]
+	case "COMPRESSION":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		test.Compression = args[0]
+	case "STATUS":
+		status, err := parseStatus(t)
+		if err != nil {
+			return err
+		}
+		test.Statuses = append(test.Statuses, status)
+	case "EXPECT", "EXPECT-ALL":
+		expect, err := parseExpect(t)
+		if err != nil {
+			return err
+		}
+		expect.Line = tok.line
+		expect.All = tok.value == "EXPECT-ALL"
+		test.Expects = append(test.Expects, expect)
+	case "DISPLAY":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		test.Displays = append(test.Displays, args[0])
+	case "IGNORE-ERRORS":
+		test.IgnoreErrors, err = nextBool(t)
+		return err
+	case "SKIP-PREVIOUS-ERROR":
+		test.SkipPreviousError, err = nextBool(t)
+		return err
+	case "SKIP-IF-DEFINED", "SKIP-IF-NOT-DEFINED", "SKIP-IF-MISSING", "PASS-IF-DEFINED", "PASS-IF-NOT-DEFINED":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		switch tok.value {
+		case "SKIP-IF-DEFINED":
+			test.SkipIfDefined = args[0]
+		case "SKIP-IF-NOT-DEFINED":
+			test.SkipIfNotDefined = args[0]
+		case "PASS-IF-DEFINED":
+			test.PassIfDefined = args[0]
+		case "PASS-IF-NOT-DEFINED":
+			test.PassIfNotDefined = args[0]
+		default:
+			test.SkipIfMissing = args[0]
+		}
+	case "DELAY":
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return err
+		}
+		if test.Delay, test.RepeatDelay, err = parseDelay(args[0]); err != nil {
+			return err
+		}
+	case "TRANSFER":
+		if _, err := nextValues(t, 1); err != nil {
+			return err
+		}
+	case "PAUSE", "GET-VARIABLES", "MONITOR-PRINTER-STATE":
+		// interactive and monitoring directives are not supported, the remaining tokens of the line and a block in
+		// braces are skipped
+		if err := skipDirective(t, tok); err != nil {
+			return err
+		}
+		p.warnings = append(p.warnings, fmt.Sprintf("%s: line %d: %s is not supported and ignored", path, tok.line, tok.value))
+	default:
+		return fmt.Errorf("unknown test directive %s", tok.value)
+	}
+
+	return nil
+}
+
+// skipDirective skips the arguments of a directive on the same line and a following block in braces
+func skipDirective(t *tokenizer, directive token) error {
+	for {
+		tok, err := t.peek()
+		if err != nil || tok.line != directive.line && tok.value != "{" || tok.value == "}" && !tok.quoted {
+			return nil
+		}
+		_, _ = t.next()
+
+		if tok.value == "{" && !tok.quoted {
+			return skipBlock(t)
+		}
+	}
+}
+
+// skipBlock skips the tokens up to the brace which closes an already read opening brace
+func skipBlock(t *tokenizer) error {
+	for depth := 1; depth > 0; {
+		tok, err := t.next()
+		if err != nil {
+			return fmt.Errorf("unterminated block")
+		}
+		if tok.quoted {
+			continue
+		}
+		switch tok.value {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+	}
+	return nil
+}
+
+func parseAttr(t *tokenizer) (Attr, error) {
+	args, err := nextValues(t, 2)
+	if err != nil {
+		return Attr{}, err
+	}
+
+	tag, ok := ipp.TagByName(args[0])
+	if !ok || tag < ipp.TagUnsupported {
+		return Attr{}, fmt.Errorf("unknown value tag %s", args[0])
+	}
+	attr := Attr{Tag: tag, Name: args[1]}
+
+	// out-of-band values have no value
+	if tag < ipp.TagInteger {
+		return attr, nil
+	}
+
+	if tag != ipp.TagBeginCollection {
+		value, err := t.next()
+		if err != nil {
+			return Attr{}, fmt.Errorf("missing value of attribute %s", attr.Name)
+		}
+		attr.Values = splitValues(value.value)
+		return attr, nil
+	}
+
+	for {
+		tok, err := t.next()
+		if err != nil || tok.value != "{" {
+			return Attr{}, fmt.Errorf("expected { for collection %s", attr.Name)
+		}
+
+		members, err := parseMembers(t)
+		if err != nil {
+			return Attr{}, err
+		}
+		attr.Collections = append(attr.Collections, members)
+
+		// additional collection values are separated by commas
+		if next, err := t.peek(); err != nil || next.value != "," {
+			return attr, nil
+		}
+		_, _ = t.next()
+	}
+}
+
+func parseMembers(t *tokenizer) ([]Attr, error) {
+	members := make([]Attr, 0)
+
+	for {
+		tok, err := t.next()
+		if err != nil {
+			return nil, fmt.Errorf("unterminated collection")
+		}
+
+		switch tok.value {
+		case "}":
+			return members, nil
+		case "MEMBER":
+			member, err := parseAttr(t)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, member)
+		default:
+			return nil, fmt.Errorf("unexpected %s in collection", tok.value)
+		}
+	}
+}
+
+func parseStatus(t *tokenizer) (Status, error) {
+	args, err := nextValues(t, 1)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{}
+	if status.Code, err = parseStatusCode(args[0]); err != nil {
+		return Status{}, err
+	}
+
+	for {
+		tok, err := t.peek()
+		if err != nil {
+			return status, nil
+		}
+
+		ok, err := parseControl(t, tok.value, &status.Control)
+		if err != nil {
+			return Status{}, err
+		}
+		if !ok {
+			return status, nil
+		}
+	}
+}
+
+func parseExpect(t *tokenizer) (Expect, error) {
+	args, err := nextValues(t, 1)
+	if err != nil {
+		return Expect{}, err
+	}
+
+	expect := Expect{Name: args[0]}
+	switch {
+	case strings.HasPrefix(expect.Name, "!"):
+		expect.NotExpected = true
+		expect.Name = expect.Name[1:]
+	case strings.HasPrefix(expect.Name, "?"):
+		expect.Optional = true
+		expect.Name = expect.Name[1:]
+	}
+
+	for {
+		tok, err := t.peek()
+		if err != nil {
+			return expect, nil
+		}
+
+		ok, err := parseControl(t, tok.value, &expect.Control)
+		if err != nil {
+			return Expect{}, err
+		}
+		if ok {
+			continue
+		}
+
+		switch tok.value {
+		case "OF-TYPE":
+			_, _ = t.next()
+			args, err := nextValues(t, 1)
+			if err != nil {
+				return Expect{}, err
+			}
+			for _, name := range strings.Split(args[0], "|") {
+				tags, ok := valueTypes[name]
+				if !ok {
+					tag, found := ipp.TagByName(name)
+					if !found {
+						return Expect{}, fmt.Errorf("unknown value type %s", name)
+					}
+					tags = []int8{tag}
+				}
+				expect.OfType = append(expect.OfType, tags...)
+			}
+		case "IN-GROUP":
+			_, _ = t.next()
+			args, err := nextValues(t, 1)
+			if err != nil {
+				return Expect{}, err
+			}
+			tag, ok := ipp.TagByName(args[0])
+			if !ok || tag >= ipp.TagUnsupported {
+				return Expect{}, fmt.Errorf("unknown group %s", args[0])
+			}
+			expect.InGroup = tag
+		case "COUNT":
+			_, _ = t.next()
+			args, err := nextValues(t, 1)
+			if err != nil {
+				return Expect{}, err
+			}
+			if expect.Count, err = strconv.Atoi(args[0]); err != nil || expect.Count <= 0 {
+				return Expect{}, fmt.Errorf("invalid count %s", args[0])
+			}
+		case "SAME-COUNT-AS", "WITH-VALUE-FROM", "DEFINE-VALUE":
+			_, _ = t.next()
+			args, err := nextValues(t, 1)
+			if err != nil {
+				return Expect{}, err
+			}
+			switch tok.value {
+			case "SAME-COUNT-AS":
+				expect.SameCountAs = args[0]
+			case "WITH-VALUE-FROM":
+				expect.WithValueFrom = args[0]
+			default:
+				expect.DefineValue = args[0]
+			}
+		case "WITH-VALUE", "WITH-ALL-VALUES":
+			_, _ = t.next()
+			args, err := nextValues(t, 1)
+			if err != nil {
+				return Expect{}, err
+			}
+			expect.WithValue = args[0]
+			expect.WithAllValues = tok.value == "WITH-ALL-VALUES"
+		case "WITH-DISTINCT-VALUES":
+			_, _ = t.next()
+			expect.WithDistinctValues = true
+		default:
+			return expect, nil
+		}
+	}
+}
+
+// parseControl parses a predicate shared by STATUS and EXPECT, it returns false if name is no such predicate
+func parseControl(t *tokenizer, name string, c *Control) (bool, error) {
+	switch name {
+	case "IF-DEFINED", "IF-NOT-DEFINED", "DEFINE-MATCH", "DEFINE-NO-MATCH", "REPEAT-LIMIT":
+		_, _ = t.next()
+		args, err := nextValues(t, 1)
+		if err != nil {
+			return false, err
+		}
+
+		switch name {
+		case "IF-DEFINED":
+			c.IfDefined = args[0]
+		case "IF-NOT-DEFINED":
+			c.IfNotDefined = args[0]
+		case "DEFINE-MATCH":
+			c.DefineMatch = args[0]
+		case "DEFINE-NO-MATCH":
+			c.DefineNoMatch = args[0]
+		default:
+			if c.RepeatLimit, err = strconv.Atoi(args[0]); err != nil || c.RepeatLimit <= 0 {
+				return false, fmt.Errorf("invalid repeat limit %s", args[0])
+			}
+		}
+	case "REPEAT-MATCH":
+		_, _ = t.next()
+		c.RepeatMatch = true
+	case "REPEAT-NO-MATCH":
+		_, _ = t.next()
+		c.RepeatNoMatch = true
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// valueTypes are the OF-TYPE names which match more than one tag
+var valueTypes = map[string][]int8{
+	"text": {ipp.TagText, ipp.TagTextLang},
+	"name": {ipp.TagName, ipp.TagNameLang},
+}
+
+func parseOperation(s string) (int16, error) {
+	if op, ok := ipp.OperationByName(s); ok {
+		return op, nil
+	}
+
+	if op, err := strconv.ParseInt(s, 0, 16); err == nil {
+		return int16(op), nil
+	}
+
+	return 0, fmt.Errorf("unknown operation %s", s)
+}
+
+func parseStatusCode(s string) (int16, error) {
+	if status, ok := ipp.StatusByName(s); ok {
+		return status, nil
+	}
+
+	if status, err := strconv.ParseInt(s, 0, 16); err == nil {
+		return int16(status), nil
+	}
+
+	return 0, fmt.Errorf("unknown status %s", s)
+}
+
+// parseDelay parses seconds[,repeat-seconds]
+func parseDelay(s string) (time.Duration, time.Duration, error) {
+	parts := strings.SplitN(s, ",", 2)
+
+	seconds := make([]time.Duration, len(parts))
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(part, 64)
+		if err != nil || f < 0 {
+			return 0, 0, fmt.Errorf("invalid delay %s", s)
+		}
+		seconds[i] = time.Duration(f * float64(time.Second))
+	}
+
+	if len(seconds) == 1 {
+		return seconds[0], seconds[0], nil
+	}
+	return seconds[0], seconds[1], nil
+}
+
+func nextValues(t *tokenizer, n int) ([]string, error) {
+	values := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		tok, err := t.next()
+		if err != nil || !tok.quoted && (tok.value == "{" || tok.value == "}") {
+			return nil, fmt.Errorf("missing argument")
+		}
+		values = append(values, tok.value)
+	}
+
+	return values, nil
+}
+
+func nextBool(t *tokenizer) (bool, error) {
+	args, err := nextValues(t, 1)
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "yes", "true", "on":
+		return true, nil
+	case "no", "false", "off":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("invalid boolean %s", args[0])
+}
+
+func lineError(tok token, err error) error {
+	return fmt.Errorf("line %d: %w", tok.line, err)
+}
+
+func resolvePath(base, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(base), path)
+}
@@ -0,0 +1,876 @@
+package ipptool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phin1x/go-ipp"
+)
+
+// DefaultRepeatLimit is the number of times a test is repeated by REPEAT-MATCH or REPEAT-NO-MATCH if no REPEAT-LIMIT
+// is given
+const DefaultRepeatLimit = 1000
+
+// Runner executes tests against an ipp.Adapter
+type Runner struct {
+	Adapter ipp.Adapter
+	// URI is the printer uri the tests are run against, it defines the variables $uri, $scheme, $hostname, $port
+	// and $resource
+	URI string
+	// Variables are defined before the first test, e.g. user or filename
+	Variables map[string]string
+	// ContinueOnError runs the remaining tests after a failed test, by default the run is stopped unless the test
+	// ignores errors
+	ContinueOnError bool
+}
+
+// Result is the outcome of a single test
+type Result struct {
+	Name string
+	// ID is the TEST-ID of the test
+	ID        string
+	Operation int16
+	// StatusCode is the status code of the last response, ipp.StatusCupsInvalid if no response was received
+	StatusCode int16
+	Passed     bool
+	Skipped    bool
+	// IgnoreErrors is set if the test is not taken into account for the report
+	IgnoreErrors bool
+	Failures     []string
+	// Displays contains the values of the attributes listed by DISPLAY
+	Displays map[string][]string
+	// Repeats is the number of times the test was repeated
+	Repeats int
+}
+
+// Report holds the results of all executed tests
+type Report struct {
+	Results []Result
+}
+
+// Passed returns true if all tests passed or were skipped, failed tests with IGNORE-ERRORS are not taken into account
+func (r *Report) Passed() bool {
+	for _, result := range r.Results {
+		if !result.Passed && !result.Skipped && !result.IgnoreErrors {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String formats the report similar to the output of ipptool
+func (r *Report) String() string {
+	var sb strings.Builder
+	for _, result := range r.Results {
+		status := "PASS"
+		switch {
+		case result.Skipped:
+			status = "SKIP"
+		case !result.Passed:
+			status = "FAIL"
+		}
+
+		fmt.Fprintf(&sb, "%-60s [%s]\n", result.Name, status)
+		for _, failure := range result.Failures {
+			fmt.Fprintf(&sb, "    %s\n", failure)
+		}
+		names := make([]string, 0, len(result.Displays))
+		for name := range result.Displays {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&sb, "    %s = %s\n", name, strings.Join(result.Displays[name], ","))
+		}
+	}
+
+	return sb.String()
+}
+
+// run holds the state of a single run
+type run struct {
+	runner    *Runner
+	vars      map[string]string
+	requestID int32
+	failed    bool
+}
+
+// Run executes all tests of the file. test failures are reported in the returned report, an error is only returned
+// if the run could not be executed at all
+func (r *Runner) Run(ctx context.Context, file *File) (*Report, error) {
+	if r.Adapter == nil {
+		return nil, errors.New("ipptool: runner has no adapter")
+	}
+
+	state := &run{
+		runner: r,
+		vars:   make(map[string]string),
+	}
+
+	if r.URI != "" {
+		uri, err := url.Parse(r.URI)
+		if err != nil {
+			return nil, fmt.Errorf("ipptool: invalid uri %s: %w", r.URI, err)
+		}
+
+		port := uri.Port()
+		if port == "" {
+			port = "631"
+			if uri.Scheme == "ipps" || uri.Scheme == "https" {
+				port = "443"
+			}
+		}
+
+		state.vars["uri"] = r.URI
+		state.vars["scheme"] = uri.Scheme
+		state.vars["hostname"] = uri.Hostname()
+		state.vars["port"] = port
+		state.vars["resource"] = uri.Path
+	}
+	for name, value := range r.Variables {
+		state.vars[name] = value
+	}
+
+	report := &Report{}
+	for _, test := range file.Tests {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		result := state.runTest(ctx, test)
+		report.Results = append(report.Results, result)
+
+		if !result.Passed && !result.Skipped {
+			state.failed = true
+			if !test.IgnoreErrors && !r.ContinueOnError {
+				break
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (s *run) runTest(ctx context.Context, test *Test) Result {
+	result := Result{
+		Name:         test.Name,
+		ID:           test.ID,
+		Operation:    test.Operation,
+		StatusCode:   ipp.StatusCupsInvalid,
+		IgnoreErrors: test.IgnoreErrors,
+	}
+
+	for _, define := range test.Defines {
+		if _, ok := s.vars[define.Name]; ok && define.Default {
+			continue
+		}
+		s.vars[define.Name] = s.expand(define.Value)
+	}
+
+	if s.skip(test) {
+		result.Skipped = true
+		return result
+	}
+
+	if test.PassIfDefined != "" && s.defined(test.PassIfDefined) || test.PassIfNotDefined != "" && !s.defined(test.PassIfNotDefined) {
+		result.Passed = true
+		return result
+	}
+
+	if test.Delay > 0 {
+		if err := sleep(ctx, test.Delay); err != nil {
+			result.Failures = append(result.Failures, err.Error())
+			return result
+		}
+	}
+
+	for {
+		resp, err := s.send(ctx, test)
+		if err != nil {
+			result.Failures = []string{err.Error()}
+			return result
+		}
+		result.StatusCode = resp.StatusCode
+
+		// job-id and job-uri are available as variables for the following tests
+		if attr := findAttribute(resp, ipp.AttributeJobID, 0); len(attr) > 0 {
+			s.vars[ipp.AttributeJobID] = formatValue(attr[0].Value)
+		}
+		if attr := findAttribute(resp, ipp.AttributeJobURI, 0); len(attr) > 0 {
+			s.vars[ipp.AttributeJobURI] = formatValue(attr[0].Value)
+		}
+
+		failures, repeat := s.check(test, resp, result.Repeats)
+		if repeat {
+			result.Repeats++
+			if err := sleep(ctx, test.RepeatDelay); err != nil {
+				result.Failures = []string{err.Error()}
+				return result
+			}
+			continue
+		}
+
+		result.Failures = failures
+		result.Passed = len(failures) == 0
+		result.Displays = s.display(test, resp)
+		return result
+	}
+}
+
+// skip evaluates the SKIP-* directives of a test
+func (s *run) skip(test *Test) bool {
+	if test.SkipPreviousError && s.failed {
+		return true
+	}
+	if test.SkipIfDefined != "" && s.defined(test.SkipIfDefined) {
+		return true
+	}
+	if test.SkipIfNotDefined != "" && !s.defined(test.SkipIfNotDefined) {
+		return true
+	}
+	if test.SkipIfMissing != "" {
+		if _, err := os.Stat(resolvePath(test.Path, s.expand(test.SkipIfMissing))); err != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// send builds the request of the test and sends it. as adapters return an error for unsuccessful responses, the
+// response is taken from the ipp error
+func (s *run) send(ctx context.Context, test *Test) (*ipp.Response, error) {
+	s.requestID++
+	requestID := s.requestID
+	if test.RequestID > 0 {
+		requestID = test.RequestID
+	}
+
+	req := ipp.NewRequest(test.Operation, requestID)
+	if err := setVersion(req, test.Version); err != nil {
+		return nil, err
+	}
+
+	for _, group := range test.Groups {
+		var attributes map[string]any
+		switch group.Tag {
+		case ipp.TagDelimiterOperation:
+			attributes = req.OperationAttributes
+		case ipp.TagDelimiterJob:
+			attributes = req.JobAttributes
+		case ipp.TagDelimiterPrinter:
+			attributes = req.PrinterAttributes
+		default:
+			return nil, fmt.Errorf("group %s is not supported in requests", ipp.TagTypeName(group.Tag))
+		}
+
+		for _, attr := range group.Attributes {
+			values, err := s.attribute(attr)
+			if err != nil {
+				return nil, err
+			}
+			attributes[attr.Name] = values
+		}
+	}
+
+	if test.File != "" {
+		f, err := os.Open(resolvePath(test.Path, s.expand(test.File)))
+		if err != nil {
+			return nil, fmt.Errorf("unable to open document: %w", err)
+		}
+		defer f.Close()
+
+		req.File = f
+		if fi, err := f.Stat(); err == nil {
+			req.FileSize = int(fi.Size())
+		}
+
+		if test.Compression != "" && test.Compression != ipp.CompressionNone {
+			compressed, err := ipp.NewCompressReader(f, test.Compression)
+			if err != nil {
+				return nil, err
+			}
+			defer compressed.Close()
+
+			req.File = compressed
+			req.FileSize = -1
+			req.OperationAttributes[ipp.AttributeCompression] = test.Compression
+		}
+	}
+
+	httpUrl, err := s.httpUrl(test)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.runner.Adapter.SendRequestContext(ctx, httpUrl, req, io.Discard)
+	if err != nil {
+		var ippErr ipp.IPPError
+		if !errors.As(err, &ippErr) {
+			return nil, fmt.Errorf("unable to send request: %w", err)
+		}
+
+		if ippErr.Response != nil {
+			return ippErr.Response, nil
+		}
+
+		// adapters which do not decode a response, like the mock adapter, only return the status
+		resp = ipp.NewResponse(ippErr.Status, requestID)
+		resp.OperationAttributes[ipp.AttributeStatusMessage] = []ipp.Attribute{{
+			Tag:   ipp.TagText,
+			Name:  ipp.AttributeStatusMessage,
+			Value: ippErr.Message,
+		}}
+	}
+
+	return resp, nil
+}
+
+// httpUrl converts the printer uri into the http url the request is sent to
+func (s *run) httpUrl(test *Test) (string, error) {
+	uri, err := url.Parse(s.vars["uri"])
+	if err != nil || uri.Host == "" {
+		return "", fmt.Errorf("invalid uri %q", s.vars["uri"])
+	}
+
+	switch uri.Scheme {
+	case "ipp":
+		uri.Scheme = "http"
+	case "ipps":
+		uri.Scheme = "https"
+	}
+
+	if uri.Port() == "" {
+		uri.Host = fmt.Sprintf("%s:%s", uri.Hostname(), s.vars["port"])
+	}
+	if test.Resource != "" {
+		uri.Path = s.expand(test.Resource)
+	}
+
+	return uri.String(), nil
+}
+
+// attribute converts an ATTR of the test into request attribute values
+func (s *run) attribute(attr Attr) ([]ipp.Attribute, error) {
+	if attr.Tag < ipp.TagInteger {
+		return []ipp.Attribute{{Tag: attr.Tag, Name: attr.Name}}, nil
+	}
+
+	if attr.Tag == ipp.TagBeginCollection {
+		values := make([]ipp.Attribute, 0, len(attr.Collections))
+		for _, members := range attr.Collections {
+			col := make(ipp.Collection, len(members))
+			for _, member := range members {
+				memberValues, err := s.attribute(member)
+				if err != nil {
+					return nil, err
+				}
+				col[member.Name] = memberValues
+			}
+			values = append(values, ipp.Attribute{Tag: attr.Tag, Name: attr.Name, Value: col})
+		}
+		return values, nil
+	}
+
+	values := make([]ipp.Attribute, 0, len(attr.Values))
+	for _, raw := range attr.Values {
+		value, err := parseValue(attr.Tag, s.expand(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for attribute %s: %w", attr.Name, err)
+		}
+		values = append(values, ipp.Attribute{Tag: attr.Tag, Name: attr.Name, Value: value})
+	}
+
+	return values, nil
+}
+
+// check evaluates the STATUS and EXPECT directives. repeat is true if the test has to be repeated
+func (s *run) check(test *Test, resp *ipp.Response, repeats int) (failures []string, repeat bool) {
+	canRepeat := func(c Control) bool {
+		limit := c.RepeatLimit
+		if limit == 0 {
+			limit = DefaultRepeatLimit
+		}
+		return repeats < limit
+	}
+
+	statusChecked, statusMatched := false, false
+	for _, status := range test.Statuses {
+		if !s.applies(status.Control) {
+			continue
+		}
+
+		matched := resp.StatusCode == status.Code
+		if s.define(status.Control, matched) {
+			continue
+		}
+		if status.RepeatMatch && matched || status.RepeatNoMatch && !matched {
+			if canRepeat(status.Control) {
+				return nil, true
+			}
+		}
+
+		statusChecked = true
+		statusMatched = statusMatched || matched
+	}
+
+	switch {
+	case statusChecked && !statusMatched:
+		expected := make([]string, 0, len(test.Statuses))
+		for _, status := range test.Statuses {
+			expected = append(expected, ipp.StatusName(status.Code))
+		}
+		failures = append(failures, fmt.Sprintf("EXPECTED: STATUS %s (got %s)", strings.Join(expected, " or "), ipp.StatusName(resp.StatusCode)))
+	case len(test.Statuses) == 0 && resp.StatusCode >= ipp.StatusRedirectionOtherSite:
+		failures = append(failures, fmt.Sprintf("EXPECTED: successful status (got %s)", ipp.StatusName(resp.StatusCode)))
+	}
+
+	for _, expect := range test.Expects {
+		if !s.applies(expect.Control) {
+			continue
+		}
+
+		failure := s.expect(expect, resp)
+		matched := failure == ""
+
+		if attrs := findAttribute(resp, expect.Name, expect.InGroup); expect.DefineValue != "" && matched && len(attrs) > 0 {
+			s.vars[expect.DefineValue] = formatValues(attrs)
+		}
+
+		if s.define(expect.Control, matched) {
+			continue
+		}
+		if expect.RepeatMatch && matched || expect.RepeatNoMatch && !matched {
+			if canRepeat(expect.Control) {
+				return nil, true
+			}
+		}
+
+		if !matched {
+			failures = append(failures, failure)
+		}
+	}
+
+	return failures, false
+}
+
+// expect checks a single EXPECT, it returns a failure message or an empty string. EXPECT-ALL checks every occurrence
+// of the attribute
+func (s *run) expect(expect Expect, resp *ipp.Response) string {
+	occurrences := findAttributes(resp, expect.Name, expect.InGroup)
+	if len(occurrences) == 0 {
+		return s.expectValues(expect, resp, nil)
+	}
+	if !expect.All {
+		return s.expectValues(expect, resp, occurrences[0])
+	}
+
+	for _, attrs := range occurrences {
+		if failure := s.expectValues(expect, resp, attrs); failure != "" {
+			return failure
+		}
+	}
+	return ""
+}
+
+// expectValues checks the predicates of an EXPECT against the values of an attribute, attrs is empty if the
+// attribute was not returned
+func (s *run) expectValues(expect Expect, resp *ipp.Response, attrs []ipp.Attribute) string {
+	if expect.NotExpected {
+		if len(attrs) > 0 {
+			return fmt.Sprintf("EXPECTED: NOT %s", expect.Name)
+		}
+		return ""
+	}
+
+	if len(attrs) == 0 {
+		if expect.Optional {
+			return ""
+		}
+		if expect.InGroup != 0 {
+			return fmt.Sprintf("EXPECTED: %s IN-GROUP %s", expect.Name, ipp.TagTypeName(expect.InGroup))
+		}
+		return fmt.Sprintf("EXPECTED: %s", expect.Name)
+	}
+
+	if len(expect.OfType) > 0 {
+		for _, attr := range attrs {
+			if !containsTag(expect.OfType, attr.Tag) {
+				return fmt.Sprintf("EXPECTED: %s OF-TYPE %s (got %s)", expect.Name, typeNames(expect.OfType), ipp.TagTypeName(attr.Tag))
+			}
+		}
+	}
+
+	if expect.Count > 0 && len(attrs) != expect.Count {
+		return fmt.Sprintf("EXPECTED: %s COUNT %d (got %d)", expect.Name, expect.Count, len(attrs))
+	}
+
+	if expect.SameCountAs != "" {
+		other := findAttribute(resp, expect.SameCountAs, 0)
+		if len(other) != len(attrs) {
+			return fmt.Sprintf("EXPECTED: %s (%d values) SAME-COUNT-AS %s (%d values)", expect.Name, len(attrs), expect.SameCountAs, len(other))
+		}
+	}
+
+	if expect.WithValue != "" {
+		pattern := s.expand(expect.WithValue)
+
+		matches := 0
+		for _, attr := range attrs {
+			ok, err := matchValue(attr.Value, pattern)
+			if err != nil {
+				return fmt.Sprintf("EXPECTED: %s WITH-VALUE %s: %v", expect.Name, pattern, err)
+			}
+			if ok {
+				matches++
+			}
+		}
+
+		if expect.WithAllValues && matches != len(attrs) {
+			return fmt.Sprintf("EXPECTED: %s WITH-ALL-VALUES %s (got %s)", expect.Name, pattern, formatValues(attrs))
+		}
+		if matches == 0 {
+			return fmt.Sprintf("EXPECTED: %s WITH-VALUE %s (got %s)", expect.Name, pattern, formatValues(attrs))
+		}
+	}
+
+	if expect.WithValueFrom != "" {
+		allowed := make(map[string]bool)
+		for _, attr := range findAttribute(resp, expect.WithValueFrom, 0) {
+			allowed[formatValue(attr.Value)] = true
+		}
+
+		for _, attr := range attrs {
+			if !allowed[formatValue(attr.Value)] {
+				return fmt.Sprintf("EXPECTED: %s WITH-VALUE-FROM %s (got %s)", expect.Name, expect.WithValueFrom, formatValue(attr.Value))
+			}
+		}
+	}
+
+	if expect.WithDistinctValues {
+		seen := make(map[string]bool, len(attrs))
+		for _, attr := range attrs {
+			value := formatValue(attr.Value)
+			if seen[value] {
+				return fmt.Sprintf("EXPECTED: %s WITH-DISTINCT-VALUES (got duplicate %s)", expect.Name, value)
+			}
+			seen[value] = true
+		}
+	}
+
+	return ""
+}
+
+// applies evaluates IF-DEFINED and IF-NOT-DEFINED
+func (s *run) applies(c Control) bool {
+	if c.IfDefined != "" && !s.defined(c.IfDefined) {
+		return false
+	}
+	if c.IfNotDefined != "" && s.defined(c.IfNotDefined) {
+		return false
+	}
+	return true
+}
+
+// define evaluates DEFINE-MATCH and DEFINE-NO-MATCH, it returns true if the control only defines variables and
+// therefore never fails
+func (s *run) define(c Control, matched bool) bool {
+	if c.DefineMatch != "" && matched {
+		s.vars[c.DefineMatch] = "1"
+	}
+	if c.DefineNoMatch != "" && !matched {
+		s.vars[c.DefineNoMatch] = "1"
+	}
+
+	return c.DefineMatch != "" || c.DefineNoMatch != ""
+}
+
+func (s *run) display(test *Test, resp *ipp.Response) map[string][]string {
+	if len(test.Displays) == 0 {
+		return nil
+	}
+
+	displays := make(map[string][]string, len(test.Displays))
+	for _, name := range test.Displays {
+		attrs := findAttribute(resp, name, 0)
+		values := make([]string, 0, len(attrs))
+		for _, attr := range attrs {
+			values = append(values, formatValue(attr.Value))
+		}
+		displays[name] = values
+	}
+
+	return displays
+}
+
+func (s *run) defined(name string) bool {
+	_, ok := s.vars[name]
+	return ok
+}
+
+// variablePattern matches $name, ${name}, $ENV[name] and $$
+var variablePattern = regexp.MustCompile(`\$(\$|ENV\[[^\]]*\]|\{[^}]*\}|[A-Za-z0-9_.-]+)`)
+
+// expand replaces the variables in s with their values, undefined variables are replaced with an empty string
+func (s *run) expand(value string) string {
+	return variablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		name := match[1:]
+		switch {
+		case name == "$":
+			return "$"
+		case strings.HasPrefix(name, "ENV["):
+			return os.Getenv(name[4 : len(name)-1])
+		case strings.HasPrefix(name, "{"):
+			name = name[1 : len(name)-1]
+		}
+
+		return s.vars[name]
+	})
+}
+
+// findAttribute returns the values of the first attribute with the given name. if group is not zero only the
+// attributes of that group are searched
+func findAttribute(resp *ipp.Response, name string, group int8) []ipp.Attribute {
+	if occurrences := findAttributes(resp, name, group); len(occurrences) > 0 {
+		return occurrences[0]
+	}
+	return nil
+}
+
+// findAttributes returns the values of every occurrence of the attribute, e.g. in each job of a Get-Jobs response
+func findAttributes(resp *ipp.Response, name string, group int8) [][]ipp.Attribute {
+	groups := []struct {
+		tag        int8
+		attributes []ipp.Attributes
+	}{
+		{ipp.TagDelimiterOperation, []ipp.Attributes{resp.OperationAttributes}},
+		{ipp.TagDelimiterUnsupported, []ipp.Attributes{resp.UnsupportedAttributes}},
+		{ipp.TagDelimiterPrinter, resp.PrinterAttributes},
+		{ipp.TagDelimiterJob, resp.JobAttributes},
+	}
+
+	var occurrences [][]ipp.Attribute
+	for _, g := range groups {
+		if group != 0 && g.tag != group {
+			continue
+		}
+
+		for _, attributes := range g.attributes {
+			if attrs, ok := attributes[name]; ok && len(attrs) > 0 {
+				occurrences = append(occurrences, attrs)
+			}
+		}
+	}
+
+	return occurrences
+}
+
+// parseValue converts a value of an ATTR into the type used by the ipp encoder for the tag
+func parseValue(tag int8, value string) (any, error) {
+	switch tag {
+	case ipp.TagInteger, ipp.TagEnum:
+		return strconv.Atoi(value)
+	case ipp.TagBoolean:
+		return strconv.ParseBool(value)
+	case ipp.TagRange:
+		lower, upper, found := strings.Cut(value, "-")
+		if !found {
+			upper = lower
+		}
+
+		l, err := strconv.ParseInt(lower, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		u, err := strconv.ParseInt(upper, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		return []int32{int32(l), int32(u)}, nil
+	case ipp.TagResolution:
+		return parseResolution(value)
+	case ipp.TagDate, ipp.TagTextLang, ipp.TagNameLang:
+		return nil, fmt.Errorf("values of type %s are not supported", ipp.TagTypeName(tag))
+	}
+
+	return value, nil
+}
+
+// parseResolution parses resolutions like 300dpi or 600x300dpcm
+func parseResolution(value string) (ipp.Resolution, error) {
+	units := int8(3)
+	switch {
+	case strings.HasSuffix(value, "dpi"):
+		value = strings.TrimSuffix(value, "dpi")
+	case strings.HasSuffix(value, "dpcm"):
+		value = strings.TrimSuffix(value, "dpcm")
+		units = 4
+	default:
+		return ipp.Resolution{}, fmt.Errorf("resolution %s has no unit", value)
+	}
+
+	x, y, found := strings.Cut(value, "x")
+	if !found {
+		y = x
+	}
+
+	xres, err := strconv.ParseInt(x, 10, 32)
+	if err != nil {
+		return ipp.Resolution{}, err
+	}
+	yres, err := strconv.ParseInt(y, 10, 32)
+	if err != nil {
+		return ipp.Resolution{}, err
+	}
+
+	// the encoder writes Height before Width, which are the cross feed and feed resolution on the wire
+	return ipp.Resolution{Height: int32(xres), Width: int32(yres), Depth: units}, nil
+}
+
+// matchValue matches a response value against a WITH-VALUE pattern. /regex/ patterns are matched against the
+// formatted value, integers accept a comma separated list of n, <n, >n and n-m
+func matchValue(value any, pattern string) (bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(formatValue(value)), nil
+	}
+
+	i, ok := value.(int)
+	if !ok {
+		return formatValue(value) == pattern, nil
+	}
+
+	for _, condition := range strings.Split(pattern, ",") {
+		var op string
+		switch {
+		case strings.HasPrefix(condition, "<="), strings.HasPrefix(condition, ">="):
+			op, condition = condition[:2], condition[2:]
+		case strings.HasPrefix(condition, "<"), strings.HasPrefix(condition, ">"), strings.HasPrefix(condition, "="):
+			op, condition = condition[:1], condition[1:]
+		}
+
+		if lower, upper, found := strings.Cut(condition, "-"); found && op == "" && lower != "" {
+			l, err1 := strconv.ParseInt(lower, 0, 32)
+			u, err2 := strconv.ParseInt(upper, 0, 32)
+			if err1 != nil || err2 != nil {
+				return false, fmt.Errorf("invalid range %s", condition)
+			}
+			if int64(i) >= l && int64(i) <= u {
+				return true, nil
+			}
+			continue
+		}
+
+		n, err := strconv.ParseInt(condition, 0, 32)
+		if err != nil {
+			return false, fmt.Errorf("invalid number %s", condition)
+		}
+
+		switch v := int64(i); {
+		case op == "<" && v < n, op == "<=" && v <= n, op == ">" && v > n, op == ">=" && v >= n, (op == "" || op == "=") && v == n:
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// formatValue formats a value the way it is written in test files
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case []int32:
+		if len(v) == 2 {
+			return fmt.Sprintf("%d-%d", v[0], v[1])
+		}
+	case ipp.Resolution:
+		unit := "dpi"
+		if v.Depth == 4 {
+			unit = "dpcm"
+		}
+		if v.Height == v.Width {
+			return fmt.Sprintf("%d%s", v.Height, unit)
+		}
+		return fmt.Sprintf("%dx%d%s", v.Height, v.Width, unit)
+	case ipp.Collection:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		members := make([]string, 0, len(v))
+		for _, name := range names {
+			members = append(members, fmt.Sprintf("%s=%s", name, formatValues(v[name])))
+		}
+		return "{" + strings.Join(members, " ") + "}"
+	}
+
+	return fmt.Sprint(value)
+}
+
+func formatValues(attrs []ipp.Attribute) string {
+	values := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		values = append(values, formatValue(attr.Value))
+	}
+	return strings.Join(values, ",")
+}
+
+func containsTag(tags []int8, tag int8) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func typeNames(tags []int8) string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, ipp.TagTypeName(tag))
+	}
+	return strings.Join(names, "|")
+}
+
+func setVersion(req *ipp.Request, version string) error {
+	major, minor, found := strings.Cut(version, ".")
+	ma, err1 := strconv.ParseInt(major, 10, 8)
+	mi, err2 := strconv.ParseInt(minor, 10, 8)
+	if !found || err1 != nil || err2 != nil {
+		return fmt.Errorf("invalid version %s", version)
+	}
+
+	req.ProtocolVersionMajor = int8(ma)
+	req.ProtocolVersionMinor = int8(mi)
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
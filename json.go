@@ -0,0 +1,536 @@
+package ipp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+/*
+the json encoding follows the PWG IPP-JSON conventions as used by ipptool -j. a message is written as
+
+	{
+	  "version": "2.0",
+	  "operation": "Get-Printer-Attributes",
+	  "request-id": 1,
+	  "groups": [
+	    {"operation-attributes-tag": {"attributes-charset": "utf-8", "printer-uri": "ipp://localhost/printers/test"}},
+	    {"job-attributes-tag": {"copies": 2, "media-col": {"media-type": "stationery"}}}
+	  ]
+	}
+
+responses contain status-code instead of operation. a single value is written as json value, multiple values as
+array. collections are objects, ranges are written as {"lower": 1, "upper": 5}, resolutions as
+{"xres": 600, "yres": 600, "units": "dpi"} and dates as ISO 8601 string.
+
+the tag of a value is taken from AttributeTagMapping when the json is read. values with another tag or of unknown
+attributes are wrapped with their type, e.g. {"type": "nameWithoutLanguage", "value": "foo"} or {"type": "no-value"}.
+the values of a multi-valued attribute with different tags are wrapped one by one. plain values of unknown attributes
+get their tag from the json type: strings are names, numbers integers, booleans booleans and objects collections
+*/
+
+type jsonMessage struct {
+	Version    string                  `json:"version"`
+	Operation  string                  `json:"operation,omitempty"`
+	StatusCode string                  `json:"status-code,omitempty"`
+	RequestID  int32                   `json:"request-id"`
+	Groups     []map[string]Attributes `json:"groups"`
+}
+
+type jsonTypedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type jsonRange struct {
+	Lower int32 `json:"lower"`
+	Upper int32 `json:"upper"`
+}
+
+type jsonResolution struct {
+	XRes  int32  `json:"xres"`
+	YRes  int32  `json:"yres"`
+	Units string `json:"units"`
+}
+
+// MarshalJSON encodes the request in the IPP-JSON format
+func (r *Request) MarshalJSON() ([]byte, error) {
+	msg := jsonMessage{
+		Version:   fmt.Sprintf("%d.%d", r.ProtocolVersionMajor, r.ProtocolVersionMinor),
+		Operation: OperationName(r.Operation),
+		RequestID: r.RequestId,
+	}
+
+	groups := []struct {
+		tag        int8
+		attributes map[string]any
+	}{
+		{TagDelimiterOperation, r.OperationAttributes},
+		{TagDelimiterJob, r.JobAttributes},
+		{TagDelimiterPrinter, r.PrinterAttributes},
+	}
+
+	for _, group := range groups {
+		if group.tag != TagDelimiterOperation && len(group.attributes) == 0 {
+			continue
+		}
+
+		attributes := make(Attributes, len(group.attributes))
+		for name, value := range group.attributes {
+			attributes[name] = newAttribute(name, value)
+		}
+		msg.Groups = append(msg.Groups, map[string]Attributes{TagTypeName(group.tag): attributes})
+	}
+
+	return json.Marshal(msg)
+}
+
+// UnmarshalJSON decodes a request in the IPP-JSON format. the attribute values are stored as []Attribute to keep
+// their tags
+func (r *Request) UnmarshalJSON(data []byte) error {
+	var msg jsonMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	major, minor, err := parseJSONVersion(msg.Version)
+	if err != nil {
+		return err
+	}
+	operation, err := parseTextCode(msg.Operation, OperationByName)
+	if err != nil {
+		return fmt.Errorf("invalid operation: %w", err)
+	}
+
+	*r = Request{
+		ProtocolVersionMajor: major,
+		ProtocolVersionMinor: minor,
+		Operation:            operation,
+		RequestId:            msg.RequestID,
+		OperationAttributes:  make(map[string]any),
+		JobAttributes:        make(map[string]any),
+		PrinterAttributes:    make(map[string]any),
+		FileSize:             -1,
+	}
+
+	return eachJSONGroup(msg.Groups, func(tag int8, attributes Attributes) error {
+		var group map[string]any
+		switch tag {
+		case TagDelimiterOperation:
+			group = r.OperationAttributes
+		case TagDelimiterJob:
+			group = r.JobAttributes
+		case TagDelimiterPrinter:
+			group = r.PrinterAttributes
+		default:
+			return fmt.Errorf("group %s is not supported in requests", TagTypeName(tag))
+		}
+
+		for name, values := range attributes {
+			group[name] = values
+		}
+		return nil
+	})
+}
+
+// MarshalJSON encodes the response in the IPP-JSON format
+func (r *Response) MarshalJSON() ([]byte, error) {
+	msg := jsonMessage{
+		Version:    fmt.Sprintf("%d.%d", r.ProtocolVersionMajor, r.ProtocolVersionMinor),
+		StatusCode: StatusName(r.StatusCode),
+		RequestID:  r.RequestId,
+		Groups: []map[string]Attributes{
+			{TagTypeName(TagDelimiterOperation): r.OperationAttributes},
+		},
+	}
+
+	if len(r.UnsupportedAttributes) > 0 {
+		msg.Groups = append(msg.Groups, map[string]Attributes{TagTypeName(TagDelimiterUnsupported): r.UnsupportedAttributes})
+	}
+	for _, attributes := range r.PrinterAttributes {
+		msg.Groups = append(msg.Groups, map[string]Attributes{TagTypeName(TagDelimiterPrinter): attributes})
+	}
+	for _, attributes := range r.JobAttributes {
+		msg.Groups = append(msg.Groups, map[string]Attributes{TagTypeName(TagDelimiterJob): attributes})
+	}
+
+	return json.Marshal(msg)
+}
+
+// UnmarshalJSON decodes a response in the IPP-JSON format
+func (r *Response) UnmarshalJSON(data []byte) error {
+	var msg jsonMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	major, minor, err := parseJSONVersion(msg.Version)
+	if err != nil {
+		return err
+	}
+	status, err := parseTextCode(msg.StatusCode, StatusByName)
+	if err != nil {
+		return fmt.Errorf("invalid status-code: %w", err)
+	}
+
+	*r = Response{
+		ProtocolVersionMajor:  major,
+		ProtocolVersionMinor:  minor,
+		StatusCode:            status,
+		RequestId:             msg.RequestID,
+		OperationAttributes:   make(Attributes),
+		PrinterAttributes:     make([]Attributes, 0),
+		JobAttributes:         make([]Attributes, 0),
+		UnsupportedAttributes: make(Attributes),
+	}
+
+	return eachJSONGroup(msg.Groups, func(tag int8, attributes Attributes) error {
+		switch tag {
+		case TagDelimiterOperation:
+			r.OperationAttributes = attributes
+		case TagDelimiterUnsupported:
+			r.UnsupportedAttributes = attributes
+		case TagDelimiterPrinter:
+			r.PrinterAttributes = append(r.PrinterAttributes, attributes)
+		case TagDelimiterJob:
+			r.JobAttributes = append(r.JobAttributes, attributes)
+		default:
+			return fmt.Errorf("group %s is not supported in responses", TagTypeName(tag))
+		}
+		return nil
+	})
+}
+
+// MarshalJSON encodes the attributes as json object
+func (a Attributes) MarshalJSON() ([]byte, error) {
+	return marshalJSONAttributes(a)
+}
+
+// UnmarshalJSON decodes a json object into attributes
+func (a *Attributes) UnmarshalJSON(data []byte) error {
+	attributes, err := unmarshalJSONAttributes(data)
+	if err != nil {
+		return err
+	}
+
+	*a = attributes
+	return nil
+}
+
+// MarshalJSON encodes the member attributes of the collection as json object
+func (c Collection) MarshalJSON() ([]byte, error) {
+	return marshalJSONAttributes(Attributes(c))
+}
+
+// UnmarshalJSON decodes a json object into the member attributes of the collection
+func (c *Collection) UnmarshalJSON(data []byte) error {
+	attributes, err := unmarshalJSONAttributes(data)
+	if err != nil {
+		return err
+	}
+
+	*c = Collection(attributes)
+	return nil
+}
+
+func parseJSONVersion(version string) (int8, int8, error) {
+	if version == "" {
+		return ProtocolVersionMajor, ProtocolVersionMinor, nil
+	}
+	return parseVersion(version)
+}
+
+// eachJSONGroup passes every group to add, each group must be an object with a single group tag
+func eachJSONGroup(groups []map[string]Attributes, add func(tag int8, attributes Attributes) error) error {
+	for _, group := range groups {
+		if len(group) != 1 {
+			return fmt.Errorf("attribute group must contain a single group tag, got %d", len(group))
+		}
+
+		for name, attributes := range group {
+			tag, ok := TagByName(name)
+			if !ok || tag >= TagUnsupported || tag == TagDelimiterEnd {
+				return fmt.Errorf("invalid attribute group %s", name)
+			}
+			if attributes == nil {
+				attributes = make(Attributes)
+			}
+			if err := add(tag, attributes); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func marshalJSONAttributes(attributes Attributes) ([]byte, error) {
+	values := make(map[string]json.RawMessage, len(attributes))
+	for name, attrs := range attributes {
+		if len(attrs) == 0 {
+			continue
+		}
+
+		value, err := marshalJSONValues(name, attrs)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+
+	return json.Marshal(values)
+}
+
+// marshalJSONValues encodes the values of an attribute, the values are wrapped with their type if the tag can not be
+// taken from AttributeTagMapping
+func marshalJSONValues(name string, attrs []Attribute) (json.RawMessage, error) {
+	tag := attrs[0].Tag
+	if tag == 0 {
+		tag = AttributeTagMapping[name]
+	}
+
+	// values with different tags are wrapped one by one
+	for _, attr := range attrs[1:] {
+		if attr.Tag != tag && (attr.Tag != 0 || AttributeTagMapping[name] != tag) {
+			return marshalJSONMixedValues(name, attrs)
+		}
+	}
+
+	if tag >= TagUnsupported && tag < TagInteger {
+		return json.Marshal(jsonTypedValue{Type: TagTypeName(tag)})
+	}
+
+	values := make([]any, 0, len(attrs))
+	for _, attr := range attrs {
+		value, err := jsonValue(attr.Value)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		values = append(values, value)
+	}
+
+	var raw json.RawMessage
+	var err error
+	if len(values) == 1 {
+		raw, err = json.Marshal(values[0])
+	} else {
+		raw, err = json.Marshal(values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("attribute %s: %w", name, err)
+	}
+
+	if mapped, ok := AttributeTagMapping[name]; ok && mapped == tag {
+		return raw, nil
+	}
+	return json.Marshal(jsonTypedValue{Type: TagTypeName(tag), Value: raw})
+}
+
+func marshalJSONMixedValues(name string, attrs []Attribute) (json.RawMessage, error) {
+	values := make([]json.RawMessage, 0, len(attrs))
+	for _, attr := range attrs {
+		value, err := marshalJSONValues(name, []Attribute{attr})
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return json.Marshal(values)
+}
+
+func jsonValue(value any) (any, error) {
+	switch v := value.(type) {
+	case string, int, int8, int16, int32, int64, bool, Collection:
+		return v, nil
+	case []int32:
+		if len(v) == 2 {
+			return jsonRange{Lower: v[0], Upper: v[1]}, nil
+		}
+	case Resolution:
+		units := "dpi"
+		if v.Depth == 4 {
+			units = "dpcm"
+		}
+		return jsonResolution{XRes: v.Height, YRes: v.Width, Units: units}, nil
+	case []int:
+		if len(v) == 11 {
+			return formatTextDate(v), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported value type %T", value)
+}
+
+func unmarshalJSONAttributes(data []byte) (Attributes, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	attributes := make(Attributes, len(values))
+	for name, raw := range values {
+		attrs, err := unmarshalJSONValues(name, raw)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		attributes[name] = attrs
+	}
+
+	return attributes, nil
+}
+
+func unmarshalJSONValues(name string, raw json.RawMessage) ([]Attribute, error) {
+	tag, known := AttributeTagMapping[name]
+
+	if typed, ok := parseJSONTypedValue(raw); ok {
+		tag, known = typed.tag, true
+		raw = typed.value
+	}
+
+	if known && tag >= TagUnsupported && tag < TagInteger {
+		return []Attribute{{Tag: tag, Name: name}}, nil
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("missing value")
+	}
+
+	raws := []json.RawMessage{raw}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		if err := json.Unmarshal(raw, &raws); err != nil {
+			return nil, err
+		}
+	}
+
+	attrs := make([]Attribute, 0, len(raws))
+	for _, r := range raws {
+		// each value of a multi-valued attribute can be wrapped with its own type
+		valueTag := tag
+		if typed, ok := parseJSONTypedValue(r); ok {
+			valueTag, r = typed.tag, typed.value
+		} else if !known {
+			var err error
+			if valueTag, err = inferJSONTag(r); err != nil {
+				return nil, err
+			}
+		}
+
+		if valueTag >= TagUnsupported && valueTag < TagInteger {
+			attrs = append(attrs, Attribute{Tag: valueTag, Name: name})
+			continue
+		}
+		if r == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+
+		value, err := parseJSONValue(valueTag, r)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, Attribute{Tag: valueTag, Name: name, Value: value})
+	}
+
+	return attrs, nil
+}
+
+// inferJSONTag returns the tag of a plain value of an unknown attribute from its json type
+func inferJSONTag(raw json.RawMessage) (int8, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return 0, fmt.Errorf("missing value")
+	}
+
+	switch c := trimmed[0]; {
+	case c == '"':
+		return TagName, nil
+	case c == 't' || c == 'f':
+		return TagBoolean, nil
+	case c == '-' || c >= '0' && c <= '9':
+		return TagInteger, nil
+	case c == '{':
+		return TagBeginCollection, nil
+	}
+
+	return 0, fmt.Errorf("unable to infer the type of %s", trimmed)
+}
+
+type typedJSONValue struct {
+	tag   int8
+	value json.RawMessage
+}
+
+// parseJSONTypedValue checks if raw is a value wrapped with its type, an object is only treated as wrapped value if
+// it contains nothing else than a valid type and the value
+func parseJSONTypedValue(raw json.RawMessage) (typedJSONValue, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return typedJSONValue{}, false
+	}
+
+	if _, ok := fields["type"]; !ok || len(fields) > 2 {
+		return typedJSONValue{}, false
+	}
+	if _, ok := fields["value"]; !ok && len(fields) == 2 {
+		return typedJSONValue{}, false
+	}
+
+	var typed jsonTypedValue
+	if err := json.Unmarshal(raw, &typed); err != nil {
+		return typedJSONValue{}, false
+	}
+
+	tag, ok := TagByName(typed.Type)
+	if !ok || tag < TagUnsupported {
+		return typedJSONValue{}, false
+	}
+
+	return typedJSONValue{tag: tag, value: typed.Value}, true
+}
+
+func parseJSONValue(tag int8, raw json.RawMessage) (any, error) {
+	switch tag {
+	case TagInteger, TagEnum:
+		var v int
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case TagBoolean:
+		var v bool
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case TagRange:
+		var v jsonRange
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		return []int32{v.Lower, v.Upper}, nil
+	case TagResolution:
+		var v jsonResolution
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+
+		units := int8(3)
+		switch v.Units {
+		case "dpi":
+		case "dpcm":
+			units = 4
+		default:
+			return nil, fmt.Errorf("invalid resolution units %s", v.Units)
+		}
+		return Resolution{Height: v.XRes, Width: v.YRes, Depth: units}, nil
+	case TagDate:
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		return parseTextDate(v)
+	case TagBeginCollection:
+		var v Collection
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+
+	var v string
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
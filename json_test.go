@@ -0,0 +1,132 @@
+package ipp
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest_MarshalJSON(t *testing.T) {
+	req := NewRequest(OperationPrintJob, 7)
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/test"
+	req.OperationAttributes[AttributeRequestingUserName] = "user"
+	req.JobAttributes[AttributeCopies] = 2
+	req.JobAttributes["x-custom"] = []Attribute{{Tag: TagKeyword, Name: "x-custom", Value: "foo"}}
+
+	data, err := json.Marshal(req)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "2.0",
+		"operation": "Print-Job",
+		"request-id": 7,
+		"groups": [
+			{"operation-attributes-tag": {"printer-uri": "ipp://localhost/printers/test", "requesting-user-name": "user"}},
+			{"job-attributes-tag": {"copies": 2, "x-custom": {"type": "keyword", "value": "foo"}}}
+		]
+	}`, string(data))
+
+	var parsed Request
+	assert.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, OperationPrintJob, parsed.Operation)
+	assert.Equal(t, int32(7), parsed.RequestId)
+
+	assert.Equal(t, decodeTestRequest(t, req), decodeTestRequest(t, &parsed))
+}
+
+func TestResponse_MarshalJSON(t *testing.T) {
+	resp := NewResponse(StatusOk, 5)
+	resp.OperationAttributes[AttributeCharset] = []Attribute{{Tag: TagCharset, Name: AttributeCharset, Value: "utf-8"}}
+	resp.PrinterAttributes = append(resp.PrinterAttributes, Attributes{
+		AttributePrinterName: []Attribute{{Tag: TagName, Name: AttributePrinterName, Value: "test"}},
+		AttributeMediaColReady: []Attribute{
+			{Tag: TagBeginCollection, Name: AttributeMediaColReady, Value: Collection{
+				"media-type": []Attribute{{Tag: TagKeyword, Name: "media-type", Value: "stationery"}},
+			}},
+			{Tag: TagBeginCollection, Name: AttributeMediaColReady, Value: Collection{
+				"media-type": []Attribute{{Tag: TagKeyword, Name: "media-type", Value: "photographic"}},
+			}},
+		},
+		"printer-resolution-supported": []Attribute{{Tag: TagResolution, Name: "printer-resolution-supported", Value: Resolution{Height: 600, Width: 300, Depth: 3}}},
+		"copies-supported":             []Attribute{{Tag: TagRange, Name: "copies-supported", Value: []int32{1, 99}}},
+		"printer-current-time":         []Attribute{{Tag: TagDate, Name: "printer-current-time", Value: []int{7, -22, 10, 19, 12, 30, 5, 0, '+', 2, 0}}},
+		"printer-state-message":        []Attribute{{Tag: TagNoValue, Name: "printer-state-message"}},
+	})
+	resp.JobAttributes = append(resp.JobAttributes,
+		Attributes{AttributeJobID: []Attribute{{Tag: TagInteger, Name: AttributeJobID, Value: 1}}},
+		Attributes{AttributeJobID: []Attribute{{Tag: TagInteger, Name: AttributeJobID, Value: 2}}},
+	)
+
+	data, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "2.0",
+		"status-code": "successful-ok",
+		"request-id": 5,
+		"groups": [
+			{"operation-attributes-tag": {"attributes-charset": "utf-8"}},
+			{"printer-attributes-tag": {
+				"printer-name": "test",
+				"media-col-ready": [{"media-type": "stationery"}, {"media-type": "photographic"}],
+				"printer-resolution-supported": {"type": "resolution", "value": {"xres": 600, "yres": 300, "units": "dpi"}},
+				"copies-supported": {"lower": 1, "upper": 99},
+				"printer-current-time": {"type": "dateTime", "value": "2026-10-19T12:30:05.0+02:00"},
+				"printer-state-message": {"type": "no-value"}
+			}},
+			{"job-attributes-tag": {"job-id": 1}},
+			{"job-attributes-tag": {"job-id": 2}}
+		]
+	}`, string(data))
+
+	var parsed Response
+	assert.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, resp, &parsed)
+
+	encoded, err := parsed.Encode()
+	assert.NoError(t, err)
+	decoded, err := NewResponseDecoder(bytes.NewReader(encoded)).Decode(nil)
+	assert.NoError(t, err)
+	data, err = json.Marshal(&parsed)
+	assert.NoError(t, err)
+	reencoded, err := json.Marshal(decoded)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(data), string(reencoded))
+}
+
+func TestAttributes_UnmarshalJSON(t *testing.T) {
+	var attributes Attributes
+	assert.NoError(t, json.Unmarshal([]byte(`{"copies": 3, "sides": "two-sided-long-edge", "job-name": "\"quoted\""}`), &attributes))
+	assert.Equal(t, Attributes{
+		AttributeCopies:  []Attribute{{Tag: TagInteger, Name: AttributeCopies, Value: 3}},
+		AttributeSides:   []Attribute{{Tag: TagKeyword, Name: AttributeSides, Value: "two-sided-long-edge"}},
+		AttributeJobName: []Attribute{{Tag: TagName, Name: AttributeJobName, Value: "\"quoted\""}},
+	}, attributes)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"x-name": "foo", "x-count": [1, 2], "x-flag": true, "job-sheets": [{"type": "keyword", "value": "none"}, "secret"]}`), &attributes))
+	assert.Equal(t, Attributes{
+		"x-name":  []Attribute{{Tag: TagName, Name: "x-name", Value: "foo"}},
+		"x-count": []Attribute{{Tag: TagInteger, Name: "x-count", Value: 1}, {Tag: TagInteger, Name: "x-count", Value: 2}},
+		"x-flag":  []Attribute{{Tag: TagBoolean, Name: "x-flag", Value: true}},
+		AttributeJobSheets: []Attribute{
+			{Tag: TagKeyword, Name: AttributeJobSheets, Value: "none"},
+			{Tag: TagName, Name: AttributeJobSheets, Value: "secret"},
+		},
+	}, attributes)
+
+	data, err := json.Marshal(attributes)
+	assert.NoError(t, err)
+	var parsed Attributes
+	assert.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, attributes, parsed)
+
+	for _, invalid := range []string{
+		`{"x-unknown": 1.5}`,
+		`{"x-unknown": null}`,
+		`{"copies": "three"}`,
+		`{"copies": {"type": "unknown-type", "value": 1}}`,
+		`{"printer-resolution": {"xres": 600, "yres": 600, "units": "inch"}}`,
+	} {
+		assert.Error(t, json.Unmarshal([]byte(invalid), &attributes), invalid)
+	}
+}
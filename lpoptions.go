@@ -0,0 +1,394 @@
+package ipp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LpOptions is the content of a lpoptions file, e.g. ~/.cups/lpoptions or /etc/cups/lpoptions, with the saved
+// options of destinations as written by lpoptions -p printer -o option=value
+type LpOptions struct {
+	Dests []*LpDest
+}
+
+// LpDest is a Dest or Default line of a lpoptions file
+type LpDest struct {
+	Name string
+	// Instance is the part after the slash of printer/instance, empty for the printer itself
+	Instance  string
+	IsDefault bool
+	Options   map[string]string
+}
+
+// LoadLpOptions reads the lpoptions file of the server root (CUPS_SERVERROOT or /etc/cups) and the one of the user and
+// merges them like cups, options of the user file override the ones of the system file. missing files are ignored
+func LoadLpOptions() (*LpOptions, error) {
+	options := &LpOptions{Dests: make([]*LpDest, 0)}
+
+	for _, path := range lpOptionsPaths() {
+		file, err := ReadLpOptionsFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		options.Merge(file)
+	}
+
+	return options, nil
+}
+
+// lpOptionsPaths returns the paths of the system and the user lpoptions file in the order they are read
+func lpOptionsPaths() []string {
+	serverRoot := os.Getenv("CUPS_SERVERROOT")
+	if serverRoot == "" {
+		serverRoot = "/etc/cups"
+	}
+	paths := []string{filepath.Join(serverRoot, "lpoptions")}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".cups", "lpoptions"))
+	}
+
+	return paths
+}
+
+// ReadLpOptionsFile reads a lpoptions file
+func ReadLpOptionsFile(path string) (*LpOptions, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseLpOptions(f)
+}
+
+// ParseLpOptions parses the content of a lpoptions file. options without value are booleans, e.g. collate is
+// collate=true and nocollate is collate=false
+func ParseLpOptions(r io.Reader) (*LpOptions, error) {
+	options := &LpOptions{Dests: make([]*LpDest, 0)}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		directive, value := parseConfLine(scanner.Text())
+		isDefault := strings.EqualFold(directive, "Default")
+		if !isDefault && !strings.EqualFold(directive, "Dest") {
+			continue
+		}
+
+		name, rest, _ := strings.Cut(value, " ")
+		if name == "" {
+			return nil, fmt.Errorf("line %d: missing destination name", lineNumber)
+		}
+
+		dest := &LpDest{IsDefault: isDefault}
+		dest.Name, dest.Instance, _ = strings.Cut(name, "/")
+
+		var err error
+		if dest.Options, err = parseLpOptionsValues(rest); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		if isDefault {
+			for _, d := range options.Dests {
+				d.IsDefault = false
+			}
+		}
+		options.set(dest)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// parseLpOptionsValues parses space separated name=value pairs, values can be quoted with ' or " and escaped with \
+func parseLpOptionsValues(s string) (map[string]string, error) {
+	options := make(map[string]string)
+
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		end := strings.IndexAny(s, "= \t")
+		if end == -1 {
+			end = len(s)
+		}
+		name := s[:end]
+		s = s[end:]
+
+		if !strings.HasPrefix(s, "=") {
+			if strings.HasPrefix(strings.ToLower(name), "no") && len(name) > 2 {
+				options[name[2:]] = "false"
+			} else {
+				options[name] = "true"
+			}
+			continue
+		}
+		if name == "" {
+			return nil, fmt.Errorf("missing option name")
+		}
+
+		var value strings.Builder
+		var quote byte
+		i := 1
+	scan:
+		for ; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case c == '\\' && i+1 < len(s):
+				i++
+				value.WriteByte(s[i])
+			case quote != 0 && c == quote:
+				quote = 0
+			case quote != 0:
+				value.WriteByte(c)
+			case c == '"' || c == '\'':
+				quote = c
+			case c == ' ' || c == '\t':
+				break scan
+			default:
+				value.WriteByte(c)
+			}
+		}
+		if quote != 0 {
+			return nil, fmt.Errorf("unterminated quote in value of option %s", name)
+		}
+
+		options[name] = value.String()
+		s = s[i:]
+	}
+
+	return options, nil
+}
+
+// set adds a destination or merges its options into the existing one
+func (o *LpOptions) set(dest *LpDest) {
+	if existing := o.Dest(dest.Name, dest.Instance); existing != nil {
+		for name, value := range dest.Options {
+			existing.Options[name] = value
+		}
+		existing.IsDefault = existing.IsDefault || dest.IsDefault
+		return
+	}
+
+	o.Dests = append(o.Dests, dest)
+}
+
+// Merge adds the destinations of other, options of other override existing options. the default destination of
+// other replaces the current default
+func (o *LpOptions) Merge(other *LpOptions) {
+	if other.Default() != nil {
+		for _, dest := range o.Dests {
+			dest.IsDefault = false
+		}
+	}
+
+	for _, dest := range other.Dests {
+		options := make(map[string]string, len(dest.Options))
+		for name, value := range dest.Options {
+			options[name] = value
+		}
+		o.set(&LpDest{Name: dest.Name, Instance: dest.Instance, IsDefault: dest.IsDefault, Options: options})
+	}
+}
+
+// Dest returns the destination with the name and instance or nil, names are case-insensitive like in cups
+func (o *LpOptions) Dest(name, instance string) *LpDest {
+	for _, dest := range o.Dests {
+		if strings.EqualFold(dest.Name, name) && strings.EqualFold(dest.Instance, instance) {
+			return dest
+		}
+	}
+	return nil
+}
+
+// Default returns the default destination or nil
+func (o *LpOptions) Default() *LpDest {
+	for _, dest := range o.Dests {
+		if dest.IsDefault {
+			return dest
+		}
+	}
+	return nil
+}
+
+// Options returns the saved options of a destination. an instance inherits the options of its printer
+func (o *LpOptions) Options(name, instance string) map[string]string {
+	options := make(map[string]string)
+
+	for _, dest := range []*LpDest{o.Dest(name, ""), o.Dest(name, instance)} {
+		if dest == nil {
+			continue
+		}
+		for option, value := range dest.Options {
+			options[option] = value
+		}
+	}
+
+	return options
+}
+
+// JobAttributes returns the saved options of a destination as job attributes. like cupsEncodeOptions, comma separated
+// lists are split into multiple values and converted to the tag of AttributeTagMapping. the tags of options unknown by
+// AttributeTagMapping are guessed from their values, e.g. 1-3 is a range and all other values are names
+func (o *LpOptions) JobAttributes(name, instance string) (map[string]any, error) {
+	options := o.Options(name, instance)
+	attributes := make(map[string]any, len(options))
+
+	for option, value := range options {
+		v, err := lpOptionValue(option, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of option %s: %w", option, err)
+		}
+		attributes[option] = v
+	}
+
+	return attributes, nil
+}
+
+// lpOptionsMultiValued are the job attributes whose saved values are comma separated lists
+var lpOptionsMultiValued = map[string]bool{
+	AttributeFinishings: true,
+	AttributeJobSheets:  true,
+	"page-ranges":       true,
+}
+
+func lpOptionValue(option, value string) (any, error) {
+	tag, known := AttributeTagMapping[option]
+
+	// like cups, only values of multi-valued and unknown attributes are split, e.g. job-name may contain commas
+	values := []string{value}
+	if !known || lpOptionsMultiValued[option] {
+		values = strings.Split(value, ",")
+	}
+
+	attrs := make([]Attribute, 0, len(values))
+	for _, s := range values {
+		valueTag := tag
+		if !known {
+			valueTag = guessLpOptionTag(s)
+		}
+
+		v, err := parseLpOptionValue(valueTag, s)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, Attribute{Tag: valueTag, Name: option, Value: v})
+	}
+
+	// single values of known attributes are sent with the tag of AttributeTagMapping anyway
+	if known && len(attrs) == 1 {
+		switch attrs[0].Value.(type) {
+		case int, bool, string:
+			return attrs[0].Value, nil
+		}
+	}
+
+	return attrs, nil
+}
+
+// guessLpOptionTag returns the tag of a value of an option unknown by AttributeTagMapping like cupsEncodeOptions
+func guessLpOptionTag(value string) int8 {
+	switch {
+	case strings.EqualFold(value, "true") || strings.EqualFold(value, "false"):
+		return TagBoolean
+	case textRangePattern.MatchString(value):
+		return TagRange
+	case textResolutionPattern.MatchString(value):
+		return TagResolution
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return TagInteger
+	}
+	return TagName
+}
+
+// parseLpOptionValue converts a saved value to the type of the tag
+func parseLpOptionValue(tag int8, value string) (any, error) {
+	switch tag {
+	case TagBoolean:
+		switch strings.ToLower(value) {
+		case "true", "yes", "on":
+			return true, nil
+		case "false", "no", "off":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%s is not a boolean", value)
+	case TagInteger, TagEnum:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an integer", value)
+		}
+		return i, nil
+	case TagRange, TagResolution:
+		return parseTextValue(tag, value)
+	case TagBeginCollection, TagDate:
+		return nil, fmt.Errorf("values of type %s are not supported", TagTypeName(tag))
+	}
+
+	return value, nil
+}
+
+// WriteFile writes the options to a file
+func (o *LpOptions) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if err := o.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// Write writes the options in the lpoptions format, options are sorted by name
+func (o *LpOptions) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, dest := range o.Dests {
+		if dest.IsDefault {
+			bw.WriteString("Default ")
+		} else {
+			bw.WriteString("Dest ")
+		}
+
+		bw.WriteString(dest.Name)
+		if dest.Instance != "" {
+			bw.WriteByte('/')
+			bw.WriteString(dest.Instance)
+		}
+
+		for _, name := range sortedKeys(dest.Options) {
+			fmt.Fprintf(bw, " %s=%s", name, quoteLpOptionValue(dest.Options[name]))
+		}
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+func quoteLpOptionValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"'\\#") {
+		return value
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		if value[i] == '"' || value[i] == '\\' || value[i] == '#' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(value[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
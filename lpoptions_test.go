@@ -0,0 +1,173 @@
+package ipp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testLpOptions = `# saved by lpoptions
+Dest office sides=two-sided-long-edge copies=2 media=iso_a4_210x297mm
+Default office/draft print-quality=3 job-name="Draft Copy" nocollate
+Dest lab Staple=True fit-to-page output-bin='face down' note=a\"b
+`
+
+func TestParseLpOptions(t *testing.T) {
+	options, err := ParseLpOptions(strings.NewReader(testLpOptions))
+	assert.NoError(t, err)
+	assert.Len(t, options.Dests, 3)
+
+	assert.Equal(t, &LpDest{Name: "office", Instance: "draft", IsDefault: true, Options: map[string]string{
+		"print-quality": "3",
+		"job-name":      "Draft Copy",
+		"collate":       "false",
+	}}, options.Default())
+
+	assert.Equal(t, map[string]string{
+		"Staple":      "True",
+		"fit-to-page": "true",
+		"output-bin":  "face down",
+		"note":        "a\"b",
+	}, options.Dest("LAB", "").Options)
+
+	assert.Equal(t, map[string]string{
+		"sides":         "two-sided-long-edge",
+		"copies":        "2",
+		"media":         "iso_a4_210x297mm",
+		"print-quality": "3",
+		"job-name":      "Draft Copy",
+		"collate":       "false",
+	}, options.Options("office", "draft"))
+	assert.Empty(t, options.Options("unknown", ""))
+
+	_, err = ParseLpOptions(strings.NewReader("Dest office media=\"iso_a4\n"))
+	assert.Error(t, err)
+	_, err = ParseLpOptions(strings.NewReader("Dest office =a4\n"))
+	assert.Error(t, err)
+}
+
+func TestLpOptions_Write(t *testing.T) {
+	options, err := ParseLpOptions(strings.NewReader(testLpOptions))
+	assert.NoError(t, err)
+	options.Dest("lab", "").Options["comment"] = "room #2"
+
+	var buf bytes.Buffer
+	assert.NoError(t, options.Write(&buf))
+	assert.Contains(t, buf.String(), "Default office/draft collate=false job-name=\"Draft Copy\" print-quality=3\n")
+
+	written, err := ParseLpOptions(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, options, written)
+}
+
+func TestLoadLpOptions(t *testing.T) {
+	home := t.TempDir()
+	serverRoot := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CUPS_SERVERROOT", serverRoot)
+
+	options, err := LoadLpOptions()
+	assert.NoError(t, err)
+	assert.Empty(t, options.Dests)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(serverRoot, "lpoptions"), []byte("Default office sides=one-sided copies=2\n"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(home, ".cups"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(home, ".cups", "lpoptions"), []byte("Dest office sides=two-sided-long-edge\nDefault lab\n"), 0644))
+
+	options, err = LoadLpOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, "lab", options.Default().Name)
+	assert.Equal(t, map[string]string{"sides": "two-sided-long-edge", "copies": "2"}, options.Options("office", ""))
+}
+
+func TestLpOptions_JobAttributes(t *testing.T) {
+	options, err := ParseLpOptions(strings.NewReader(testLpOptions))
+	assert.NoError(t, err)
+
+	attributes, err := options.JobAttributes("office", "draft")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		AttributeSides:        "two-sided-long-edge",
+		AttributeCopies:       2,
+		AttributeMedia:        "iso_a4_210x297mm",
+		AttributePrintQuality: 3,
+		AttributeJobName:      "Draft Copy",
+		"collate":             []Attribute{{Tag: TagBoolean, Name: "collate", Value: false}},
+	}, attributes)
+
+	attributes, err = options.JobAttributes("lab", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Attribute{{Tag: TagBoolean, Name: "Staple", Value: true}}, attributes["Staple"])
+	assert.Equal(t, []Attribute{{Tag: TagName, Name: "note", Value: "a\"b"}}, attributes["note"])
+}
+
+func TestLpOptions_JobAttributesLists(t *testing.T) {
+	options, err := ParseLpOptions(strings.NewReader("Dest office finishings=4,5 job-sheets=standard,none page-ranges=1-3,7-9 job-name=a,b\n"))
+	assert.NoError(t, err)
+
+	attributes, err := options.JobAttributes("office", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Attribute{
+		{Tag: TagEnum, Name: AttributeFinishings, Value: 4},
+		{Tag: TagEnum, Name: AttributeFinishings, Value: 5},
+	}, attributes[AttributeFinishings])
+	assert.Equal(t, []Attribute{
+		{Tag: TagName, Name: AttributeJobSheets, Value: "standard"},
+		{Tag: TagName, Name: AttributeJobSheets, Value: "none"},
+	}, attributes[AttributeJobSheets])
+	assert.Equal(t, []Attribute{
+		{Tag: TagRange, Name: "page-ranges", Value: []int32{1, 3}},
+		{Tag: TagRange, Name: "page-ranges", Value: []int32{7, 9}},
+	}, attributes["page-ranges"])
+	assert.Equal(t, "a,b", attributes[AttributeJobName])
+
+	req := NewRequest(OperationPrintJob, 1)
+	for name, value := range attributes {
+		req.JobAttributes[name] = value
+	}
+	payload, err := req.Encode()
+	assert.NoError(t, err)
+	decoded, err := NewRequestDecoder(bytes.NewReader(payload)).Decode(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4, 5}, decoded.JobAttributes[AttributeFinishings])
+
+	options, err = ParseLpOptions(strings.NewReader("Dest office finishings=staple copies=two\n"))
+	assert.NoError(t, err)
+	_, err = options.JobAttributes("office", "")
+	assert.Error(t, err)
+}
+
+func TestIPPClient_PrintJobWithLpOptions(t *testing.T) {
+	adapter := NewMockAdapter()
+	adapter.AddPrinter("office", nil)
+	client := NewIPPClientWithAdapter("user", adapter)
+
+	options, err := ParseLpOptions(strings.NewReader(testLpOptions))
+	assert.NoError(t, err)
+	client.SetLpOptions(options)
+
+	jobID, err := client.PrintJob(Document{Document: bytes.NewBufferString("test"), Size: 4, Name: "test.txt", MimeType: MimeTypeOctetStream}, "office/draft", map[string]any{
+		AttributeCopies: 3,
+	})
+	assert.NoError(t, err)
+
+	req := adapter.Requests()[0]
+	assert.Equal(t, "ipp://localhost/printers/office", req.OperationAttributes[AttributePrinterURI])
+	assert.Equal(t, 3, req.JobAttributes[AttributeCopies])
+	assert.Equal(t, "two-sided-long-edge", req.JobAttributes[AttributeSides])
+	assert.Equal(t, 3, req.JobAttributes[AttributePrintQuality])
+
+	job, _ := adapter.Job(jobID)
+	assert.Equal(t, "office", job.Printer)
+
+	options, err = ParseLpOptions(strings.NewReader("Dest office finishings=staple\n"))
+	assert.NoError(t, err)
+	client.SetLpOptions(options)
+	_, err = client.PrintJob(Document{Document: bytes.NewBufferString("test"), Size: 4, Name: "test.txt", MimeType: MimeTypeOctetStream}, "office", nil)
+	assert.Error(t, err)
+	assert.Len(t, adapter.Requests(), 1)
+}
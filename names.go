@@ -0,0 +1,302 @@
+package ipp
+
+import "fmt"
+
+// operationNames maps operation ids to their names as defined in the ipp registry
+var operationNames = map[int16]string{
+	OperationPrintJob:                        "Print-Job",
+	OperationPrintUri:                        "Print-URI",
+	OperationValidateJob:                     "Validate-Job",
+	OperationCreateJob:                       "Create-Job",
+	OperationSendDocument:                    "Send-Document",
+	OperationSendUri:                         "Send-URI",
+	OperationCancelJob:                       "Cancel-Job",
+	OperationGetJobAttributes:                "Get-Job-Attributes",
+	OperationGetJobs:                         "Get-Jobs",
+	OperationGetPrinterAttributes:            "Get-Printer-Attributes",
+	OperationHoldJob:                         "Hold-Job",
+	OperationReleaseJob:                      "Release-Job",
+	OperationRestartJob:                      "Restart-Job",
+	OperationPausePrinter:                    "Pause-Printer",
+	OperationResumePrinter:                   "Resume-Printer",
+	OperationPurgeJobs:                       "Purge-Jobs",
+	OperationSetPrinterAttributes:            "Set-Printer-Attributes",
+	OperationSetJobAttributes:                "Set-Job-Attributes",
+	OperationGetPrinterSupportedValues:       "Get-Printer-Supported-Values",
+	OperationCreatePrinterSubscriptions:      "Create-Printer-Subscriptions",
+	OperationCreateJobSubscriptions:          "Create-Job-Subscriptions",
+	OperationGetSubscriptionAttributes:       "Get-Subscription-Attributes",
+	OperationGetSubscriptions:                "Get-Subscriptions",
+	OperationRenewSubscription:               "Renew-Subscription",
+	OperationCancelSubscription:              "Cancel-Subscription",
+	OperationGetNotifications:                "Get-Notifications",
+	OperationSendNotifications:               "Send-Notifications",
+	OperationGetResourceAttributes:           "Get-Resource-Attributes",
+	OperationGetResourceData:                 "Get-Resource-Data",
+	OperationGetResources:                    "Get-Resources",
+	OperationGetPrintSupportFiles:            "Get-Print-Support-Files",
+	OperationEnablePrinter:                   "Enable-Printer",
+	OperationDisablePrinter:                  "Disable-Printer",
+	OperationPausePrinterAfterCurrentJob:     "Pause-Printer-After-Current-Job",
+	OperationHoldNewJobs:                     "Hold-New-Jobs",
+	OperationReleaseHeldNewJobs:              "Release-Held-New-Jobs",
+	OperationDeactivatePrinter:               "Deactivate-Printer",
+	OperationActivatePrinter:                 "Activate-Printer",
+	OperationRestartPrinter:                  "Restart-Printer",
+	OperationShutdownPrinter:                 "Shutdown-Printer",
+	OperationStartupPrinter:                  "Startup-Printer",
+	OperationReprocessJob:                    "Reprocess-Job",
+	OperationCancelCurrentJob:                "Cancel-Current-Job",
+	OperationSuspendCurrentJob:               "Suspend-Current-Job",
+	OperationResumeJob:                       "Resume-Job",
+	OperationOperationPromoteJob:             "Promote-Job",
+	OperationScheduleJobAfter:                "Schedule-Job-After",
+	OperationCancelDocument:                  "Cancel-Document",
+	OperationGetDocumentAttributes:           "Get-Document-Attributes",
+	OperationGetDocuments:                    "Get-Documents",
+	OperationDeleteDocument:                  "Delete-Document",
+	OperationSetDocumentAttributes:           "Set-Document-Attributes",
+	OperationCancelJobs:                      "Cancel-Jobs",
+	OperationCancelMyJobs:                    "Cancel-My-Jobs",
+	OperationResubmitJob:                     "Resubmit-Job",
+	OperationCloseJob:                        "Close-Job",
+	OperationIdentifyPrinter:                 "Identify-Printer",
+	OperationValidateDocument:                "Validate-Document",
+	OperationAddDocumentImages:               "Add-Document-Images",
+	OperationAcknowledgeDocument:             "Acknowledge-Document",
+	OperationAcknowledgeIdentifyPrinter:      "Acknowledge-Identify-Printer",
+	OperationAcknowledgeJob:                  "Acknowledge-Job",
+	OperationFetchDocument:                   "Fetch-Document",
+	OperationFetchJob:                        "Fetch-Job",
+	OperationGetOutputDeviceAttributes:       "Get-Output-Device-Attributes",
+	OperationUpdateActiveJobs:                "Update-Active-Jobs",
+	OperationDeregisterOutputDevice:          "Deregister-Output-Device",
+	OperationUpdateDocumentStatus:            "Update-Document-Status",
+	OperationUpdateJobStatus:                 "Update-Job-Status",
+	OperationUpdateOutputDeviceAttributes:    "Update-Output-Device-Attributes",
+	OperationGetNextDocumentData:             "Get-Next-Document-Data",
+	OperationAllocatePrinterResources:        "Allocate-Printer-Resources",
+	OperationCreatePrinter:                   "Create-Printer",
+	OperationDeallocatePrinterResources:      "Deallocate-Printer-Resources",
+	OperationDeletePrinter:                   "Delete-Printer",
+	OperationGetPrinters:                     "Get-Printers",
+	OperationShutdownOnePrinter:              "Shutdown-One-Printer",
+	OperationStartupOnePrinter:               "Startup-One-Printer",
+	OperationCancelResource:                  "Cancel-Resource",
+	OperationCreateResource:                  "Create-Resource",
+	OperationInstallResource:                 "Install-Resource",
+	OperationSendResourceData:                "Send-Resource-Data",
+	OperationSetResourceAttributes:           "Set-Resource-Attributes",
+	OperationCreateResourceSubscriptions:     "Create-Resource-Subscriptions",
+	OperationCreateSystemSubscriptions:       "Create-System-Subscriptions",
+	OperationDisableAllPrinters:              "Disable-All-Printers",
+	OperationEnableAllPrinters:               "Enable-All-Printers",
+	OperationGetSystemAttributes:             "Get-System-Attributes",
+	OperationGetSystemSupportedValues:        "Get-System-Supported-Values",
+	OperationPauseAllPrinters:                "Pause-All-Printers",
+	OperationPauseAllPrintersAfterCurrentJob: "Pause-All-Printers-After-Current-Job",
+	OperationRegisterOutputDevice:            "Register-Output-Device",
+	OperationRestartSystem:                   "Restart-System",
+	OperationResumeAllPrinters:               "Resume-All-Printers",
+	OperationSetSystemAttributes:             "Set-System-Attributes",
+	OperationShutdownAllPrinter:              "Shutdown-All-Printers",
+	OperationStartupAllPrinters:              "Startup-All-Printers",
+	OperationCupsGetDefault:                  "CUPS-Get-Default",
+	OperationCupsGetPrinters:                 "CUPS-Get-Printers",
+	OperationCupsAddModifyPrinter:            "CUPS-Add-Modify-Printer",
+	OperationCupsDeletePrinter:               "CUPS-Delete-Printer",
+	OperationCupsGetClasses:                  "CUPS-Get-Classes",
+	OperationCupsAddModifyClass:              "CUPS-Add-Modify-Class",
+	OperationCupsDeleteClass:                 "CUPS-Delete-Class",
+	OperationCupsAcceptJobs:                  "CUPS-Accept-Jobs",
+	OperationCupsRejectJobs:                  "CUPS-Reject-Jobs",
+	OperationCupsSetDefault:                  "CUPS-Set-Default",
+	OperationCupsGetDevices:                  "CUPS-Get-Devices",
+	OperationCupsGetPPDs:                     "CUPS-Get-PPDs",
+	OperationCupsMoveJob:                     "CUPS-Move-Job",
+	OperationCupsAuthenticateJob:             "CUPS-Authenticate-Job",
+	OperationCupsGetPpd:                      "CUPS-Get-PPD",
+	OperationCupsGetDocument:                 "CUPS-Get-Document",
+	OperationCupsCreateLocalPrinter:          "CUPS-Create-Local-Printer",
+}
+
+// OperationName returns the name of an operation (e.g. Print-Job), unknown operations are returned in hex notation
+func OperationName(op int16) string {
+	if name, ok := operationNames[op]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%04x", op)
+}
+
+// OperationByName returns the operation id for an operation name
+func OperationByName(name string) (int16, bool) {
+	for op, opName := range operationNames {
+		if opName == name {
+			return op, true
+		}
+	}
+
+	return 0, false
+}
+
+// statusNames maps status codes to their names as defined in the ipp registry
+var statusNames = map[int16]string{
+	StatusOk:                              "successful-ok",
+	StatusOkIgnoredOrSubstituted:          "successful-ok-ignored-or-substituted-attributes",
+	StatusOkConflicting:                   "successful-ok-conflicting-attributes",
+	StatusOkIgnoredSubscriptions:          "successful-ok-ignored-subscriptions",
+	StatusOkIgnoredNotifications:          "successful-ok-ignored-notifications",
+	StatusOkTooManyEvents:                 "successful-ok-too-many-events",
+	StatusOkButCancelSubscription:         "successful-ok-but-cancel-subscription",
+	StatusOkEventsComplete:                "successful-ok-events-complete",
+	StatusRedirectionOtherSite:            "redirection-other-site",
+	StatusCupsSeeOther:                    "cups-see-other",
+	StatusErrorBadRequest:                 "client-error-bad-request",
+	StatusErrorForbidden:                  "client-error-forbidden",
+	StatusErrorNotAuthenticated:           "client-error-not-authenticated",
+	StatusErrorNotAuthorized:              "client-error-not-authorized",
+	StatusErrorNotPossible:                "client-error-not-possible",
+	StatusErrorTimeout:                    "client-error-timeout",
+	StatusErrorNotFound:                   "client-error-not-found",
+	StatusErrorGone:                       "client-error-gone",
+	StatusErrorRequestEntity:              "client-error-request-entity-too-large",
+	StatusErrorRequestValue:               "client-error-request-value-too-long",
+	StatusErrorDocumentFormatNotSupported: "client-error-document-format-not-supported",
+	StatusErrorAttributesOrValues:         "client-error-attributes-or-values-not-supported",
+	StatusErrorUriScheme:                  "client-error-uri-scheme-not-supported",
+	StatusErrorCharset:                    "client-error-charset-not-supported",
+	StatusErrorConflicting:                "client-error-conflicting-attributes",
+	// the status codes between 0x040f and 0x0420 are given as values, as the names of their constants are shifted by
+	// one against the ipp registry
+	0x040f:                             "client-error-compression-not-supported",
+	0x0410:                             "client-error-compression-error",
+	0x0411:                             "client-error-document-format-error",
+	0x0412:                             "client-error-document-access-error",
+	0x0413:                             "client-error-attributes-not-settable",
+	0x0414:                             "client-error-ignored-all-subscriptions",
+	0x0415:                             "client-error-too-many-subscriptions",
+	0x0416:                             "client-error-ignored-all-notifications",
+	0x0417:                             "client-error-print-support-file-not-found",
+	0x0418:                             "client-error-document-password-error",
+	0x0419:                             "client-error-document-permission-error",
+	0x041a:                             "client-error-document-security-error",
+	0x041b:                             "client-error-document-unprintable-error",
+	0x041c:                             "client-error-account-info-needed",
+	0x041d:                             "client-error-account-closed",
+	0x041e:                             "client-error-account-limit-reached",
+	0x041f:                             "client-error-account-authorization-failed",
+	0x0420:                             "client-error-not-fetchable",
+	StatusErrorCupsAccountInfoNeeded:   "cups-error-account-info-needed",
+	StatusErrorCupsAccountClosed:       "cups-error-account-closed",
+	StatusErrorCupsAccountLimitReached: "cups-error-account-limit-reached",
+	StatusErrorCupsAccountAuthorizationFailed: "cups-error-account-authorization-failed",
+	StatusErrorInternal:                       "server-error-internal-error",
+	StatusErrorOperationNotSupported:          "server-error-operation-not-supported",
+	StatusErrorServiceUnavailable:             "server-error-service-unavailable",
+	StatusErrorVersionNotSupported:            "server-error-version-not-supported",
+	StatusErrorDevice:                         "server-error-device-error",
+	StatusErrorTemporary:                      "server-error-temporary-error",
+	StatusErrorNotAcceptingJobs:               "server-error-not-accepting-jobs",
+	StatusErrorBusy:                           "server-error-busy",
+	StatusErrorJobCanceled:                    "server-error-job-canceled",
+	StatusErrorMultipleJobsNotSupported:       "server-error-multiple-document-jobs-not-supported",
+	StatusErrorPrinterIsDeactivated:           "server-error-printer-is-deactivated",
+	StatusErrorTooManyJobs:                    "server-error-too-many-jobs",
+	StatusErrorTooManyDocuments:               "server-error-too-many-documents",
+	StatusErrorCupsAuthenticationCanceled:     "cups-authentication-canceled",
+	StatusErrorCupsPki:                        "cups-pki-error",
+	StatusErrorCupsUpgradeRequired:            "cups-upgrade-required",
+}
+
+// StatusName returns the name of a status code, unknown status codes are returned as hex value
+func StatusName(status int16) string {
+	if name, ok := statusNames[status]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%04x", status)
+}
+
+// StatusByName returns the status code for a status name
+func StatusByName(name string) (int16, bool) {
+	for status, statusName := range statusNames {
+		if statusName == name {
+			return status, true
+		}
+	}
+
+	return 0, false
+}
+
+// tagNames maps delimiter and value tags to their names as used by ipptool
+var tagNames = map[int8]string{
+	TagDelimiterOperation:         "operation-attributes-tag",
+	TagDelimiterJob:               "job-attributes-tag",
+	TagDelimiterEnd:               "end-of-attributes-tag",
+	TagDelimiterPrinter:           "printer-attributes-tag",
+	TagDelimiterUnsupported:       "unsupported-attributes-tag",
+	TagDelimiterSubscription:      "subscription-attributes-tag",
+	TagDelimiterEventNotification: "event-notification-attributes-tag",
+	TagDelimiterResource:          "resource-attributes-tag",
+	TagDelimiterDocument:          "document-attributes-tag",
+	TagDelimiterSystem:            "system-attributes-tag",
+	TagUnsupported:                "unsupported",
+	TagDefault:                    "default",
+	TagUnknown:                    "unknown",
+	TagNoValue:                    "no-value",
+	TagNotSettable:                "not-settable",
+	TagDeleteAttr:                 "delete-attribute",
+	TagAdminDefine:                "admin-define",
+	TagInteger:                    "integer",
+	TagBoolean:                    "boolean",
+	TagEnum:                       "enum",
+	TagString:                     "octetString",
+	TagDate:                       "dateTime",
+	TagResolution:                 "resolution",
+	TagRange:                      "rangeOfInteger",
+	TagBeginCollection:            "collection",
+	TagTextLang:                   "textWithLanguage",
+	TagNameLang:                   "nameWithLanguage",
+	TagEndCollection:              "endCollection",
+	TagText:                       "textWithoutLanguage",
+	TagName:                       "nameWithoutLanguage",
+	TagKeyword:                    "keyword",
+	TagUri:                        "uri",
+	TagUriScheme:                  "uriScheme",
+	TagCharset:                    "charset",
+	TagLanguage:                   "naturalLanguage",
+	TagMimeType:                   "mimeMediaType",
+	TagMemberName:                 "memberAttrName",
+	TagExtension:                  "extension",
+}
+
+// tagAliases are additional names accepted for tags
+var tagAliases = map[string]int8{
+	"text": TagText,
+	"name": TagName,
+}
+
+// TagTypeName returns the name of a tag, unknown tags are returned as hex value
+func TagTypeName(tag int8) string {
+	if name, ok := tagNames[tag]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%02x", uint8(tag))
+}
+
+// TagByName returns the tag for a tag name
+func TagByName(name string) (int8, bool) {
+	if tag, ok := tagAliases[name]; ok {
+		return tag, true
+	}
+
+	for tag, tagName := range tagNames {
+		if tagName == name {
+			return tag, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,140 @@
+// Package otelipp records traces and metrics of ipp operations with OpenTelemetry.
+// it implements the ipp.Instrumentation interface and is kept in a separate module, so the ipp module itself
+// does not depend on OpenTelemetry
+package otelipp
+
+import (
+	"context"
+
+	"github.com/phin1x/go-ipp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/phin1x/go-ipp/otelipp"
+
+// attribute keys of spans and metrics
+const (
+	KeyOperation     = attribute.Key("ipp.operation")
+	KeyPrinterURI    = attribute.Key("ipp.printer_uri")
+	KeyStatusCode    = attribute.Key("ipp.status_code")
+	KeyRequestID     = attribute.Key("ipp.request_id")
+	KeyBytesSent     = attribute.Key("ipp.bytes_sent")
+	KeyBytesReceived = attribute.Key("ipp.bytes_received")
+	KeyURL           = attribute.Key("url.full")
+)
+
+// Instrumentation records a span per ipp operation and latency and error metrics
+type Instrumentation struct {
+	tracer     trace.Tracer
+	duration   metric.Float64Histogram
+	operations metric.Int64Counter
+	errors     metric.Int64Counter
+}
+
+// Option configures an Instrumentation
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider sets the tracer provider, the global provider is used by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider, the global provider is used by default
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// New creates a new OpenTelemetry instrumentation
+func New(opts ...Option) (*Instrumentation, error) {
+	cfg := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	meter := cfg.meterProvider.Meter(instrumentationName)
+
+	duration, err := meter.Float64Histogram("ipp.client.operation.duration",
+		metric.WithDescription("Duration of ipp operations"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	operations, err := meter.Int64Counter("ipp.client.operations",
+		metric.WithDescription("Number of ipp operations"))
+	if err != nil {
+		return nil, err
+	}
+
+	errs, err := meter.Int64Counter("ipp.client.errors",
+		metric.WithDescription("Number of failed ipp operations"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instrumentation{
+		tracer:     cfg.tracerProvider.Tracer(instrumentationName),
+		duration:   duration,
+		operations: operations,
+		errors:     errs,
+	}, nil
+}
+
+// Middleware returns an ipp middleware which reports to this instrumentation
+func (i *Instrumentation) Middleware() ipp.Middleware {
+	return ipp.InstrumentationMiddleware(i)
+}
+
+// StartOperation implements ipp.Instrumentation
+func (i *Instrumentation) StartOperation(ctx context.Context, info ipp.OperationInfo) (context.Context, func(ipp.OperationResult)) {
+	ctx, span := i.tracer.Start(ctx, "IPP "+info.OperationName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			KeyOperation.String(info.OperationName),
+			KeyPrinterURI.String(info.PrinterURI),
+			KeyRequestID.Int(int(info.RequestId)),
+			KeyURL.String(info.URL),
+		))
+
+	return ctx, func(result ipp.OperationResult) {
+		span.SetAttributes(
+			KeyStatusCode.Int(int(result.StatusCode)),
+			KeyBytesSent.Int64(result.BytesSent),
+			KeyBytesReceived.Int64(result.BytesReceived),
+		)
+
+		if result.Err != nil {
+			span.RecordError(result.Err)
+			span.SetStatus(codes.Error, result.Err.Error())
+		}
+		span.End()
+
+		attrs := metric.WithAttributes(
+			KeyOperation.String(info.OperationName),
+			KeyStatusCode.Int(int(result.StatusCode)),
+		)
+
+		i.duration.Record(ctx, result.Duration.Seconds(), attrs)
+		i.operations.Add(ctx, 1, attrs)
+		if result.Err != nil {
+			i.errors.Add(ctx, 1, attrs)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package otelipp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/phin1x/go-ipp"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrumentation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	instrumentation, err := New(WithTracerProvider(tracerProvider), WithMeterProvider(meterProvider))
+	assert.NoError(t, err)
+
+	adapter := ipp.NewMockAdapter()
+	adapter.AddPrinter("printer", nil)
+	client := ipp.NewIPPClientWithAdapter("user", ipp.NewMiddlewareAdapter(adapter, instrumentation.Middleware()))
+
+	_, err = client.PrintJob(ipp.Document{
+		Document: bytes.NewBufferString("test"),
+		Size:     4,
+		Name:     "test.txt",
+		MimeType: ipp.MimeTypeOctetStream,
+	}, "printer", map[string]any{})
+	assert.NoError(t, err)
+
+	err = client.CancelJob(42, false)
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "IPP Print-Job", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, KeyPrinterURI.String("ipp://localhost/printers/printer"))
+	assert.Contains(t, spans[0].Attributes, KeyStatusCode.Int(int(ipp.StatusOk)))
+	assert.Equal(t, "IPP Cancel-Job", spans[1].Name)
+	assert.Contains(t, spans[1].Attributes, KeyStatusCode.Int(int(ipp.StatusErrorNotFound)))
+	assert.Len(t, spans[1].Events, 1)
+
+	var metrics metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &metrics))
+	assert.Len(t, metrics.ScopeMetrics, 1)
+
+	counts := make(map[string]int64)
+	for _, m := range metrics.ScopeMetrics[0].Metrics {
+		if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+			for _, point := range sum.DataPoints {
+				operation, _ := point.Attributes.Value(KeyOperation)
+				counts[m.Name+" "+operation.AsString()] += point.Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{
+		"ipp.client.operations Print-Job":  1,
+		"ipp.client.operations Cancel-Job": 1,
+		"ipp.client.errors Cancel-Job":     1,
+	}, counts)
+
+}
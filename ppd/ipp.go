@@ -0,0 +1,162 @@
+package ppd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/phin1x/go-ipp"
+)
+
+// pwgMediaNames maps the common ppd page size names to pwg media names
+var pwgMediaNames = map[string]string{
+	"Letter":     "na_letter_8.5x11in",
+	"Legal":      "na_legal_8.5x14in",
+	"Executive":  "na_executive_7.25x10.5in",
+	"Tabloid":    "na_ledger_11x17in",
+	"Statement":  "na_invoice_5.5x8.5in",
+	"A3":         "iso_a3_297x420mm",
+	"A4":         "iso_a4_210x297mm",
+	"A5":         "iso_a5_148x210mm",
+	"A6":         "iso_a6_105x148mm",
+	"B5":         "jis_b5_182x257mm",
+	"Env10":      "na_number-10_4.125x9.5in",
+	"EnvDL":      "iso_dl_110x220mm",
+	"EnvC5":      "iso_c5_162x229mm",
+	"EnvMonarch": "na_monarch_3.875x7.5in",
+	"4x6":        "na_index-4x6_4x6in",
+	"w288h432":   "na_index-4x6_4x6in",
+}
+
+// printQualities maps the choices of cupsPrintQuality to print-quality
+var printQualities = map[string]int{
+	"Draft":  3,
+	"Normal": 4,
+	"High":   5,
+}
+
+var resolutionPattern = regexp.MustCompile(`^(\d+)(?:x(\d+))?(dpi|dpcm)$`)
+
+// JobAttributes converts ppd options into ipp job attributes. Duplex, PageSize, InputSlot, MediaType, ColorModel,
+// Resolution, OutputBin and cupsPrintQuality are mapped to their ipp counterparts, all other options are passed as
+// names with their ppd keyword as attribute name, cups applies them as ppd options
+func (p *PPD) JobAttributes(options map[string]string) (map[string]any, error) {
+	attributes := make(map[string]any, len(options))
+
+	for keyword, choice := range options {
+		if option := p.Option(keyword); option != nil && option.Choice(choice) == nil && !strings.HasPrefix(choice, "Custom.") {
+			return nil, fmt.Errorf("invalid choice %s of option %s", choice, keyword)
+		}
+
+		switch keyword {
+		case "Duplex":
+			switch choice {
+			case "None":
+				attributes[ipp.AttributeSides] = "one-sided"
+			case "DuplexNoTumble":
+				attributes[ipp.AttributeSides] = "two-sided-long-edge"
+			case "DuplexTumble":
+				attributes[ipp.AttributeSides] = "two-sided-short-edge"
+			default:
+				return nil, fmt.Errorf("unknown duplex choice %s", choice)
+			}
+		case "PageSize", "PageRegion":
+			attributes[ipp.AttributeMedia] = p.PWGMediaName(choice)
+		case "InputSlot":
+			attributes[ipp.AttributeMediaSource] = ippKeyword(choice)
+		case "MediaType":
+			if choice == "Plain" {
+				attributes[ipp.AttributeMediaType] = "stationery"
+			} else {
+				attributes[ipp.AttributeMediaType] = ippKeyword(choice)
+			}
+		case "OutputBin":
+			attributes[ipp.AttributeOutputBin] = ippKeyword(choice)
+		case "ColorModel":
+			switch strings.ToLower(choice) {
+			case "gray", "kgray", "grayscale", "black", "mono", "monochrome":
+				attributes[ipp.AttributePrintColorMode] = "monochrome"
+			default:
+				attributes[ipp.AttributePrintColorMode] = "color"
+			}
+		case "Resolution":
+			resolution, err := parseResolution(choice)
+			if err != nil {
+				return nil, err
+			}
+			attributes[ipp.AttributePrinterResolution] = []ipp.Attribute{{Tag: ipp.TagResolution, Name: ipp.AttributePrinterResolution, Value: resolution}}
+		case "cupsPrintQuality":
+			quality, ok := printQualities[choice]
+			if !ok {
+				return nil, fmt.Errorf("unknown print quality %s", choice)
+			}
+			attributes[ipp.AttributePrintQuality] = quality
+		default:
+			// the options are unknown to the attribute tag mapping, so they are sent with an explicit tag like cups does
+			attributes[keyword] = []ipp.Attribute{{Tag: ipp.TagName, Name: keyword, Value: choice}}
+		}
+	}
+
+	return attributes, nil
+}
+
+// PWGMediaName returns the pwg media name of a page size, e.g. iso_a4_210x297mm for A4. sizes without a known name
+// get a custom name with their dimensions
+func (p *PPD) PWGMediaName(pageSize string) string {
+	if name, ok := pwgMediaNames[pageSize]; ok {
+		return name
+	}
+
+	size := p.PageSize(pageSize)
+	if size == nil {
+		return pageSize
+	}
+
+	return fmt.Sprintf("custom_%s_%sx%smm", ippKeyword(pageSize), formatMillimeters(size.Width), formatMillimeters(size.Length))
+}
+
+// formatMillimeters converts points to millimeters with at most two decimals
+func formatMillimeters(points float64) string {
+	return strconv.FormatFloat(float64(int(points*25.4/72*100+0.5))/100, 'f', -1, 64)
+}
+
+// ippKeyword converts a ppd choice like Tray1 or LargeCapacity into an ipp keyword like tray-1 or large-capacity
+func ippKeyword(choice string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range choice {
+		switch {
+		case r == '_' || r == ' ' || r == '.':
+			r = '-'
+		case i > 0 && unicode.IsUpper(r) && unicode.IsLower(prev):
+			b.WriteByte('-')
+		case i > 0 && unicode.IsDigit(r) && unicode.IsLetter(prev):
+			b.WriteByte('-')
+		}
+		b.WriteRune(unicode.ToLower(r))
+		prev = r
+	}
+	return b.String()
+}
+
+func parseResolution(choice string) (ipp.Resolution, error) {
+	m := resolutionPattern.FindStringSubmatch(choice)
+	if m == nil {
+		return ipp.Resolution{}, fmt.Errorf("invalid resolution %s", choice)
+	}
+
+	x, _ := strconv.Atoi(m[1])
+	y := x
+	if m[2] != "" {
+		y, _ = strconv.Atoi(m[2])
+	}
+
+	units := int8(3)
+	if m[3] == "dpcm" {
+		units = 4
+	}
+
+	return ipp.Resolution{Height: int32(x), Width: int32(y), Depth: units}, nil
+}
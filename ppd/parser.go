@@ -0,0 +1,463 @@
+package ppd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxLineLength limits the length of a single line, invocation code of some drivers is quite long
+const maxLineLength = 1024 * 1024
+
+// entry is a single main keyword statement: *Keyword Option/Text: Value
+type entry struct {
+	keyword string
+	option  string
+	text    string
+	value   string
+	line    int
+}
+
+type parser struct {
+	ppd     *PPD
+	scanner *bufio.Scanner
+	line    int
+
+	group    *Group
+	subGroup *Group
+	option   *Option
+	options  map[string]*Option
+
+	defaults   map[string]string
+	orders     map[string]entry
+	dimensions map[string][2]float64
+	areas      map[string][4]float64
+}
+
+// ParseFile parses the ppd file at path, gzip compressed files are decompressed
+func ParseFile(path string) (*PPD, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return p, nil
+}
+
+// Parse parses a ppd file, gzip compressed files are decompressed
+func Parse(r io.Reader) (*PPD, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+
+	p := &parser{
+		ppd:        &PPD{},
+		scanner:    scanner,
+		options:    make(map[string]*Option),
+		defaults:   make(map[string]string),
+		orders:     make(map[string]entry),
+		dimensions: make(map[string][2]float64),
+		areas:      make(map[string][4]float64),
+	}
+
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	return p.ppd, nil
+}
+
+func (p *parser) parse() error {
+	first := true
+	for {
+		e, ok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		if first {
+			if e.keyword != "PPD-Adobe" {
+				return errors.New("not a ppd file, missing *PPD-Adobe")
+			}
+			first = false
+		}
+
+		if err := p.handle(e); err != nil {
+			return fmt.Errorf("line %d: %w", e.line, err)
+		}
+	}
+
+	if first {
+		return errors.New("not a ppd file, missing *PPD-Adobe")
+	}
+
+	return p.finish()
+}
+
+// next returns the next entry, comments and lines without a value like *End are skipped
+func (p *parser) next() (entry, bool, error) {
+	for p.scanner.Scan() {
+		p.line++
+		line := strings.TrimRight(p.scanner.Text(), "\r")
+
+		if !strings.HasPrefix(line, "*") || strings.HasPrefix(line, "*%") {
+			continue
+		}
+
+		head, value, found := strings.Cut(line[1:], ":")
+		if !found {
+			continue
+		}
+
+		e := entry{line: p.line}
+		e.keyword, e.option, _ = strings.Cut(strings.TrimSpace(head), " ")
+		e.option, e.text, _ = strings.Cut(strings.TrimSpace(e.option), "/")
+		e.text = decodeHex(e.text)
+
+		value = strings.TrimLeft(value, " \t")
+		if strings.HasPrefix(value, "\"") {
+			quoted, err := p.readQuoted(value[1:])
+			if err != nil {
+				return entry{}, false, fmt.Errorf("line %d: %w", e.line, err)
+			}
+			e.value = quoted
+		} else {
+			e.value = strings.TrimSpace(value)
+		}
+
+		return e, true, nil
+	}
+
+	return entry{}, false, p.scanner.Err()
+}
+
+// readQuoted reads a quoted value which may span multiple lines, s is the rest of the line after the opening quote
+func (p *parser) readQuoted(s string) (string, error) {
+	var value strings.Builder
+	for {
+		if end := strings.IndexByte(s, '"'); end >= 0 {
+			value.WriteString(s[:end])
+			return value.String(), nil
+		}
+
+		value.WriteString(s)
+		if !p.scanner.Scan() {
+			if err := p.scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", errors.New("unterminated quoted value")
+		}
+		p.line++
+		value.WriteByte('\n')
+		s = strings.TrimRight(p.scanner.Text(), "\r")
+	}
+}
+
+func (p *parser) handle(e entry) error {
+	switch e.keyword {
+	case "OpenGroup":
+		name, text, _ := strings.Cut(e.value, "/")
+		p.group = &Group{Name: name, Text: textOrName(decodeHex(text), name)}
+		p.subGroup = nil
+		p.ppd.Groups = append(p.ppd.Groups, p.group)
+	case "CloseGroup":
+		p.group, p.subGroup = nil, nil
+	case "OpenSubGroup":
+		if p.group == nil {
+			return errors.New("*OpenSubGroup outside of a group")
+		}
+		name, text, _ := strings.Cut(e.value, "/")
+		p.subGroup = &Group{Name: name, Text: textOrName(decodeHex(text), name)}
+		p.group.SubGroups = append(p.group.SubGroups, p.subGroup)
+	case "CloseSubGroup":
+		p.subGroup = nil
+	case "OpenUI", "JCLOpenUI":
+		return p.openUI(e)
+	case "CloseUI", "JCLCloseUI":
+		p.option = nil
+	case "OrderDependency", "NonUIOrderDependency":
+		fields := strings.Fields(e.value)
+		if len(fields) < 3 {
+			return fmt.Errorf("invalid *%s %s", e.keyword, e.value)
+		}
+		p.orders[strings.TrimPrefix(fields[2], "*")] = e
+	case "UIConstraints", "NonUIConstraints":
+		p.ppd.Constraints = append(p.ppd.Constraints, Constraint{Options: parseConstraintOptions(e.value)})
+	case "cupsUIConstraints":
+		p.ppd.Constraints = append(p.ppd.Constraints, Constraint{Name: e.option, Options: parseConstraintOptions(e.value)})
+	case "PaperDimension":
+		values, err := parseFloats(e.value, 2)
+		if err != nil {
+			return fmt.Errorf("invalid *PaperDimension %s: %w", e.option, err)
+		}
+		p.dimensions[e.option] = [2]float64{values[0], values[1]}
+	case "ImageableArea":
+		values, err := parseFloats(e.value, 4)
+		if err != nil {
+			return fmt.Errorf("invalid *ImageableArea %s: %w", e.option, err)
+		}
+		p.areas[e.option] = [4]float64{values[0], values[1], values[2], values[3]}
+	case "cupsFilter", "cupsFilter2":
+		filter, err := parseFilter(e.keyword, e.value)
+		if err != nil {
+			return err
+		}
+		p.ppd.Filters = append(p.ppd.Filters, filter)
+	default:
+		if option, ok := p.options[e.keyword]; ok && e.option != "" {
+			if option.Choice(e.option) == nil {
+				option.Choices = append(option.Choices, &Choice{Name: e.option, Text: textOrName(e.text, e.option), Code: e.value})
+			}
+			return nil
+		}
+
+		if strings.HasPrefix(e.keyword, "Default") && len(e.keyword) > len("Default") {
+			p.defaults[strings.TrimPrefix(e.keyword, "Default")] = e.value
+		}
+		p.setHeader(e)
+	}
+
+	p.ppd.Attributes = append(p.ppd.Attributes, Attribute{Keyword: e.keyword, Option: e.option, Text: e.text, Value: e.value})
+	return nil
+}
+
+func (p *parser) openUI(e entry) error {
+	keyword := strings.TrimPrefix(e.option, "*")
+	if keyword == "" {
+		return fmt.Errorf("*%s without option keyword", e.keyword)
+	}
+
+	ui := e.value
+	switch ui {
+	case UIPickOne, UIPickMany, UIBoolean:
+	default:
+		return fmt.Errorf("invalid ui type %s of option %s", ui, keyword)
+	}
+
+	if _, ok := p.options[keyword]; ok {
+		return fmt.Errorf("duplicate option %s", keyword)
+	}
+
+	option := &Option{Keyword: keyword, Text: textOrName(e.text, keyword), UI: ui}
+	p.options[keyword] = option
+	p.option = option
+
+	group := p.subGroup
+	if group == nil {
+		group = p.group
+	}
+	if group == nil {
+		group = p.generalGroup()
+	}
+	group.Options = append(group.Options, option)
+
+	return nil
+}
+
+// generalGroup returns the group of options outside of *OpenGroup, like cups it is named General
+func (p *parser) generalGroup() *Group {
+	for _, group := range p.ppd.Groups {
+		if group.Name == "General" {
+			return group
+		}
+	}
+
+	group := &Group{Name: "General", Text: "General"}
+	p.ppd.Groups = append(p.ppd.Groups, group)
+	return group
+}
+
+func (p *parser) setHeader(e entry) {
+	switch e.keyword {
+	case "FormatVersion":
+		p.ppd.FormatVersion = e.value
+	case "LanguageVersion":
+		p.ppd.LanguageVersion = e.value
+	case "Manufacturer":
+		p.ppd.Manufacturer = e.value
+	case "ModelName":
+		p.ppd.ModelName = e.value
+	case "NickName":
+		p.ppd.NickName = e.value
+	case "ShortNickName":
+		p.ppd.ShortNickName = e.value
+	case "Product":
+		p.ppd.Product = e.value
+	case "PCFileName":
+		p.ppd.PCFileName = e.value
+	case "ColorDevice":
+		p.ppd.ColorDevice = strings.EqualFold(e.value, "True")
+	}
+}
+
+// finish applies the defaults and order dependencies and collects the page sizes
+func (p *parser) finish() error {
+	for keyword, option := range p.options {
+		if def, ok := p.defaults[keyword]; ok {
+			option.Default = def
+		}
+
+		if e, ok := p.orders[keyword]; ok {
+			fields := strings.Fields(e.value)
+			order, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return fmt.Errorf("line %d: invalid order %s of option %s", e.line, fields[0], keyword)
+			}
+			option.Order = order
+			option.Section = fields[1]
+		}
+	}
+
+	names := make([]string, 0)
+	texts := make(map[string]string)
+	if option, ok := p.options["PageSize"]; ok {
+		for _, choice := range option.Choices {
+			names = append(names, choice.Name)
+			texts[choice.Name] = choice.Text
+		}
+	} else {
+		for _, attr := range p.ppd.Attributes {
+			if attr.Keyword == "PaperDimension" {
+				names = append(names, attr.Option)
+				texts[attr.Option] = textOrName(attr.Text, attr.Option)
+			}
+		}
+	}
+
+	for _, name := range names {
+		dimension, ok := p.dimensions[name]
+		if !ok {
+			continue
+		}
+
+		size := PageSize{Name: name, Text: texts[name], Width: dimension[0], Length: dimension[1]}
+		if area, ok := p.areas[name]; ok {
+			size.Left, size.Bottom, size.Right, size.Top = area[0], area[1], area[2], area[3]
+		} else {
+			size.Right, size.Top = dimension[0], dimension[1]
+		}
+		p.ppd.PageSizes = append(p.ppd.PageSizes, size)
+	}
+
+	return nil
+}
+
+// parseConstraintOptions parses the options of a constraint like *Duplex *MediaType Transparency
+func parseConstraintOptions(value string) []ConstraintOption {
+	options := make([]ConstraintOption, 0, 2)
+	for _, field := range strings.Fields(value) {
+		if strings.HasPrefix(field, "*") {
+			options = append(options, ConstraintOption{Option: field[1:]})
+		} else if len(options) > 0 {
+			options[len(options)-1].Choice = field
+		}
+	}
+	return options
+}
+
+// parseFilter parses a *cupsFilter (source cost program) or *cupsFilter2 (source dest cost program) value
+func parseFilter(keyword, value string) (Filter, error) {
+	n := 3
+	if keyword == "cupsFilter2" {
+		n = 4
+	}
+
+	fields := strings.SplitN(strings.TrimSpace(value), " ", n)
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	if len(fields) != n {
+		return Filter{}, fmt.Errorf("invalid *%s %s", keyword, value)
+	}
+
+	filter := Filter{SourceType: fields[0], Program: fields[n-1]}
+	if n == 4 {
+		filter.DestType = fields[1]
+	}
+
+	cost, err := strconv.Atoi(fields[n-2])
+	if err != nil {
+		return Filter{}, fmt.Errorf("invalid cost of *%s %s", keyword, value)
+	}
+	filter.Cost = cost
+
+	return filter, nil
+}
+
+func parseFloats(value string, n int) ([]float64, error) {
+	fields := strings.Fields(value)
+	if len(fields) != n {
+		return nil, fmt.Errorf("expected %d numbers, got %q", n, value)
+	}
+
+	values := make([]float64, 0, n)
+	for _, field := range fields {
+		f, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, f)
+	}
+
+	return values, nil
+}
+
+// decodeHex replaces hex substrings like <E4> of translation strings with their bytes
+func decodeHex(s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+
+	var buf bytes.Buffer
+	for {
+		start := strings.IndexByte(s, '<')
+		end := strings.IndexByte(s, '>')
+		if start < 0 || end < start {
+			buf.WriteString(s)
+			return buf.String()
+		}
+
+		decoded, err := hex.DecodeString(strings.Join(strings.Fields(s[start+1:end]), ""))
+		if err != nil {
+			buf.WriteString(s[:end+1])
+		} else {
+			buf.WriteString(s[:start])
+			buf.Write(decoded)
+		}
+		s = s[end+1:]
+	}
+}
+
+func textOrName(text, name string) string {
+	if text == "" {
+		return name
+	}
+	return text
+}
@@ -0,0 +1,226 @@
+// Package ppd parses PostScript Printer Description files as used by cups drivers.
+//
+// the parser reads the ui options with their choices and defaults, the constraints between options, the page sizes
+// with their imageable areas and the cups filters of a ppd file:
+//
+//	p, err := ppd.ParseFile("/etc/cups/ppd/printer.ppd")
+//	if err != nil {
+//		return err
+//	}
+//
+//	for _, option := range p.Options() {
+//		fmt.Println(option.Keyword, option.Default)
+//	}
+//
+// PPD.JobAttributes converts selected ppd options into ipp job attributes
+package ppd
+
+// UI types of an option
+const (
+	UIPickOne  = "PickOne"
+	UIPickMany = "PickMany"
+	UIBoolean  = "Boolean"
+)
+
+// PPD is a parsed ppd file
+type PPD struct {
+	FormatVersion   string
+	LanguageVersion string
+	Manufacturer    string
+	ModelName       string
+	NickName        string
+	ShortNickName   string
+	Product         string
+	PCFileName      string
+	ColorDevice     bool
+
+	Groups      []*Group
+	Constraints []Constraint
+	PageSizes   []PageSize
+	Filters     []Filter
+
+	// Attributes are all main keywords of the file which are not ui options or choices, e.g. *cupsVersion
+	Attributes []Attribute
+}
+
+// Group is a group of options, e.g. General or Finishing
+type Group struct {
+	Name      string
+	Text      string
+	Options   []*Option
+	SubGroups []*Group
+}
+
+// Option is an ui option defined by *OpenUI or *JCLOpenUI
+type Option struct {
+	Keyword string
+	Text    string
+	// UI is one of UIPickOne, UIPickMany or UIBoolean
+	UI      string
+	Default string
+	// Section and Order are taken from *OrderDependency
+	Section string
+	Order   float64
+	Choices []*Choice
+}
+
+// Choice is a possible value of an option
+type Choice struct {
+	Name string
+	Text string
+	// Code is the invocation value sent to the printer
+	Code string
+}
+
+// Constraint marks a combination of options and choices which can not be used together. a constraint of
+// *UIConstraints contains two options, *cupsUIConstraints may contain more
+type Constraint struct {
+	// Name is the name of a *cupsUIConstraints constraint, empty for *UIConstraints
+	Name    string
+	Options []ConstraintOption
+}
+
+// ConstraintOption is an option of a constraint, an empty choice matches all choices except None, Off and False
+type ConstraintOption struct {
+	Option string
+	Choice string
+}
+
+// PageSize is a choice of the PageSize option with its dimensions in points
+type PageSize struct {
+	Name   string
+	Text   string
+	Width  float64
+	Length float64
+	// Left, Bottom, Right and Top are the imageable area in points from the bottom left corner
+	Left   float64
+	Bottom float64
+	Right  float64
+	Top    float64
+}
+
+// Filter is a *cupsFilter or *cupsFilter2 entry. DestType is empty for *cupsFilter entries, the output of the
+// filter is the printer format
+type Filter struct {
+	SourceType string
+	DestType   string
+	Cost       int
+	Program    string
+}
+
+// Attribute is a main keyword of a ppd file
+type Attribute struct {
+	Keyword string
+	Option  string
+	Text    string
+	Value   string
+}
+
+// Options returns all options of all groups and sub groups in file order
+func (p *PPD) Options() []*Option {
+	options := make([]*Option, 0)
+
+	var collect func(groups []*Group)
+	collect = func(groups []*Group) {
+		for _, group := range groups {
+			options = append(options, group.Options...)
+			collect(group.SubGroups)
+		}
+	}
+	collect(p.Groups)
+
+	return options
+}
+
+// Option returns the option with the keyword or nil
+func (p *PPD) Option(keyword string) *Option {
+	for _, option := range p.Options() {
+		if option.Keyword == keyword {
+			return option
+		}
+	}
+	return nil
+}
+
+// Defaults returns the default choice of every option
+func (p *PPD) Defaults() map[string]string {
+	defaults := make(map[string]string)
+	for _, option := range p.Options() {
+		if option.Default != "" {
+			defaults[option.Keyword] = option.Default
+		}
+	}
+	return defaults
+}
+
+// Attribute returns the first attribute with the keyword and option or nil
+func (p *PPD) Attribute(keyword, option string) *Attribute {
+	for i, attr := range p.Attributes {
+		if attr.Keyword == keyword && attr.Option == option {
+			return &p.Attributes[i]
+		}
+	}
+	return nil
+}
+
+// PageSize returns the page size with the name or nil
+func (p *PPD) PageSize(name string) *PageSize {
+	for i, size := range p.PageSizes {
+		if size.Name == name {
+			return &p.PageSizes[i]
+		}
+	}
+	return nil
+}
+
+// Conflicts returns the constraints violated by the selected options. options which are not selected are set to
+// their default
+func (p *PPD) Conflicts(selected map[string]string) []Constraint {
+	marked := p.Defaults()
+	for option, choice := range selected {
+		marked[option] = choice
+	}
+
+	conflicts := make([]Constraint, 0)
+	for _, constraint := range p.Constraints {
+		if constraint.matches(marked) {
+			conflicts = append(conflicts, constraint)
+		}
+	}
+
+	return conflicts
+}
+
+func (c Constraint) matches(marked map[string]string) bool {
+	if len(c.Options) == 0 {
+		return false
+	}
+
+	for _, option := range c.Options {
+		choice, ok := marked[option.Option]
+		if !ok {
+			return false
+		}
+
+		if option.Choice == "" {
+			switch choice {
+			case "", "None", "Off", "False":
+				return false
+			}
+		} else if option.Choice != choice {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Choice returns the choice with the name or nil
+func (o *Option) Choice(name string) *Choice {
+	for _, choice := range o.Choices {
+		if choice.Name == name {
+			return choice
+		}
+	}
+	return nil
+}
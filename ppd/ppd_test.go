@@ -0,0 +1,175 @@
+package ppd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/phin1x/go-ipp"
+)
+
+func TestParseFile(t *testing.T) {
+	p, err := ParseFile("testdata/sample.ppd")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "4.3", p.FormatVersion)
+	assert.Equal(t, "Example", p.Manufacturer)
+	assert.Equal(t, "Example Laser 1000", p.ModelName)
+	assert.Equal(t, "Example Laser 1000, 1.0", p.NickName)
+	assert.Equal(t, "SAMPLE.PPD", p.PCFileName)
+	assert.True(t, p.ColorDevice)
+	assert.Equal(t, "2.4", p.Attribute("cupsVersion", "").Value)
+
+	groups := make([]string, 0)
+	for _, group := range p.Groups {
+		groups = append(groups, group.Name)
+	}
+	assert.Equal(t, []string{"General", "Basic", "Advanced"}, groups)
+	assert.Equal(t, "Quality", p.Groups[2].SubGroups[0].Name)
+	assert.Equal(t, "Print Quality", p.Groups[2].SubGroups[0].Text)
+
+	keywords := make([]string, 0)
+	for _, option := range p.Options() {
+		keywords = append(keywords, option.Keyword)
+	}
+	assert.Equal(t, []string{"PageSize", "Staple", "Duplex", "MediaType", "InputSlot", "ColorModel", "JCLToner", "Resolution", "cupsPrintQuality"}, keywords)
+
+	pageSize := p.Option("PageSize")
+	assert.Equal(t, "Media Size", pageSize.Text)
+	assert.Equal(t, UIPickOne, pageSize.UI)
+	assert.Equal(t, "A4", pageSize.Default)
+	assert.Equal(t, "AnySetup", pageSize.Section)
+	assert.Equal(t, 10.0, pageSize.Order)
+	assert.Len(t, pageSize.Choices, 3)
+	assert.Equal(t, "Postcard (100x148mm)", pageSize.Choice("Postcard").Text)
+	assert.Equal(t, "<</PageSize[595 842]/ImagingBBox null>>setpagedevice", pageSize.Choice("A4").Code)
+
+	assert.Equal(t, UIBoolean, p.Option("JCLToner").UI)
+	assert.Equal(t, "@PJL SET ECONOMODE=ON<0A>", p.Option("JCLToner").Choice("True").Code)
+	assert.Equal(t, "\n  <</Staple 0>>setpagedevice\n", p.Option("Staple").Choice("True").Code)
+	assert.Equal(t, "No", p.Option("Staple").Choice("False").Text)
+
+	assert.Equal(t, map[string]string{
+		"PageSize":         "A4",
+		"Staple":           "False",
+		"Duplex":           "None",
+		"MediaType":        "Plain",
+		"InputSlot":        "Tray1",
+		"ColorModel":       "RGB",
+		"JCLToner":         "False",
+		"Resolution":       "600dpi",
+		"cupsPrintQuality": "Normal",
+	}, p.Defaults())
+
+	assert.Equal(t, []PageSize{
+		{Name: "A4", Text: "A4", Width: 595, Length: 842, Left: 18, Bottom: 36, Right: 577, Top: 806},
+		{Name: "Letter", Text: "US Letter", Width: 612, Length: 792, Left: 18, Bottom: 36, Right: 594, Top: 756},
+		{Name: "Postcard", Text: "Postcard (100x148mm)", Width: 283.46, Length: 419.53, Right: 283.46, Top: 419.53},
+	}, p.PageSizes)
+
+	assert.Equal(t, []Filter{
+		{SourceType: "application/vnd.cups-postscript", DestType: "application/postscript", Cost: 0, Program: "-"},
+		{SourceType: "application/vnd.cups-raster", Cost: 50, Program: "rastertoexample"},
+	}, p.Filters)
+
+	assert.Equal(t, []Constraint{
+		{Options: []ConstraintOption{{Option: "Duplex"}, {Option: "MediaType", Choice: "Transparency"}}},
+		{Options: []ConstraintOption{{Option: "MediaType", Choice: "Transparency"}, {Option: "Duplex"}}},
+		{Name: "photo", Options: []ConstraintOption{
+			{Option: "MediaType", Choice: "PhotoGlossy"},
+			{Option: "Resolution", Choice: "300dpi"},
+			{Option: "ColorModel", Choice: "Gray"},
+		}},
+	}, p.Constraints)
+}
+
+func TestParse_Gzip(t *testing.T) {
+	data, err := os.ReadFile("testdata/sample.ppd")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+
+	p, err := Parse(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "Example Laser 1000", p.ModelName)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, invalid := range []string{
+		"",
+		"*NickName: \"no header\"\n",
+		"*PPD-Adobe: \"4.3\"\n*Manufacturer: \"unterminated\n",
+		"*PPD-Adobe: \"4.3\"\n*OpenUI *Duplex: PickSome\n",
+		"*PPD-Adobe: \"4.3\"\n*PaperDimension A4: \"595\"\n",
+		"*PPD-Adobe: \"4.3\"\n*cupsFilter2: \"application/pdf 0 -\"\n",
+	} {
+		_, err := Parse(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPPD_Conflicts(t *testing.T) {
+	p, err := ParseFile("testdata/sample.ppd")
+	assert.NoError(t, err)
+
+	assert.Empty(t, p.Conflicts(nil))
+	assert.Empty(t, p.Conflicts(map[string]string{"MediaType": "Transparency"}))
+	assert.Len(t, p.Conflicts(map[string]string{"MediaType": "Transparency", "Duplex": "DuplexNoTumble"}), 2)
+	assert.Empty(t, p.Conflicts(map[string]string{"MediaType": "PhotoGlossy", "Resolution": "300dpi"}))
+
+	conflicts := p.Conflicts(map[string]string{"MediaType": "PhotoGlossy", "Resolution": "300dpi", "ColorModel": "Gray"})
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, "photo", conflicts[0].Name)
+}
+
+func TestPPD_JobAttributes(t *testing.T) {
+	p, err := ParseFile("testdata/sample.ppd")
+	assert.NoError(t, err)
+
+	attributes, err := p.JobAttributes(map[string]string{
+		"Duplex":           "DuplexTumble",
+		"PageSize":         "A4",
+		"InputSlot":        "Tray1",
+		"MediaType":        "Plain",
+		"ColorModel":       "Gray",
+		"Resolution":       "1200x600dpi",
+		"cupsPrintQuality": "High",
+		"JCLToner":         "True",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		ipp.AttributeSides:             "two-sided-short-edge",
+		ipp.AttributeMedia:             "iso_a4_210x297mm",
+		ipp.AttributeMediaSource:       "tray-1",
+		ipp.AttributeMediaType:         "stationery",
+		ipp.AttributePrintColorMode:    "monochrome",
+		ipp.AttributePrinterResolution: []ipp.Attribute{{Tag: ipp.TagResolution, Name: ipp.AttributePrinterResolution, Value: ipp.Resolution{Height: 1200, Width: 600, Depth: 3}}},
+		ipp.AttributePrintQuality:      5,
+		"JCLToner":                     []ipp.Attribute{{Tag: ipp.TagName, Name: "JCLToner", Value: "True"}},
+	}, attributes)
+
+	req := ipp.NewRequest(ipp.OperationPrintJob, 1)
+	req.JobAttributes = attributes
+	_, err = req.Encode()
+	assert.NoError(t, err)
+
+	attributes, err = new(PPD).JobAttributes(map[string]string{"ColorModel": "KGray"})
+	assert.NoError(t, err)
+	assert.Equal(t, "monochrome", attributes[ipp.AttributePrintColorMode])
+
+	attributes, err = p.JobAttributes(map[string]string{"PageSize": "Postcard", "MediaType": "PhotoGlossy"})
+	assert.NoError(t, err)
+	assert.Equal(t, "custom_postcard_100x148mm", attributes[ipp.AttributeMedia])
+	assert.Equal(t, "photo-glossy", attributes[ipp.AttributeMediaType])
+
+	_, err = p.JobAttributes(map[string]string{"Duplex": "Always"})
+	assert.Error(t, err)
+}
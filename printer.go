@@ -0,0 +1,244 @@
+package ipp
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Printer holds the common attributes of a printer or class as returned by cups
+type Printer struct {
+	Name            string
+	URI             string
+	DeviceURI       string
+	Info            string
+	Location        string
+	MakeAndModel    string
+	State           int8
+	StateMessage    string
+	StateReasons    []string
+	Type            int
+	IsShared        bool
+	IsAcceptingJobs bool
+
+	// Attributes are all attributes returned for the printer
+	Attributes Attributes
+}
+
+// NewPrinterFromAttributes creates a printer from its attributes, missing attributes are left empty
+func NewPrinterFromAttributes(attributes Attributes) *Printer {
+	return &Printer{
+		Name:            attributeString(attributes, AttributePrinterName),
+		URI:             attributeString(attributes, AttributePrinterUriSupported),
+		DeviceURI:       attributeString(attributes, AttributeDeviceURI),
+		Info:            attributeString(attributes, AttributePrinterInfo),
+		Location:        attributeString(attributes, AttributePrinterLocation),
+		MakeAndModel:    attributeString(attributes, AttributePrinterMakeAndModel),
+		State:           int8(attributeInt(attributes, AttributePrinterState)),
+		StateMessage:    attributeString(attributes, AttributePrinterStateMessage),
+		StateReasons:    attributeStrings(attributes, AttributePrinterStateReasons),
+		Type:            attributeInt(attributes, AttributePrinterType),
+		IsShared:        attributeBool(attributes, AttributePrinterIsShared),
+		IsAcceptingJobs: attributeBool(attributes, AttributePrinterIsAcceptingJobs),
+		Attributes:      attributes,
+	}
+}
+
+// PrinterConfig is the configuration of a cups printer applied by CUPSClient.ApplyPrinterConfig. empty fields are not
+// sent, the server keeps its current values for them
+type PrinterConfig struct {
+	Name      string
+	DeviceURI string
+	// PPDName is the name of a ppd or driver known by the server, e.g. everywhere or drv:///sample.drv/generic.ppd
+	PPDName string
+	// PPD is the content of a ppd file uploaded with the request, it is used instead of PPDName
+	PPD io.Reader
+
+	Info          string
+	Location      string
+	ErrorPolicy   string
+	OpPolicy      string
+	PortMonitor   string
+	Shared        *bool
+	AcceptingJobs *bool
+
+	// JobSheetsDefault are the banners printed before and after a job, e.g. standard and none
+	JobSheetsDefault []string
+	// UsersAllowed and UsersDenied restrict the users which can print, names starting with @ are groups. only one of
+	// them can be set, all removes the restriction
+	UsersAllowed []string
+	UsersDenied  []string
+
+	// JobPageLimit and JobKLimit are the pages and kilobytes a user can print within JobQuotaPeriod. zero values remove
+	// the quota
+	JobQuotaPeriod *time.Duration
+	JobPageLimit   *int
+	JobKLimit      *int
+
+	// Defaults are default job options sent as <option>-default attributes, e.g. sides: two-sided-long-edge
+	Defaults map[string]any
+}
+
+// request creates the CUPS-Add-Modify-Printer request of the config
+func (p PrinterConfig) request(printerURI string, reqID int32) (*Request, error) {
+	if p.Name == "" {
+		return nil, fmt.Errorf("printer name must not be empty")
+	}
+	if len(p.UsersAllowed) > 0 && len(p.UsersDenied) > 0 {
+		return nil, fmt.Errorf("users allowed and users denied can not be set both")
+	}
+
+	req := NewRequest(OperationCupsAddModifyPrinter, reqID)
+	req.OperationAttributes[AttributePrinterURI] = printerURI
+
+	if p.PPD != nil {
+		req.File = p.PPD
+	} else if p.PPDName != "" {
+		req.OperationAttributes[AttributePPDName] = p.PPDName
+	}
+	if p.Shared != nil {
+		req.OperationAttributes[AttributePrinterIsShared] = *p.Shared
+	}
+	if p.AcceptingJobs != nil {
+		req.PrinterAttributes[AttributePrinterIsAcceptingJobs] = *p.AcceptingJobs
+	}
+
+	values := map[string]string{
+		AttributeDeviceURI:          p.DeviceURI,
+		AttributePrinterInfo:        p.Info,
+		AttributePrinterLocation:    p.Location,
+		AttributePrinterErrorPolicy: p.ErrorPolicy,
+		AttributePrinterOpPolicy:    p.OpPolicy,
+		AttributePortMonitor:        p.PortMonitor,
+	}
+	for attr, value := range values {
+		if value != "" {
+			req.PrinterAttributes[attr] = value
+		}
+	}
+
+	lists := map[string][]string{
+		AttributeJobSheetsDefault:          p.JobSheetsDefault,
+		AttributeRequestingUserNameAllowed: p.UsersAllowed,
+		AttributeRequestingUserNameDenied:  p.UsersDenied,
+	}
+	for attr, values := range lists {
+		if len(values) > 0 {
+			req.PrinterAttributes[attr] = values
+		}
+	}
+
+	if p.JobQuotaPeriod != nil {
+		req.PrinterAttributes[AttributeJobQuotaPeriod] = int(*p.JobQuotaPeriod / time.Second)
+	}
+	if p.JobPageLimit != nil {
+		req.PrinterAttributes[AttributeJobPageLimit] = *p.JobPageLimit
+	}
+	if p.JobKLimit != nil {
+		req.PrinterAttributes[AttributeJobKLimit] = *p.JobKLimit
+	}
+
+	for option, value := range p.Defaults {
+		attr, err := defaultAttribute(option, value)
+		if err != nil {
+			return nil, err
+		}
+		req.PrinterAttributes[option+"-default"] = attr
+	}
+
+	return req, nil
+}
+
+// defaultAttribute converts the default value of a job option into attributes. the tag is taken from the option, e.g.
+// keyword for sides, or from the type of the value for options unknown by AttributeTagMapping like ppd options
+func defaultAttribute(option string, value any) ([]Attribute, error) {
+	name := option + "-default"
+
+	tag, known := AttributeTagMapping[name]
+	if !known {
+		tag, known = AttributeTagMapping[option]
+	}
+
+	var values []any
+	switch v := value.(type) {
+	case []string:
+		for _, s := range v {
+			values = append(values, s)
+		}
+	case []int:
+		for _, i := range v {
+			values = append(values, i)
+		}
+	case []Collection:
+		for _, c := range v {
+			values = append(values, c)
+		}
+	default:
+		values = append(values, v)
+	}
+
+	attrs := make([]Attribute, 0, len(values))
+	for _, v := range values {
+		valueTag := tag
+		if !known {
+			switch v.(type) {
+			case string:
+				valueTag = TagName
+			case int:
+				valueTag = TagInteger
+			case bool:
+				valueTag = TagBoolean
+			case Resolution:
+				valueTag = TagResolution
+			case Collection:
+				valueTag = TagBeginCollection
+			default:
+				return nil, fmt.Errorf("unsupported value type %T of default %s", v, name)
+			}
+		}
+		attrs = append(attrs, Attribute{Tag: valueTag, Name: name, Value: v})
+	}
+
+	return attrs, nil
+}
+
+// attributeString returns the first value of a string attribute or an empty string
+func attributeString(attributes Attributes, name string) string {
+	if values := attributes[name]; len(values) > 0 {
+		if s, ok := values[0].Value.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// attributeStrings returns all string values of an attribute
+func attributeStrings(attributes Attributes, name string) []string {
+	values := make([]string, 0, len(attributes[name]))
+	for _, attr := range attributes[name] {
+		if s, ok := attr.Value.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// attributeInt returns the first value of an integer attribute or 0
+func attributeInt(attributes Attributes, name string) int {
+	if values := attributes[name]; len(values) > 0 {
+		if i, ok := values[0].Value.(int); ok {
+			return i
+		}
+	}
+	return 0
+}
+
+// attributeBool returns the first value of a boolean attribute or false
+func attributeBool(attributes Attributes, name string) bool {
+	if values := attributes[name]; len(values) > 0 {
+		if b, ok := values[0].Value.(bool); ok {
+			return b
+		}
+	}
+	return false
+}
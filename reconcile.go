@@ -0,0 +1,334 @@
+package ipp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// kinds of plan actions
+const (
+	ActionCreatePrinter = "create-printer"
+	ActionModifyPrinter = "modify-printer"
+	ActionDeletePrinter = "delete-printer"
+	ActionCreateClass   = "create-class"
+	ActionModifyClass   = "modify-class"
+	ActionDeleteClass   = "delete-class"
+)
+
+// DesiredState is the configuration a cups server is reconciled to
+type DesiredState struct {
+	Printers []PrinterConfig
+	Classes  []ClassConfig
+	// Prune deletes the printers and classes of the server which are not part of the desired state. temporary
+	// printers created by cups for driverless printers are never deleted
+	Prune bool
+}
+
+// ClassConfig is the configuration of a cups class
+type ClassConfig struct {
+	Name    string
+	Members []string
+}
+
+// PlanAction is a single change to reconcile a server
+type PlanAction struct {
+	// Kind is one of the Action constants
+	Kind string
+	Name string
+	// Changes are the attributes which differ from the server, only set for modify actions
+	Changes []string
+	// Printer is the config applied by create and modify printer actions
+	Printer *PrinterConfig
+	// Members are the members of the class set by create and modify class actions
+	Members []string
+}
+
+// Plan contains the actions required to reconcile a server, in the order they are applied
+type Plan struct {
+	Actions []PlanAction
+}
+
+// Empty reports if the server already matches the desired state
+func (p *Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// String returns a human readable summary of the plan with one action per line
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, action := range p.Actions {
+		b.WriteString(action.Kind)
+		b.WriteByte(' ')
+		b.WriteString(action.Name)
+		if len(action.Changes) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(action.Changes, ", "))
+		}
+		if len(action.Members) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(action.Members, ", "))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Reconcile plans the changes to reach the desired state and applies them unless dryRun is set. the plan is returned
+// in both cases
+func (c *CUPSClient) Reconcile(state DesiredState, dryRun bool) (*Plan, error) {
+	return c.ReconcileContext(context.Background(), state, dryRun)
+}
+
+func (c *CUPSClient) ReconcileContext(ctx context.Context, state DesiredState, dryRun bool) (*Plan, error) {
+	plan, err := c.PlanContext(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return plan, nil
+	}
+
+	return plan, c.ApplyPlanContext(ctx, plan)
+}
+
+// Plan compares the desired state with the printers and classes of the server and returns the required changes.
+// only the fields set in a printer config are compared, ppd files are only sent when a printer is created
+func (c *CUPSClient) Plan(state DesiredState) (*Plan, error) {
+	return c.PlanContext(context.Background(), state)
+}
+
+func (c *CUPSClient) PlanContext(ctx context.Context, state DesiredState) (*Plan, error) {
+	requested := []string{AttributePrinterIsTemporary}
+	for _, config := range state.Printers {
+		req, err := config.request("", 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, attributes := range []map[string]any{req.OperationAttributes, req.PrinterAttributes} {
+			for attr := range attributes {
+				requested = append(requested, attr)
+			}
+		}
+	}
+
+	printers, err := c.GetPrintersContext(ctx, requested)
+	if err != nil && !IsNotExistsError(err) {
+		return nil, err
+	}
+
+	classes, err := c.GetClassesContext(ctx, []string{AttributeMemberNames})
+	if err != nil && !IsNotExistsError(err) {
+		return nil, err
+	}
+
+	// cups returns classes as printers too
+	for name := range classes {
+		delete(printers, name)
+	}
+
+	plan := &Plan{Actions: make([]PlanAction, 0)}
+
+	desiredPrinters := make(map[string]bool)
+	for i := range state.Printers {
+		config := state.Printers[i]
+		desiredPrinters[config.Name] = true
+
+		current, ok := printers[config.Name]
+		if !ok {
+			plan.Actions = append(plan.Actions, PlanAction{Kind: ActionCreatePrinter, Name: config.Name, Printer: &config})
+			continue
+		}
+
+		config.PPD = nil
+		config.PPDName = ""
+		req, _ := config.request("", 0)
+		if changes := attributeChanges(req, current); len(changes) > 0 {
+			plan.Actions = append(plan.Actions, PlanAction{Kind: ActionModifyPrinter, Name: config.Name, Changes: changes, Printer: &config})
+		}
+	}
+
+	desiredClasses := make(map[string]bool)
+	for _, config := range state.Classes {
+		if config.Name == "" {
+			return nil, fmt.Errorf("class name must not be empty")
+		}
+		if len(config.Members) == 0 {
+			return nil, fmt.Errorf("class %s has no members", config.Name)
+		}
+		desiredClasses[config.Name] = true
+
+		current, ok := classes[config.Name]
+		if !ok {
+			plan.Actions = append(plan.Actions, PlanAction{Kind: ActionCreateClass, Name: config.Name, Members: config.Members})
+			continue
+		}
+
+		if !sameMembers(config.Members, attributeStrings(current, AttributeMemberNames)) {
+			plan.Actions = append(plan.Actions, PlanAction{Kind: ActionModifyClass, Name: config.Name, Changes: []string{AttributeMemberNames}, Members: config.Members})
+		}
+	}
+
+	if state.Prune {
+		for _, name := range sortedKeys(classes) {
+			if !desiredClasses[name] {
+				plan.Actions = append(plan.Actions, PlanAction{Kind: ActionDeleteClass, Name: name})
+			}
+		}
+
+		for _, name := range sortedKeys(printers) {
+			if !desiredPrinters[name] && !attributeBool(printers[name], AttributePrinterIsTemporary) {
+				plan.Actions = append(plan.Actions, PlanAction{Kind: ActionDeletePrinter, Name: name})
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyPlan applies the actions of a plan in order and stops at the first error
+func (c *CUPSClient) ApplyPlan(plan *Plan) error {
+	return c.ApplyPlanContext(context.Background(), plan)
+}
+
+func (c *CUPSClient) ApplyPlanContext(ctx context.Context, plan *Plan) error {
+	for _, action := range plan.Actions {
+		var err error
+
+		switch action.Kind {
+		case ActionCreatePrinter, ActionModifyPrinter:
+			err = c.ApplyPrinterConfigContext(ctx, *action.Printer)
+		case ActionDeletePrinter:
+			err = c.DeletePrinterContext(ctx, action.Name)
+		case ActionCreateClass, ActionModifyClass:
+			err = c.setClassMembers(ctx, action.Name, action.Members)
+		case ActionDeleteClass:
+			err = c.DeleteClassContext(ctx, action.Name)
+		default:
+			err = fmt.Errorf("unknown action %s", action.Kind)
+		}
+
+		if err != nil {
+			return fmt.Errorf("unable to %s %s: %w", action.Kind, action.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// setClassMembers creates a class or replaces its members
+func (c *CUPSClient) setClassMembers(ctx context.Context, class string, members []string) error {
+	memberURIList := make([]string, 0, len(members))
+	for _, member := range members {
+		memberURIList = append(memberURIList, c.getPrinterUri(member))
+	}
+
+	req := NewRequest(OperationCupsAddModifyClass, c.nextRequestID())
+	req.OperationAttributes[AttributePrinterURI] = c.getClassUri(class)
+	req.PrinterAttributes[AttributeMemberURIs] = memberURIList
+
+	_, err := c.SendRequestContext(ctx, c.adapter.GetHttpUri("admin", ""), req, nil)
+	return err
+}
+
+// attributeChanges returns the sorted names of the request attributes whose values differ from the current attributes
+func attributeChanges(req *Request, current Attributes) []string {
+	changes := make([]string, 0)
+
+	for _, attributes := range []map[string]any{req.OperationAttributes, req.PrinterAttributes} {
+		for attr, value := range attributes {
+			if attr == AttributePrinterURI {
+				continue
+			}
+
+			if !sameValues(attr, requestValues(value), current[attr]) {
+				changes = append(changes, attr)
+			}
+		}
+	}
+
+	sort.Strings(changes)
+	return changes
+}
+
+// unorderedAttributes are set valued attributes which the server may return in another order than they were sent
+var unorderedAttributes = map[string]bool{
+	AttributeRequestingUserNameAllowed: true,
+	AttributeRequestingUserNameDenied:  true,
+}
+
+// sameValues reports if the desired values of a request attribute equal the current values of the server
+func sameValues(attr string, desired []any, current []Attribute) bool {
+	values := make([]any, 0, len(current))
+	for _, value := range current {
+		values = append(values, value.Value)
+	}
+
+	// cups always returns the start and the end banner
+	if attr == AttributeJobSheetsDefault && len(desired) == 1 && len(values) == 2 {
+		desired = append(desired, "none")
+	}
+
+	if len(desired) != len(values) {
+		return false
+	}
+
+	if unorderedAttributes[attr] {
+		sortValues(desired)
+		sortValues(values)
+	}
+
+	return reflect.DeepEqual(desired, values)
+}
+
+func sortValues(values []any) {
+	sort.Slice(values, func(i, j int) bool {
+		return fmt.Sprint(values[i]) < fmt.Sprint(values[j])
+	})
+}
+
+// requestValues returns the single values of a request attribute value
+func requestValues(value any) []any {
+	values := make([]any, 0)
+	switch v := value.(type) {
+	case []Attribute:
+		for _, attr := range v {
+			values = append(values, attr.Value)
+		}
+	case []string:
+		for _, s := range v {
+			values = append(values, s)
+		}
+	case []int:
+		for _, i := range v {
+			values = append(values, i)
+		}
+	case []Collection:
+		for _, c := range v {
+			values = append(values, c)
+		}
+	default:
+		values = append(values, v)
+	}
+	return values
+}
+
+// sameMembers reports if both lists contain the same printers regardless of their order
+func sameMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package ipp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCUPSClient_Reconcile(t *testing.T) {
+	client, adapter := newMockCUPSClient()
+	assert.NoError(t, client.AddPrinterToClass("old-class", "printer-2"))
+	assert.NoError(t, client.ApplyPrinterConfig(PrinterConfig{Name: "printer-1", Location: "basement"}))
+
+	state := DesiredState{
+		Printers: []PrinterConfig{
+			{Name: "printer-1", Location: "1st floor", Defaults: map[string]any{AttributeSides: "one-sided"}},
+			{Name: "printer-3", DeviceURI: "socket://10.0.0.3", PPD: bytes.NewBufferString("*PPD-Adobe: \"4.3\"\n")},
+		},
+		Classes: []ClassConfig{
+			{Name: "all", Members: []string{"printer-1", "printer-3"}},
+		},
+		Prune: true,
+	}
+
+	plan, err := client.Reconcile(state, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "modify-printer printer-1 (printer-location, sides-default)\n"+
+		"create-printer printer-3\n"+
+		"create-class all [printer-1, printer-3]\n"+
+		"delete-class old-class\n"+
+		"delete-printer printer-2\n", plan.String())
+
+	// a dry run does not change the server
+	printers, err := client.GetPrinters(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, printers, "printer-2")
+	assert.NotContains(t, printers, "printer-3")
+
+	_, err = client.Reconcile(state, false)
+	assert.NoError(t, err)
+
+	printers, err = client.GetPrinters([]string{AttributePrinterLocation})
+	assert.NoError(t, err)
+	assert.Len(t, printers, 2)
+	assert.Equal(t, "1st floor", printers["printer-1"][AttributePrinterLocation][0].Value)
+	_, ok := adapter.PrinterPPD("printer-3")
+	assert.True(t, ok)
+
+	classes, err := client.GetClasses(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"all"}, sortedKeys(classes))
+	assert.Len(t, classes["all"][AttributeMemberNames], 2)
+
+	state.Classes[0].Members = []string{"printer-3", "printer-1"}
+	plan, err = client.Plan(state)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+
+	state.Classes[0].Members = []string{"printer-3"}
+	plan, err = client.Plan(state)
+	assert.NoError(t, err)
+	assert.Equal(t, []PlanAction{{Kind: ActionModifyClass, Name: "all", Changes: []string{AttributeMemberNames}, Members: []string{"printer-3"}}}, plan.Actions)
+
+	_, err = client.Plan(DesiredState{Classes: []ClassConfig{{Name: "empty"}}})
+	assert.Error(t, err)
+}
+
+func TestCUPSClient_ReconcileRemovesQuota(t *testing.T) {
+	client, _ := newMockCUPSClient()
+	pageLimit := 100
+	assert.NoError(t, client.ApplyPrinterConfig(PrinterConfig{Name: "printer-1", JobPageLimit: &pageLimit}))
+
+	pageLimit = 0
+	state := DesiredState{Printers: []PrinterConfig{{Name: "printer-1", JobPageLimit: &pageLimit}}}
+
+	plan, err := client.Reconcile(state, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "modify-printer printer-1 (job-page-limit)\n", plan.String())
+
+	plan, err = client.Plan(state)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+}
+
+func TestCUPSClient_PlanComparesValues(t *testing.T) {
+	client, _ := newMockCUPSClient()
+
+	mediaCol := Collection{
+		AttributeMediaSize: {{Tag: TagBeginCollection, Name: AttributeMediaSize, Value: Collection{
+			"x-dimension": {{Tag: TagInteger, Name: "x-dimension", Value: 21000}},
+			"y-dimension": {{Tag: TagInteger, Name: "y-dimension", Value: 29700}},
+		}}},
+	}
+	assert.NoError(t, client.ApplyPrinterConfig(PrinterConfig{
+		Name:             "printer-1",
+		UsersAllowed:     []string{"alice", "bob"},
+		JobSheetsDefault: []string{"standard", "none"},
+		Defaults:         map[string]any{AttributeMediaCol: mediaCol},
+	}))
+
+	// cups returns the end banner of job sheets set with a single value
+	state := DesiredState{Printers: []PrinterConfig{{
+		Name:             "printer-1",
+		UsersAllowed:     []string{"bob", "alice"},
+		JobSheetsDefault: []string{"standard"},
+		Defaults:         map[string]any{AttributeMediaCol: mediaCol},
+	}}}
+
+	plan, err := client.Plan(state)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+
+	state.Printers[0].UsersAllowed = []string{"bob", "carol"}
+	plan, err = client.Plan(state)
+	assert.NoError(t, err)
+	assert.Equal(t, "modify-printer printer-1 (requesting-user-name-allowed)\n", plan.String())
+}
@@ -17,7 +17,8 @@ type Request struct {
 	JobAttributes       map[string]any
 	PrinterAttributes   map[string]any
 
-	File     io.Reader
+	File io.Reader
+	// FileSize is the size of File in bytes, -1 if the size is unknown (e.g. compressed documents)
 	FileSize int
 }
 
@@ -44,3 +45,20 @@ func (r *Request) Encode() ([]byte, error) {
 	}
 	return buf.Bytes(), nil
 }
+
+// requestAttribute returns the value of a request attribute as T. single values which were decoded as []Attribute,
+// because they were sent with another tag than the one of AttributeTagMapping, are returned too
+func requestAttribute[T any](attributes map[string]any, name string) (T, bool) {
+	switch v := attributes[name].(type) {
+	case T:
+		return v, true
+	case []Attribute:
+		if len(v) == 1 {
+			value, ok := v[0].Value.(T)
+			return value, ok
+		}
+	}
+
+	var zero T
+	return zero, false
+}
@@ -16,7 +16,9 @@ func NewRequestDecoder(r io.Reader) *RequestDecoder {
 	}
 }
 
-// Decode decodes a ipp request into a request  struct. additional data will be written to an io.Writer if data is not nil
+// Decode decodes a ipp request into a request  struct. additional data will be written to an io.Writer if data is not nil.
+// attributes sent with the tag of AttributeTagMapping are decoded into plain values, e.g. string, int or []string for
+// multiple values. unknown attributes and attributes sent with another tag are decoded as []Attribute to keep their tag
 func (d *RequestDecoder) Decode(data io.Writer) (*Request, error) {
 	return newRequestStateMachine().Decode(d.reader, data)
 }
@@ -87,7 +87,7 @@ func (r *requestDecoderStateMachine) Decode(reader io.Reader, data io.Writer) (*
 			}
 
 			// read first attribute group tag
-			if _, err := reader.Read(b); err != nil {
+			if _, err := io.ReadFull(reader, b); err != nil {
 				return nil, err
 			}
 			r.setAttributeGroupTag(int8(b[0]))
@@ -110,7 +110,7 @@ func (r *requestDecoderStateMachine) Decode(reader io.Reader, data io.Writer) (*
 
 			r.state = requestDecoderStateAttribute
 		case requestDecoderStateAttribute:
-			if _, err := reader.Read(b); err != nil {
+			if _, err := io.ReadFull(reader, b); err != nil {
 				return nil, err
 			}
 			if b[0] < 0x10 {
@@ -128,11 +128,18 @@ func (r *requestDecoderStateMachine) Decode(reader io.Reader, data io.Writer) (*
 			// save attribute name for optional additional values
 			if attrib.Name != "" {
 				r.currentAttributeName = attrib.Name
+				r.currentAttributes[r.currentAttributeName] = requestValue(attrib)
+				continue
 			}
 
-			// TODO FIX: handle attributes with array values
-			// append attribute to list
-			r.currentAttributes[r.currentAttributeName] = attrib.Value
+			// additional value of a multi-valued attribute
+			current := r.currentAttributes[r.currentAttributeName]
+			if attrs, ok := current.([]Attribute); ok {
+				attrib.Name = r.currentAttributeName
+				r.currentAttributes[r.currentAttributeName] = append(attrs, *attrib)
+				continue
+			}
+			r.currentAttributes[r.currentAttributeName] = appendRequestValue(current, attrib.Value)
 		case requestDecoderStateData:
 			if data != nil {
 				if _, err := io.Copy(data, reader); err != nil {
@@ -160,3 +167,55 @@ func appendAttributeToRequest(req *Request, tag int8, attributes map[string]any)
 		req.JobAttributes = attributes
 	}
 }
+
+// requestValue returns the value of a decoded attribute. attributes which are unknown to AttributeTagMapping or were
+// sent with another tag are returned as []Attribute, so they are encoded again with their original tag
+func requestValue(attr *Attribute) any {
+	if tag, ok := AttributeTagMapping[attr.Name]; ok && tag == attr.Tag {
+		return attr.Value
+	}
+	return []Attribute{*attr}
+}
+
+// appendRequestValue appends an additional value to an attribute value. values of the same type are collected in a
+// typed slice, so they can be encoded again
+func appendRequestValue(current, value any) any {
+	switch c := current.(type) {
+	case string:
+		if v, ok := value.(string); ok {
+			return []string{c, v}
+		}
+	case []string:
+		if v, ok := value.(string); ok {
+			return append(c, v)
+		}
+	case int:
+		if v, ok := value.(int); ok {
+			return []int{c, v}
+		}
+	case []int:
+		if v, ok := value.(int); ok {
+			return append(c, v)
+		}
+	case bool:
+		if v, ok := value.(bool); ok {
+			return []bool{c, v}
+		}
+	case []bool:
+		if v, ok := value.(bool); ok {
+			return append(c, v)
+		}
+	case Collection:
+		if v, ok := value.(Collection); ok {
+			return []Collection{c, v}
+		}
+	case []Collection:
+		if v, ok := value.(Collection); ok {
+			return append(c, v)
+		}
+	case []any:
+		return append(c, value)
+	}
+
+	return []any{current, value}
+}
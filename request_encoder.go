@@ -77,16 +77,21 @@ func (e *requestEncoder) encodeOperationAttributes(attributes map[string]any) er
 		AttributeJobID,
 	}
 
+	ordered := make(map[string]bool, len(order))
 	for _, attr := range order {
+		ordered[attr] = true
 		if value, ok := attributes[attr]; ok {
-			delete(attributes, attr)
 			if err := e.attrEncoder.Encode(attr, value); err != nil {
 				return err
 			}
 		}
 	}
 
+	// the attributes map is not modified, so a request can be encoded more than once (e.g. on retries)
 	for attr, value := range attributes {
+		if ordered[attr] {
+			continue
+		}
 		if err := e.attrEncoder.Encode(attr, value); err != nil {
 			return err
 		}
@@ -58,3 +58,45 @@ func TestRequestDecoder_Decode(t *testing.T) {
 		assert.Equal(t, &c.Request, request, "decoded request is not correct")
 	}
 }
+
+func TestRequestDecoder_DecodeAttributeValues(t *testing.T) {
+	req := NewRequest(OperationPrintJob, 1)
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/test"
+	req.OperationAttributes[AttributeRequestedAttributes] = []string{AttributeJobID, AttributeJobState}
+	req.JobAttributes[AttributeCopies] = 2
+	req.JobAttributes[AttributeFinishings] = []int{4, 5}
+	req.JobAttributes[AttributeJobHoldUntil] = []Attribute{{Tag: TagName, Value: "tonight"}}
+	req.JobAttributes["x-vendor-option"] = []Attribute{{Tag: TagKeyword, Value: "a"}, {Tag: TagKeyword, Value: "b"}}
+
+	payload, err := req.Encode()
+	assert.NoError(t, err)
+
+	decoded, err := NewRequestDecoder(bytes.NewReader(payload)).Decode(nil)
+	assert.NoError(t, err)
+
+	// attributes with the tag of AttributeTagMapping are plain values
+	assert.Equal(t, "ipp://localhost/printers/test", decoded.OperationAttributes[AttributePrinterURI])
+	assert.Equal(t, []string{AttributeJobID, AttributeJobState}, decoded.OperationAttributes[AttributeRequestedAttributes])
+	assert.Equal(t, 2, decoded.JobAttributes[AttributeCopies])
+	assert.Equal(t, []int{4, 5}, decoded.JobAttributes[AttributeFinishings])
+
+	// attributes with another tag and unknown attributes keep their tag
+	assert.Equal(t, []Attribute{{Tag: TagName, Name: AttributeJobHoldUntil, Value: "tonight"}}, decoded.JobAttributes[AttributeJobHoldUntil])
+	assert.Equal(t, []Attribute{
+		{Tag: TagKeyword, Name: "x-vendor-option", Value: "a"},
+		{Tag: TagKeyword, Name: "x-vendor-option", Value: "b"},
+	}, decoded.JobAttributes["x-vendor-option"])
+
+	hold, ok := requestAttribute[string](decoded.JobAttributes, AttributeJobHoldUntil)
+	assert.True(t, ok)
+	assert.Equal(t, "tonight", hold)
+	_, ok = requestAttribute[string](decoded.JobAttributes, "x-vendor-option")
+	assert.False(t, ok)
+
+	// the decoded request is encoded again with the same tags
+	reencoded, err := decoded.Encode()
+	assert.NoError(t, err)
+	redecoded, err := NewRequestDecoder(bytes.NewReader(reencoded)).Decode(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, decoded, redecoded)
+}
@@ -2,6 +2,7 @@ package ipp
 
 import (
 	"bytes"
+	"errors"
 )
 
 // Attributes is a wrapper for a set of attributes
@@ -39,6 +40,24 @@ func (r *Response) CheckForErrors() error {
 	return nil
 }
 
+// checkResponse checks the response for errors like CheckForErrors, the error keeps the response. the error of a
+// cups-see-other response keeps the printer-uri the server redirects to
+func checkResponse(r *Response) error {
+	err := r.CheckForErrors()
+
+	var ippErr IPPError
+	if !errors.As(err, &ippErr) {
+		return err
+	}
+	ippErr.Response = r
+
+	if r.StatusCode == StatusCupsSeeOther {
+		return seeOtherError{IPPError: ippErr, Location: attributeString(r.OperationAttributes, AttributePrinterURI)}
+	}
+
+	return ippErr
+}
+
 // NewResponse creates a new ipp response
 func NewResponse(statusCode int16, reqID int32) *Response {
 	return &Response{
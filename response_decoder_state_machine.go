@@ -88,7 +88,7 @@ func (r *responseDecoderStateMachine) Decode(reader io.Reader, data io.Writer) (
 			}
 
 			// read first attribute group tag
-			if _, err := reader.Read(b); err != nil {
+			if _, err := io.ReadFull(reader, b); err != nil {
 				return nil, err
 			}
 			r.setAttributeGroupTag(int8(b[0]))
@@ -111,7 +111,7 @@ func (r *responseDecoderStateMachine) Decode(reader io.Reader, data io.Writer) (
 
 			r.state = responseDecoderStateAttribute
 		case responseDecoderStateAttribute:
-			if _, err := reader.Read(b); err != nil {
+			if _, err := io.ReadFull(reader, b); err != nil {
 				return nil, err
 			}
 			if b[0] < 0x10 {
@@ -62,6 +62,12 @@ func (e *responseEncoder) encode(r *Response) error {
 		return err
 	}
 
+	if len(r.UnsupportedAttributes) > 0 {
+		if err := e.encodeAttributes(TagDelimiterUnsupported, r.UnsupportedAttributes); err != nil {
+			return err
+		}
+	}
+
 	if len(r.PrinterAttributes) > 0 {
 		for _, attribute := range r.PrinterAttributes {
 			if err := e.encodeAttributes(TagDelimiterPrinter, attribute); err != nil {
@@ -103,9 +109,10 @@ func (e *responseEncoder) encodeOperationAttributes(attributes Attributes) error
 		AttributeJobID,
 	}
 
+	ordered := make(map[string]bool, len(order))
 	for _, name := range order {
+		ordered[name] = true
 		if attr, ok := attributes[name]; ok {
-			delete(attributes, name)
 			if err := e.encodeAttribute(name, attr); err != nil {
 				return err
 			}
@@ -113,6 +120,9 @@ func (e *responseEncoder) encodeOperationAttributes(attributes Attributes) error
 	}
 
 	for name, attr := range attributes {
+		if ordered[name] {
+			continue
+		}
 		if err := e.encodeAttribute(name, attr); err != nil {
 			return err
 		}
@@ -126,14 +136,5 @@ func (e *responseEncoder) encodeAttribute(name string, attr []Attribute) error {
 		return nil
 	}
 
-	values := make([]any, len(attr))
-	for i, v := range attr {
-		values[i] = v.Value
-	}
-
-	if len(values) == 1 {
-		return e.attrEncoder.Encode(name, values[0])
-	}
-
-	return e.attrEncoder.Encode(name, values)
+	return e.attrEncoder.EncodeAttributes(name, attr)
 }
@@ -2,6 +2,7 @@ package ipp
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -72,3 +73,15 @@ func TestResponseDecoder_Decode(t *testing.T) {
 		assert.Equal(t, &c.Response, response, "decoded response is not correct")
 	}
 }
+
+func TestResponse_CheckForErrors(t *testing.T) {
+	assert.NoError(t, NewResponse(StatusOk, 1).CheckForErrors())
+
+	resp := NewResponse(StatusCupsSeeOther, 1)
+	resp.OperationAttributes[AttributePrinterURI] = []Attribute{{Tag: TagUri, Name: AttributePrinterURI, Value: "ipp://remote/printers/test"}}
+	assert.Equal(t, IPPError{Status: StatusCupsSeeOther, Message: "no status message returned"}, resp.CheckForErrors())
+
+	var seeOther seeOtherError
+	assert.True(t, errors.As(checkResponse(resp), &seeOther))
+	assert.Equal(t, "ipp://remote/printers/test", seeOther.Location)
+}
@@ -0,0 +1,260 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Handler responds to a single ipp operation. additional response data (e.g. a document or ppd) can be written to
+// additionalResponseData, it is sent after the encoded response.
+// returning an IPPError responds with its status code and message, any other error with server-error-internal-error
+type Handler interface {
+	ServeIPP(ctx context.Context, req *Request, additionalResponseData io.Writer) (*Response, error)
+}
+
+// HandlerFunc is an adapter to use ordinary functions as Handler
+type HandlerFunc func(ctx context.Context, req *Request, additionalResponseData io.Writer) (*Response, error)
+
+// ServeIPP calls f(ctx, req, additionalResponseData)
+func (f HandlerFunc) ServeIPP(ctx context.Context, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	return f(ctx, req, additionalResponseData)
+}
+
+// supportedCharsets are the values of attributes-charset accepted by the server
+var supportedCharsets = map[string]bool{
+	Charset:    true,
+	"us-ascii": true,
+}
+
+// supportedUriSchemes are the schemes accepted for printer-uri and job-uri
+var supportedUriSchemes = map[string]bool{
+	"ipp":   true,
+	"ipps":  true,
+	"http":  true,
+	"https": true,
+}
+
+// untargetedOperations do not address a printer or job and therefore need no printer-uri
+var untargetedOperations = map[int16]bool{
+	OperationCupsGetDefault:  true,
+	OperationCupsGetPrinters: true,
+	OperationCupsGetClasses:  true,
+	OperationCupsGetDevices:  true,
+	OperationCupsGetPPDs:     true,
+	OperationCupsGetPpd:      true,
+}
+
+type httpRequestContextKey struct{}
+
+// HTTPRequestFromContext returns the http request of an ipp request handled by a Server
+func HTTPRequestFromContext(ctx context.Context) (*http.Request, bool) {
+	r, ok := ctx.Value(httpRequestContextKey{}).(*http.Request)
+	return r, ok
+}
+
+// Server is a http.Handler which decodes ipp requests and dispatches them by operation to the registered handlers.
+// the document of a request is not buffered, it is available to the handler as Request.File
+type Server struct {
+	mu             sync.RWMutex
+	handlers       map[int16]Handler
+	defaultHandler Handler
+}
+
+// NewServer creates a new server without any handlers
+func NewServer() *Server {
+	return &Server{
+		handlers: make(map[int16]Handler),
+	}
+}
+
+// Handle registers the handler for the given operation
+func (s *Server) Handle(operation int16, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[operation] = handler
+}
+
+// HandleFunc registers the handler function for the given operation
+func (s *Server) HandleFunc(operation int16, handler func(ctx context.Context, req *Request, additionalResponseData io.Writer) (*Response, error)) {
+	s.Handle(operation, HandlerFunc(handler))
+}
+
+// HandleDefault registers the handler for all operations without a registered handler. without a default handler
+// these operations are answered with server-error-operation-not-supported
+func (s *Server) HandleDefault(handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.defaultHandler = handler
+}
+
+func (s *Server) handler(operation int16) (Handler, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if handler, ok := s.handlers[operation]; ok {
+		return handler, true
+	}
+	return s.defaultHandler, s.defaultHandler != nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != ContentTypeIPP {
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// decode only the attributes, the document data is left in the body for the handler
+	req, err := NewRequestDecoder(r.Body).Decode(nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to decode IPP request: %v", err), http.StatusBadRequest)
+		return
+	}
+	req.File = r.Body
+	req.FileSize = -1
+
+	data := new(bytes.Buffer)
+	resp, err := s.serve(context.WithValue(r.Context(), httpRequestContextKey{}, r), req, data)
+	if err != nil {
+		resp = newErrorResponse(req, err)
+		data.Reset()
+	}
+
+	resp.ProtocolVersionMajor = req.ProtocolVersionMajor
+	resp.ProtocolVersionMinor = req.ProtocolVersionMinor
+	resp.RequestId = req.RequestId
+	if resp.StatusCode == StatusErrorVersionNotSupported {
+		resp.ProtocolVersionMajor = ProtocolVersionMajor
+		resp.ProtocolVersionMinor = ProtocolVersionMinor
+	}
+
+	payload, err := resp.Encode()
+	if err != nil {
+		payload, err = newErrorResponse(req, fmt.Errorf("unable to encode IPP response: %w", err)).Encode()
+		if err != nil {
+			http.Error(w, "unable to encode IPP response", http.StatusInternalServerError)
+			return
+		}
+		data.Reset()
+	}
+
+	w.Header().Set("Content-Type", ContentTypeIPP)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(payload)
+	_, _ = data.WriteTo(w)
+}
+
+func (s *Server) serve(ctx context.Context, req *Request, data io.Writer) (*Response, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	handler, ok := s.handler(req.Operation)
+	if !ok {
+		return nil, IPPError{
+			Status:  StatusErrorOperationNotSupported,
+			Message: fmt.Sprintf("operation %s is not supported", OperationName(req.Operation)),
+		}
+	}
+
+	resp, err := handler.ServeIPP(ctx, req, data)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		resp = NewResponse(StatusOk, req.RequestId)
+	}
+
+	return resp, nil
+}
+
+// validateRequest checks the request header and the required operation attributes as described in RFC 8011 4.1.8
+func validateRequest(req *Request) error {
+	if req.ProtocolVersionMajor < 1 || req.ProtocolVersionMajor > 2 {
+		return IPPError{
+			Status:  StatusErrorVersionNotSupported,
+			Message: fmt.Sprintf("version %d.%d is not supported", req.ProtocolVersionMajor, req.ProtocolVersionMinor),
+		}
+	}
+
+	if req.RequestId <= 0 {
+		return IPPError{Status: StatusErrorBadRequest, Message: "request-id must be greater than zero"}
+	}
+
+	charset, ok := requestAttribute[string](req.OperationAttributes, AttributeCharset)
+	if !ok {
+		return IPPError{Status: StatusErrorBadRequest, Message: fmt.Sprintf("missing required attribute %s", AttributeCharset)}
+	}
+	if !supportedCharsets[strings.ToLower(charset)] {
+		return IPPError{Status: StatusErrorCharset, Message: fmt.Sprintf("charset %s is not supported", charset)}
+	}
+
+	if _, ok := requestAttribute[string](req.OperationAttributes, AttributeNaturalLanguage); !ok {
+		return IPPError{Status: StatusErrorBadRequest, Message: fmt.Sprintf("missing required attribute %s", AttributeNaturalLanguage)}
+	}
+
+	_, hasPrinterUri := req.OperationAttributes[AttributePrinterURI]
+	_, hasJobUri := req.OperationAttributes[AttributeJobURI]
+	if !hasPrinterUri && !hasJobUri && !untargetedOperations[req.Operation] {
+		return IPPError{Status: StatusErrorBadRequest, Message: fmt.Sprintf("missing required attribute %s", AttributePrinterURI)}
+	}
+
+	for _, name := range []string{AttributePrinterURI, AttributeJobURI} {
+		value, ok := req.OperationAttributes[name]
+		if !ok {
+			continue
+		}
+
+		if err := validateUri(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateUri(name string, value any) error {
+	raw, ok := value.(string)
+	if !ok {
+		return IPPError{Status: StatusErrorBadRequest, Message: fmt.Sprintf("attribute %s must have a single uri value", name)}
+	}
+
+	uri, err := url.Parse(raw)
+	if err != nil || uri.Host == "" {
+		return IPPError{Status: StatusErrorBadRequest, Message: fmt.Sprintf("attribute %s has an invalid uri %q", name, raw)}
+	}
+
+	if !supportedUriSchemes[strings.ToLower(uri.Scheme)] {
+		return IPPError{Status: StatusErrorUriScheme, Message: fmt.Sprintf("uri scheme %s is not supported", uri.Scheme)}
+	}
+
+	return nil
+}
+
+// newErrorResponse creates a response with the status code and message of err
+func newErrorResponse(req *Request, err error) *Response {
+	ippErr := IPPError{Status: StatusErrorInternal, Message: err.Error()}
+	errors.As(err, &ippErr)
+
+	resp := NewResponse(ippErr.Status, req.RequestId)
+	if ippErr.Message != "" {
+		resp.OperationAttributes[AttributeStatusMessage] = []Attribute{{Tag: TagText, Name: AttributeStatusMessage, Value: ippErr.Message}}
+	}
+
+	return resp
+}
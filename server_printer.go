@@ -0,0 +1,681 @@
+package ipp
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VirtualPrinterConfig configures a VirtualPrinter
+type VirtualPrinterConfig struct {
+	// Name is the printer-name, default "virtual-printer"
+	Name string
+	// SpoolDir is the directory the received documents and job attributes are written to
+	SpoolDir string
+	// Attributes override or extend the default printer attributes, e.g. to advertise other capabilities
+	Attributes Attributes
+	// ProcessingTime is the time a job stays in the processing state before it is completed
+	ProcessingTime time.Duration
+}
+
+// VirtualJob is a job received by a VirtualPrinter
+type VirtualJob struct {
+	ID         int
+	Name       string
+	User       string
+	State      int8
+	Attributes map[string]any
+	// Documents are the paths of the spooled documents
+	Documents []string
+	Created   time.Time
+
+	complete   bool
+	printerUri string
+	// spool is held while a document of the job is spooled, so concurrent Send-Document requests are added in order
+	spool *sync.Mutex
+}
+
+// VirtualPrinter is an IPP Everywhere printer which writes every received document and the job attributes to a spool
+// directory. it implements http.Handler, so it can be served by any http server
+type VirtualPrinter struct {
+	*Server
+
+	config  VirtualPrinterConfig
+	started time.Time
+
+	mu        sync.Mutex
+	jobs      map[int]*VirtualJob
+	nextJobID int
+	// paused printers accept jobs but do not process them until they are resumed
+	paused bool
+	// timers complete the processing jobs, they are removed once they fired
+	timers map[int]*time.Timer
+}
+
+// virtualPrinterOperations are the operations supported by a VirtualPrinter
+var virtualPrinterOperations = []int16{
+	OperationPrintJob,
+	OperationValidateJob,
+	OperationCreateJob,
+	OperationSendDocument,
+	OperationCancelJob,
+	OperationGetJobAttributes,
+	OperationGetJobs,
+	OperationGetPrinterAttributes,
+	OperationPausePrinter,
+	OperationResumePrinter,
+}
+
+// virtualDocumentExtensions maps document formats to the file extension of spooled documents
+var virtualDocumentExtensions = map[string]string{
+	MimeTypePDF:        "pdf",
+	MimeTypePostscript: "ps",
+	MimeTypePWGRaster:  "pwg",
+	MimeTypeURF:        "urf",
+	MimeTypeJPEG:       "jpg",
+	"text/plain":       "txt",
+}
+
+// NewVirtualPrinter creates a new virtual printer and its spool directory
+func NewVirtualPrinter(config VirtualPrinterConfig) (*VirtualPrinter, error) {
+	if config.Name == "" {
+		config.Name = "virtual-printer"
+	}
+	if config.SpoolDir == "" {
+		return nil, fmt.Errorf("spool directory is required")
+	}
+
+	if err := os.MkdirAll(config.SpoolDir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create spool directory: %w", err)
+	}
+
+	nextJobID, err := nextSpoolJobID(config.SpoolDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read spool directory: %w", err)
+	}
+
+	p := &VirtualPrinter{
+		Server:    NewServer(),
+		config:    config,
+		started:   time.Now(),
+		jobs:      make(map[int]*VirtualJob),
+		nextJobID: nextJobID,
+		timers:    make(map[int]*time.Timer),
+	}
+
+	p.HandleFunc(OperationPrintJob, p.printJob)
+	p.HandleFunc(OperationValidateJob, p.validateJob)
+	p.HandleFunc(OperationCreateJob, p.createJob)
+	p.HandleFunc(OperationSendDocument, p.sendDocument)
+	p.HandleFunc(OperationCancelJob, p.cancelJob)
+	p.HandleFunc(OperationGetJobAttributes, p.getJobAttributes)
+	p.HandleFunc(OperationGetJobs, p.getJobs)
+	p.HandleFunc(OperationGetPrinterAttributes, p.getPrinterAttributes)
+	p.HandleFunc(OperationPausePrinter, p.pausePrinter)
+	p.HandleFunc(OperationResumePrinter, p.resumePrinter)
+
+	return p, nil
+}
+
+// Job returns a copy of the job with the given id
+func (p *VirtualPrinter) Job(jobID int) (VirtualJob, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job, ok := p.jobs[jobID]
+	if !ok {
+		return VirtualJob{}, false
+	}
+	return *job, true
+}
+
+// Close stops the processing of all pending jobs
+func (p *VirtualPrinter) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, timer := range p.timers {
+		timer.Stop()
+		delete(p.timers, id)
+	}
+}
+
+// nextSpoolJobID returns the job id after the highest id of the jobs in the spool directory, so the ids of a
+// restarted printer do not overwrite spooled jobs
+func nextSpoolJobID(spoolDir string) (int, error) {
+	entries, err := os.ReadDir(spoolDir)
+	if err != nil {
+		return 0, err
+	}
+
+	next := 1
+	for _, entry := range entries {
+		var id int
+		if _, err := fmt.Sscanf(entry.Name(), "job-%d", &id); err == nil && id >= next {
+			next = id + 1
+		}
+	}
+
+	return next, nil
+}
+
+// Attributes returns the printer attributes. printerUri is advertised as printer-uri-supported if it is not configured
+func (p *VirtualPrinter) Attributes(printerUri string) Attributes {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.attributes(printerUri)
+}
+
+func (p *VirtualPrinter) attributes(printerUri string) Attributes {
+	state := PrinterStateIdle
+	queued := 0
+	for _, job := range p.jobs {
+		if job.State == JobStateProcessing {
+			state = PrinterStateProcessing
+		}
+		if job.State < JobStateCanceled {
+			queued++
+		}
+	}
+	reason := "none"
+	if p.paused {
+		state = PrinterStateStopped
+		reason = "paused"
+	}
+
+	operations := make([]int, 0, len(virtualPrinterOperations))
+	for _, op := range virtualPrinterOperations {
+		operations = append(operations, int(op))
+	}
+
+	formats := make([]string, 0, len(virtualDocumentExtensions)+1)
+	for format := range virtualDocumentExtensions {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	formats = append(formats, MimeTypeOctetStream)
+
+	defaults := map[string]any{
+		AttributePrinterName:                       p.config.Name,
+		AttributePrinterInfo:                       p.config.Name,
+		AttributePrinterMakeAndModel:               "Virtual IPP Everywhere Printer",
+		AttributePrinterUriSupported:               printerUri,
+		AttributeUriSecuritySupported:              "none",
+		AttributeUriAuthenticationSupported:        "none",
+		AttributePrinterState:                      int(state),
+		AttributePrinterStateReasons:               reason,
+		AttributePrinterIsAcceptingJobs:            true,
+		AttributeQueuedJobCount:                    queued,
+		AttributePrinterUpTime:                     int(time.Since(p.started).Seconds()) + 1,
+		AttributeOperationsSupported:               operations,
+		AttributeIppVersionsSupported:              []string{"1.1", "2.0"},
+		AttributeCharsetConfigured:                 Charset,
+		AttributeCharsetSupported:                  []string{Charset, "us-ascii"},
+		AttributeNaturalLanguageConfigured:         CharsetLanguage,
+		AttributeGeneratedNaturalLanguageSupported: CharsetLanguage,
+		AttributeDocumentFormatSupported:           formats,
+		AttributeDocumentFormatDefault:             MimeTypeOctetStream,
+		AttributePdlOverrideSupported:              "attempted",
+		AttributeCompressionSupported:              []string{CompressionNone, CompressionGzip, CompressionDeflate},
+		AttributeMediaSupported:                    []string{"iso_a4_210x297mm", "na_letter_8.5x11in"},
+		AttributeMediaDefault:                      "iso_a4_210x297mm",
+		AttributeSidesSupported:                    []string{"one-sided", "two-sided-long-edge", "two-sided-short-edge"},
+		AttributeSidesDefault:                      "one-sided",
+		AttributeCopiesSupported:                   []int32{1, 999},
+		AttributeCopiesDefault:                     1,
+	}
+
+	attributes := make(Attributes, len(defaults)+len(p.config.Attributes))
+	for name, value := range defaults {
+		if name == AttributeCopiesSupported {
+			attributes[name] = []Attribute{{Tag: TagRange, Name: name, Value: value}}
+			continue
+		}
+		attributes[name] = newAttribute(name, value)
+	}
+	for name, values := range p.config.Attributes {
+		attributes[name] = values
+	}
+
+	return attributes
+}
+
+func (p *VirtualPrinter) printJob(ctx context.Context, req *Request, _ io.Writer) (*Response, error) {
+	if err := p.validateDocument(req); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	job := p.newJob(ctx, req)
+	job.spool.Lock()
+	p.mu.Unlock()
+	defer job.spool.Unlock()
+
+	if err := p.spoolDocument(job, req); err != nil {
+		p.abortJob(job)
+		return nil, err
+	}
+
+	return p.finishDocument(req, job, true)
+}
+
+func (p *VirtualPrinter) validateJob(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+	if err := p.validateDocument(req); err != nil {
+		return nil, err
+	}
+	return NewResponse(StatusOk, req.RequestId), nil
+}
+
+func (p *VirtualPrinter) createJob(ctx context.Context, req *Request, _ io.Writer) (*Response, error) {
+	if err := p.validateDocument(req); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job := p.newJob(ctx, req)
+	if err := p.writeJobAttributes(job); err != nil {
+		return nil, err
+	}
+
+	resp := NewResponse(StatusOk, req.RequestId)
+	resp.JobAttributes = append(resp.JobAttributes, job.attributes())
+	return resp, nil
+}
+
+func (p *VirtualPrinter) sendDocument(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+	if err := p.validateDocument(req); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	job, err := p.jobFromRequest(req)
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	job.spool.Lock()
+	defer job.spool.Unlock()
+
+	p.mu.Lock()
+	if job.complete || job.State >= JobStateProcessing {
+		err = IPPError{Status: StatusErrorNotPossible, Message: fmt.Sprintf("Job #%d does not accept further documents.", job.ID)}
+	}
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.spoolDocument(job, req); err != nil {
+		p.abortJob(job)
+		return nil, err
+	}
+
+	last, _ := requestAttribute[bool](req.OperationAttributes, AttributeLastDocument)
+	return p.finishDocument(req, job, last)
+}
+
+func (p *VirtualPrinter) cancelJob(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job, err := p.jobFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.State >= JobStateCanceled {
+		return nil, IPPError{Status: StatusErrorNotPossible, Message: fmt.Sprintf("Job #%d is already finished.", job.ID)}
+	}
+
+	job.State = JobStateCanceled
+	if timer, ok := p.timers[job.ID]; ok {
+		timer.Stop()
+		delete(p.timers, job.ID)
+	}
+	if err := p.writeJobAttributes(job); err != nil {
+		return nil, err
+	}
+
+	return NewResponse(StatusOk, req.RequestId), nil
+}
+
+func (p *VirtualPrinter) getJobAttributes(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job, err := p.jobFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := NewResponse(StatusOk, req.RequestId)
+	resp.JobAttributes = append(resp.JobAttributes, filterAttributes(job.attributes(), req))
+	return resp, nil
+}
+
+func (p *VirtualPrinter) getJobs(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+	whichJobs, _ := requestAttribute[string](req.OperationAttributes, AttributeWhichJobs)
+	myJobs, _ := requestAttribute[bool](req.OperationAttributes, AttributeMyJobs)
+	user, _ := requestAttribute[string](req.OperationAttributes, AttributeRequestingUserName)
+	limit, _ := requestAttribute[int](req.OperationAttributes, AttributeLimit)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ids := make([]int, 0, len(p.jobs))
+	for id := range p.jobs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	resp := NewResponse(StatusOk, req.RequestId)
+	for _, id := range ids {
+		job := p.jobs[id]
+
+		if myJobs && job.User != user {
+			continue
+		}
+
+		completed := job.State >= JobStateCanceled
+		if whichJobs == JobStateFilterCompleted && !completed || (whichJobs == "" || whichJobs == JobStateFilterNotCompleted) && completed {
+			continue
+		}
+
+		resp.JobAttributes = append(resp.JobAttributes, filterAttributes(job.attributes(), req))
+		if limit > 0 && len(resp.JobAttributes) == limit {
+			break
+		}
+	}
+
+	return resp, nil
+}
+
+func (p *VirtualPrinter) getPrinterAttributes(ctx context.Context, req *Request, _ io.Writer) (*Response, error) {
+	resp := NewResponse(StatusOk, req.RequestId)
+	resp.PrinterAttributes = append(resp.PrinterAttributes, filterAttributes(p.Attributes(requestPrinterUri(ctx, req)), req))
+	return resp, nil
+}
+
+// requestPrinterUri returns the uri the printer was addressed with, the printer-uri of the request is only used if
+// the request was not received over http
+func requestPrinterUri(ctx context.Context, req *Request) string {
+	printerUri, _ := requestAttribute[string](req.OperationAttributes, AttributePrinterURI)
+	if r, ok := HTTPRequestFromContext(ctx); ok {
+		scheme := "ipp"
+		if r.TLS != nil {
+			scheme = "ipps"
+		}
+		printerUri = fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.Path)
+	}
+
+	return printerUri
+}
+
+func (p *VirtualPrinter) pausePrinter(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+
+	return NewResponse(StatusOk, req.RequestId), nil
+}
+
+// resumePrinter starts processing the jobs which were received while the printer was paused
+func (p *VirtualPrinter) resumePrinter(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused {
+		p.paused = false
+
+		ids := make([]int, 0, len(p.jobs))
+		for id, job := range p.jobs {
+			if job.complete && job.State == JobStatePending {
+				ids = append(ids, id)
+			}
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			p.processJob(p.jobs[id])
+			_ = p.writeJobAttributes(p.jobs[id])
+		}
+	}
+
+	return NewResponse(StatusOk, req.RequestId), nil
+}
+
+// validateDocument checks if a job with the requested document format and compression can be created
+func (p *VirtualPrinter) validateDocument(req *Request) error {
+	if format, ok := requestAttribute[string](req.OperationAttributes, AttributeDocumentFormat); ok && format != MimeTypeOctetStream {
+		if _, supported := virtualDocumentExtensions[format]; !supported {
+			return IPPError{Status: StatusErrorDocumentFormatNotSupported, Message: fmt.Sprintf("Document format %s is not supported.", format)}
+		}
+	}
+
+	if compression, ok := requestAttribute[string](req.OperationAttributes, AttributeCompression); ok {
+		switch compression {
+		case CompressionNone, CompressionGzip, CompressionDeflate:
+		default:
+			return IPPError{Status: statusErrorCompressionNotSupported, Message: fmt.Sprintf("Compression %s is not supported.", compression)}
+		}
+	}
+
+	return nil
+}
+
+// newJob creates a pending job from the request, p.mu must be held
+func (p *VirtualPrinter) newJob(ctx context.Context, req *Request) *VirtualJob {
+	job := &VirtualJob{
+		ID:         p.nextJobID,
+		State:      JobStatePending,
+		Attributes: make(map[string]any, len(req.JobAttributes)),
+		Created:    time.Now(),
+		printerUri: strings.TrimSuffix(requestPrinterUri(ctx, req), "/"),
+		spool:      new(sync.Mutex),
+	}
+	p.nextJobID++
+
+	job.Name, _ = requestAttribute[string](req.OperationAttributes, AttributeJobName)
+	job.User, _ = requestAttribute[string](req.OperationAttributes, AttributeRequestingUserName)
+	if r, ok := HTTPRequestFromContext(ctx); ok && job.User == "" {
+		job.User, _, _ = r.BasicAuth()
+	}
+	for name, value := range req.JobAttributes {
+		job.Attributes[name] = value
+	}
+
+	p.jobs[job.ID] = job
+	return job
+}
+
+// spoolDocument writes the decompressed document of the request to the spool directory, job.spool must be held
+func (p *VirtualPrinter) spoolDocument(job *VirtualJob, req *Request) error {
+	if req.File == nil {
+		return IPPError{Status: StatusErrorBadRequest, Message: "The request contains no document."}
+	}
+
+	reader := req.File
+	switch req.OperationAttributes[AttributeCompression] {
+	case CompressionGzip:
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return IPPError{Status: statusErrorCompressionError, Message: fmt.Sprintf("Unable to decompress document: %v", err)}
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case CompressionDeflate:
+		flateReader := flate.NewReader(reader)
+		defer flateReader.Close()
+		reader = flateReader
+	}
+
+	format, _ := requestAttribute[string](req.OperationAttributes, AttributeDocumentFormat)
+	extension, ok := virtualDocumentExtensions[format]
+	if !ok {
+		extension = "bin"
+	}
+
+	p.mu.Lock()
+	path := filepath.Join(p.config.SpoolDir, fmt.Sprintf("job-%d-document-%d.%s", job.ID, len(job.Documents)+1, extension))
+	p.mu.Unlock()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create spool file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, reader); err != nil {
+		return IPPError{Status: statusErrorDocumentAccessError, Message: fmt.Sprintf("Unable to read document: %v", err)}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// the document is only listed once it is completely written
+	p.mu.Lock()
+	job.Documents = append(job.Documents, path)
+	p.mu.Unlock()
+
+	return nil
+}
+
+// finishDocument starts processing the job if the last document was received
+func (p *VirtualPrinter) finishDocument(req *Request, job *VirtualJob, last bool) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if last && job.State == JobStatePending {
+		job.complete = true
+		if !p.paused {
+			p.processJob(job)
+		}
+	}
+
+	if err := p.writeJobAttributes(job); err != nil {
+		return nil, err
+	}
+
+	resp := NewResponse(StatusOk, req.RequestId)
+	resp.JobAttributes = append(resp.JobAttributes, job.attributes())
+	return resp, nil
+}
+
+// processJob moves the job to processing and completes it after the configured processing time, p.mu must be held
+func (p *VirtualPrinter) processJob(job *VirtualJob) {
+	if p.config.ProcessingTime <= 0 {
+		job.State = JobStateCompleted
+		return
+	}
+
+	job.State = JobStateProcessing
+	p.timers[job.ID] = time.AfterFunc(p.config.ProcessingTime, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		delete(p.timers, job.ID)
+		if job.State == JobStateProcessing {
+			job.State = JobStateCompleted
+			_ = p.writeJobAttributes(job)
+		}
+	})
+}
+
+func (p *VirtualPrinter) abortJob(job *VirtualJob) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job.State = JobStateAborted
+	_ = p.writeJobAttributes(job)
+}
+
+// writeJobAttributes writes the job attributes as json to the spool directory, p.mu must be held
+func (p *VirtualPrinter) writeJobAttributes(job *VirtualJob) error {
+	attributes := job.attributes()
+
+	values := make(map[string]any, len(attributes))
+	for name, attrs := range attributes {
+		if len(attrs) == 1 {
+			values[name] = attrs[0].Value
+			continue
+		}
+
+		list := make([]any, 0, len(attrs))
+		for _, attr := range attrs {
+			list = append(list, attr.Value)
+		}
+		values[name] = list
+	}
+	values["documents"] = job.Documents
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(p.config.SpoolDir, fmt.Sprintf("job-%d.json", job.ID)), data, 0o644)
+}
+
+// jobFromRequest returns the job addressed by job-id or job-uri, p.mu must be held
+func (p *VirtualPrinter) jobFromRequest(req *Request) (*VirtualJob, error) {
+	jobID, ok := requestAttribute[int](req.OperationAttributes, AttributeJobID)
+	if !ok {
+		jobID, _ = strconv.Atoi(lastPathElement(req.OperationAttributes[AttributeJobURI]))
+	}
+
+	job, ok := p.jobs[jobID]
+	if !ok {
+		return nil, IPPError{Status: StatusErrorNotFound, Message: fmt.Sprintf("Job #%d does not exist.", jobID)}
+	}
+
+	return job, nil
+}
+
+func (j *VirtualJob) attributes() Attributes {
+	reason := "none"
+	switch j.State {
+	case JobStatePending:
+		if !j.complete {
+			reason = "job-incoming"
+		}
+	case JobStateCanceled:
+		reason = "job-canceled-by-user"
+	case JobStateAborted:
+		reason = "aborted-by-system"
+	case JobStateCompleted:
+		reason = "job-completed-successfully"
+	}
+
+	attributes := Attributes{
+		AttributeJobID:                  newAttribute(AttributeJobID, j.ID),
+		AttributeJobURI:                 newAttribute(AttributeJobURI, fmt.Sprintf("%s/%d", j.printerUri, j.ID)),
+		AttributeJobPrinterURI:          newAttribute(AttributeJobPrinterURI, j.printerUri),
+		AttributeJobState:               newAttribute(AttributeJobState, int(j.State)),
+		AttributeJobStateReasons:        []Attribute{{Tag: TagKeyword, Name: AttributeJobStateReasons, Value: reason}},
+		AttributeJobName:                newAttribute(AttributeJobName, j.Name),
+		AttributeJobOriginatingUserName: newAttribute(AttributeJobOriginatingUserName, j.User),
+		AttributeNumberOfDocuments:      newAttribute(AttributeNumberOfDocuments, len(j.Documents)),
+	}
+
+	for name, value := range j.Attributes {
+		if _, ok := attributes[name]; !ok {
+			attributes[name] = newAttribute(name, value)
+		}
+	}
+
+	return attributes
+}
@@ -0,0 +1,274 @@
+package ipp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVirtualPrinter(t *testing.T) {
+	spool := t.TempDir()
+	printer, err := NewVirtualPrinter(VirtualPrinterConfig{
+		SpoolDir:       spool,
+		ProcessingTime: 50 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+	t.Cleanup(printer.Close)
+
+	client := NewIPPClientWithAdapter("user", newTestHttpAdapter(t, printer.ServeHTTP))
+
+	attributes, err := client.GetPrinterAttributes("virtual-printer", []string{AttributePrinterName, AttributeCompressionSupported})
+	assert.Nil(t, err)
+	assert.Equal(t, "virtual-printer", attributes[AttributePrinterName][0].Value)
+	assert.Len(t, attributes[AttributeCompressionSupported], 3)
+
+	jobID, err := client.PrintJob(Document{
+		Document: bytes.NewBufferString("%PDF-1.4"),
+		Size:     8,
+		Name:     "test.pdf",
+		MimeType: MimeTypePDF,
+	}, "virtual-printer", map[string]any{AttributeCopies: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, jobID)
+
+	job, ok := printer.Job(jobID)
+	assert.True(t, ok)
+	assert.Equal(t, JobStateProcessing, job.State)
+	assert.Equal(t, "user", job.User)
+
+	document, err := os.ReadFile(filepath.Join(spool, "job-1-document-1.pdf"))
+	assert.Nil(t, err)
+	assert.Equal(t, "%PDF-1.4", string(document))
+
+	assert.Eventually(t, func() bool {
+		job, _ := printer.Job(jobID)
+		return job.State == JobStateCompleted
+	}, time.Second, 10*time.Millisecond)
+
+	data, err := os.ReadFile(filepath.Join(spool, "job-1.json"))
+	assert.Nil(t, err)
+	var spooled map[string]any
+	assert.Nil(t, json.Unmarshal(data, &spooled))
+	assert.Equal(t, float64(JobStateCompleted), spooled[AttributeJobState])
+	assert.Equal(t, float64(2), spooled[AttributeCopies])
+
+	jobID, err = client.PrintDocuments([]Document{
+		{Document: bytes.NewBufferString("first"), Size: 5, Name: "first.txt", MimeType: "text/plain"},
+		{Document: bytes.NewBufferString("second"), Size: 6, Name: "second.txt", MimeType: "text/plain", Compression: CompressionGzip},
+	}, "virtual-printer", map[string]any{})
+	assert.Nil(t, err)
+
+	job, _ = printer.Job(jobID)
+	assert.Len(t, job.Documents, 2)
+	document, err = os.ReadFile(job.Documents[1])
+	assert.Nil(t, err)
+	assert.Equal(t, "second", string(document))
+
+	_, err = client.PrintJob(Document{
+		Document: bytes.NewBufferString("test"),
+		Size:     4,
+		Name:     "test.doc",
+		MimeType: "application/msword",
+	}, "virtual-printer", map[string]any{})
+	assert.True(t, errors.Is(err, ErrDocumentFormat))
+
+	assert.Nil(t, client.CancelJob(jobID, false))
+	assert.NotNil(t, client.CancelJob(jobID, false))
+
+	assert.Nil(t, client.PausePrinter("virtual-printer"))
+	jobID, err = client.PrintJob(Document{
+		Document: bytes.NewBufferString("test"),
+		Size:     4,
+		Name:     "test.txt",
+		MimeType: "text/plain",
+	}, "virtual-printer", map[string]any{})
+	assert.Nil(t, err)
+
+	attributes, err = client.GetPrinterAttributes("virtual-printer", []string{AttributePrinterState, AttributePrinterIsAcceptingJobs})
+	assert.Nil(t, err)
+	assert.Equal(t, int(PrinterStateStopped), attributes[AttributePrinterState][0].Value)
+	assert.Equal(t, true, attributes[AttributePrinterIsAcceptingJobs][0].Value)
+
+	job, _ = printer.Job(jobID)
+	assert.Equal(t, JobStatePending, job.State)
+
+	assert.Nil(t, client.ResumePrinter("virtual-printer"))
+	assert.Eventually(t, func() bool {
+		job, _ := printer.Job(jobID)
+		return job.State == JobStateCompleted
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestVirtualPrinter_CompressionError(t *testing.T) {
+	printer, err := NewVirtualPrinter(VirtualPrinterConfig{SpoolDir: t.TempDir()})
+	assert.Nil(t, err)
+	t.Cleanup(printer.Close)
+
+	adapter := newTestHttpAdapter(t, printer.ServeHTTP)
+	client := NewIPPClientWithAdapter("user", adapter)
+
+	req := NewRequest(OperationPrintJob, 1)
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/virtual-printer"
+	req.OperationAttributes[AttributeDocumentFormat] = "text/plain"
+	req.OperationAttributes[AttributeCompression] = CompressionGzip
+	req.File = bytes.NewBufferString("not compressed")
+	req.FileSize = 14
+
+	_, err = client.SendRequest(adapter.GetHttpUri("printers", "virtual-printer"), req, nil)
+	var ippErr IPPError
+	assert.True(t, errors.As(err, &ippErr))
+	assert.Equal(t, int16(0x0410), ippErr.Status)
+
+	req.OperationAttributes[AttributeCompression] = "compress"
+	req.File = bytes.NewBufferString("not compressed")
+	_, err = client.SendRequest(adapter.GetHttpUri("printers", "virtual-printer"), req, nil)
+	assert.True(t, errors.As(err, &ippErr))
+	assert.Equal(t, int16(0x040f), ippErr.Status)
+}
+
+func TestVirtualPrinter_ConcurrentSendDocument(t *testing.T) {
+	printer, err := NewVirtualPrinter(VirtualPrinterConfig{SpoolDir: t.TempDir()})
+	assert.Nil(t, err)
+	t.Cleanup(printer.Close)
+
+	adapter := newTestHttpAdapter(t, printer.ServeHTTP)
+	client := NewIPPClientWithAdapter("user", adapter)
+	url := adapter.GetHttpUri("printers", "virtual-printer")
+
+	req := NewRequest(OperationCreateJob, 1)
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/virtual-printer"
+	resp, err := client.SendRequest(url, req, nil)
+	assert.Nil(t, err)
+	jobID := resp.JobAttributes[0][AttributeJobID][0].Value.(int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req := NewRequest(OperationSendDocument, 0)
+			req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/virtual-printer"
+			req.OperationAttributes[AttributeJobID] = jobID
+			req.OperationAttributes[AttributeDocumentFormat] = "text/plain"
+			req.OperationAttributes[AttributeLastDocument] = false
+			req.File = bytes.NewBufferString("document")
+			req.FileSize = 8
+
+			_, err := client.SendRequest(url, req, nil)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	job, ok := printer.Job(jobID)
+	assert.True(t, ok)
+	assert.Len(t, job.Documents, 10)
+	for i, path := range job.Documents {
+		assert.Equal(t, fmt.Sprintf("job-%d-document-%d.txt", jobID, i+1), filepath.Base(path))
+		document, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, "document", string(document))
+	}
+}
+
+func TestVirtualPrinter_SendDocumentInFlight(t *testing.T) {
+	printer, err := NewVirtualPrinter(VirtualPrinterConfig{SpoolDir: t.TempDir()})
+	assert.Nil(t, err)
+	t.Cleanup(printer.Close)
+
+	adapter := newTestHttpAdapter(t, printer.ServeHTTP)
+	client := NewIPPClientWithAdapter("user", adapter)
+	url := adapter.GetHttpUri("printers", "virtual-printer")
+
+	req := NewRequest(OperationCreateJob, 1)
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/virtual-printer"
+	resp, err := client.SendRequest(url, req, nil)
+	assert.Nil(t, err)
+	jobID := resp.JobAttributes[0][AttributeJobID][0].Value.(int)
+
+	sendDocument := func(document io.Reader, last bool) chan error {
+		req := NewRequest(OperationSendDocument, 0)
+		req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/virtual-printer"
+		req.OperationAttributes[AttributeJobID] = jobID
+		req.OperationAttributes[AttributeDocumentFormat] = "text/plain"
+		req.OperationAttributes[AttributeLastDocument] = last
+		req.File = document
+		req.FileSize = -1
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := client.SendRequest(url, req, nil)
+			done <- err
+		}()
+		return done
+	}
+
+	reader, writer := io.Pipe()
+	first := sendDocument(reader, false)
+	_, err = writer.Write([]byte("first "))
+	assert.Nil(t, err)
+
+	second := sendDocument(bytes.NewBufferString("second"), true)
+	time.Sleep(50 * time.Millisecond)
+
+	// the first document is still being written, so it is not listed and the last document has to wait for it
+	job, _ := printer.Job(jobID)
+	assert.Empty(t, job.Documents)
+	assert.Equal(t, JobStatePending, job.State)
+
+	_, err = writer.Write([]byte("document"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	assert.Nil(t, <-first)
+	assert.Nil(t, <-second)
+
+	job, _ = printer.Job(jobID)
+	assert.Len(t, job.Documents, 2)
+	document, err := os.ReadFile(job.Documents[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "first document", string(document))
+}
+
+func TestVirtualPrinter_JobUri(t *testing.T) {
+	spool := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(spool, "job-41.json"), []byte("{}"), 0o644))
+
+	printer, err := NewVirtualPrinter(VirtualPrinterConfig{SpoolDir: spool, ProcessingTime: time.Millisecond})
+	assert.Nil(t, err)
+	t.Cleanup(printer.Close)
+
+	adapter := newTestHttpAdapter(t, printer.ServeHTTP)
+	client := NewIPPClientWithAdapter("user", adapter)
+
+	jobID, err := client.PrintJob(Document{
+		Document: bytes.NewBufferString("test"),
+		Size:     4,
+		Name:     "test.txt",
+		MimeType: "text/plain",
+	}, "virtual-printer", map[string]any{})
+	assert.Nil(t, err)
+	assert.Equal(t, 42, jobID)
+
+	attributes, err := client.GetJobAttributes(jobID, []string{AttributeJobURI, AttributeJobPrinterURI})
+	assert.Nil(t, err)
+	printerUri := "ipp://" + strings.TrimPrefix(adapter.GetHttpUri("printers", "virtual-printer"), "http://")
+	assert.Equal(t, printerUri+"/42", attributes[AttributeJobURI][0].Value)
+	assert.Equal(t, printerUri, attributes[AttributeJobPrinterURI][0].Value)
+
+	assert.Eventually(t, func() bool {
+		printer.mu.Lock()
+		defer printer.mu.Unlock()
+		return len(printer.timers) == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,207 @@
+package ipp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RequestRewriter modifies a request before it is forwarded by a Proxy. returning an error rejects the request, an
+// IPPError is sent to the client with its status code
+type RequestRewriter func(ctx context.Context, req *Request) error
+
+// ResponseRewriter modifies the response of the backend before it is returned to the client by a Proxy
+type ResponseRewriter func(ctx context.Context, req *Request, resp *Response) error
+
+// jobCreationOperations are the operations which carry job template attributes
+var jobCreationOperations = map[int16]bool{
+	OperationPrintJob:    true,
+	OperationPrintUri:    true,
+	OperationValidateJob: true,
+	OperationCreateJob:   true,
+}
+
+// Proxy is a Server which forwards all requests through an Adapter to a backend printer or cups server.
+// requests and responses can be rewritten by callbacks, single operations can be overridden with Handle
+type Proxy struct {
+	*Server
+
+	adapter           Adapter
+	requestRewriters  []RequestRewriter
+	responseRewriters []ResponseRewriter
+}
+
+// NewProxy creates a new proxy which forwards requests to the backend of the given adapter.
+// the path of the http request is kept, so a request to /printers/foo is sent to /printers/foo of the backend
+func NewProxy(adapter Adapter) *Proxy {
+	p := &Proxy{
+		Server:  NewServer(),
+		adapter: adapter,
+	}
+	p.HandleDefault(p)
+
+	return p
+}
+
+// RewriteRequest adds request rewriters, they are called in the given order
+func (p *Proxy) RewriteRequest(rewriters ...RequestRewriter) {
+	p.requestRewriters = append(p.requestRewriters, rewriters...)
+}
+
+// RewriteResponse adds response rewriters, they are called in the given order
+func (p *Proxy) RewriteResponse(rewriters ...ResponseRewriter) {
+	p.responseRewriters = append(p.responseRewriters, rewriters...)
+}
+
+// ServeIPP rewrites and forwards the request to the backend
+func (p *Proxy) ServeIPP(ctx context.Context, req *Request, additionalResponseData io.Writer) (*Response, error) {
+	for _, rewrite := range p.requestRewriters {
+		if err := rewrite(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	// a request which cannot be encoded again is not a failure of the backend and must not be retried by the client
+	if _, err := req.Encode(); err != nil {
+		return nil, IPPError{Status: StatusErrorInternal, Message: fmt.Sprintf("unable to encode request: %v", err)}
+	}
+
+	resp, err := p.adapter.SendRequestContext(ctx, p.backendUrl(ctx, req), req, additionalResponseData)
+	if err != nil {
+		return nil, proxyError(err)
+	}
+
+	for _, rewrite := range p.responseRewriters {
+		if err := rewrite(ctx, req, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// backendUrl returns the http url of the backend with the path of the original request
+func (p *Proxy) backendUrl(ctx context.Context, req *Request) string {
+	path := "/"
+	if r, ok := HTTPRequestFromContext(ctx); ok {
+		path = r.URL.Path
+	} else if printerUri, ok := req.OperationAttributes[AttributePrinterURI].(string); ok {
+		if uri, err := url.Parse(printerUri); err == nil && uri.Path != "" {
+			path = uri.Path
+		}
+	}
+
+	return strings.TrimSuffix(p.adapter.GetHttpUri("", nil), "/") + path
+}
+
+// proxyError converts errors of the backend into ipp errors for the client
+func proxyError(err error) error {
+	var ippErr IPPError
+	if errors.As(err, &ippErr) {
+		return ippErr
+	}
+
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.Code {
+		case http.StatusUnauthorized:
+			return IPPError{Status: StatusErrorNotAuthenticated, Message: "backend requires authentication"}
+		case http.StatusForbidden:
+			return IPPError{Status: StatusErrorForbidden, Message: "backend denied access"}
+		case http.StatusNotFound:
+			return IPPError{Status: StatusErrorNotFound, Message: "backend printer not found"}
+		}
+	}
+
+	return IPPError{Status: StatusErrorServiceUnavailable, Message: fmt.Sprintf("backend unavailable: %v", err)}
+}
+
+// SetJobAttribute forces a job template attribute on all job creating requests, e.g. sides to force duplex printing
+func SetJobAttribute(name string, value any) RequestRewriter {
+	return func(_ context.Context, req *Request) error {
+		if jobCreationOperations[req.Operation] {
+			req.JobAttributes[name] = value
+		}
+		return nil
+	}
+}
+
+// LimitJobAttribute caps an integer job template attribute like copies to max. requests with non integer values of
+// the attribute are rejected, so the limit cannot be bypassed by sending the attribute with another tag
+func LimitJobAttribute(name string, max int) RequestRewriter {
+	invalid := IPPError{Status: StatusErrorAttributesOrValues, Message: fmt.Sprintf("%s must be an integer", name)}
+
+	return func(_ context.Context, req *Request) error {
+		switch v := req.JobAttributes[name].(type) {
+		case nil:
+		case int:
+			req.JobAttributes[name] = min(v, max)
+		case []int:
+			limited := make([]int, len(v))
+			for i, value := range v {
+				limited[i] = min(value, max)
+			}
+			req.JobAttributes[name] = limited
+		case []Attribute:
+			// the attribute was sent with another tag than the one of AttributeTagMapping
+			limited := make([]Attribute, len(v))
+			for i, attr := range v {
+				value, ok := attr.Value.(int)
+				if !ok {
+					return invalid
+				}
+				limited[i] = attr
+				limited[i].Value = min(value, max)
+			}
+			req.JobAttributes[name] = limited
+		default:
+			return invalid
+		}
+		return nil
+	}
+}
+
+// RemoveAttributes removes operation and job attributes from all requests, e.g. job-name for privacy
+func RemoveAttributes(names ...string) RequestRewriter {
+	return func(_ context.Context, req *Request) error {
+		for _, name := range names {
+			delete(req.OperationAttributes, name)
+			delete(req.JobAttributes, name)
+		}
+		return nil
+	}
+}
+
+// RewriteResponseURIs replaces the prefix from with to in all uri attributes of a response, e.g. to replace the
+// backend address in printer-uri-supported and job-uri with the address of the proxy
+func RewriteResponseURIs(from, to string) ResponseRewriter {
+	rewrite := func(attributes Attributes) {
+		for _, values := range attributes {
+			for i, attr := range values {
+				uri, ok := attr.Value.(string)
+				if !ok || !strings.HasPrefix(uri, from) {
+					continue
+				}
+
+				if attr.Tag == TagUri || attr.Tag == 0 && AttributeTagMapping[attr.Name] == TagUri {
+					values[i].Value = to + strings.TrimPrefix(uri, from)
+				}
+			}
+		}
+	}
+
+	return func(_ context.Context, _ *Request, resp *Response) error {
+		rewrite(resp.OperationAttributes)
+		for _, attributes := range resp.PrinterAttributes {
+			rewrite(attributes)
+		}
+		for _, attributes := range resp.JobAttributes {
+			rewrite(attributes)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,116 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy(t *testing.T) {
+	printer, err := NewVirtualPrinter(VirtualPrinterConfig{SpoolDir: t.TempDir()})
+	assert.Nil(t, err)
+	t.Cleanup(printer.Close)
+
+	backend := newTestHttpAdapter(t, printer.ServeHTTP)
+	backendHost := strings.TrimPrefix(backend.GetHttpUri("", nil), "http://")
+
+	proxy := NewProxy(backend)
+	proxy.RewriteRequest(
+		SetJobAttribute(AttributeSides, "two-sided-long-edge"),
+		LimitJobAttribute(AttributeCopies, 5),
+		RemoveAttributes(AttributeJobName),
+	)
+	proxy.RewriteResponse(RewriteResponseURIs("ipp://"+backendHost, "ipp://proxy.local"))
+	proxy.HandleFunc(OperationPausePrinter, func(ctx context.Context, req *Request, _ io.Writer) (*Response, error) {
+		return nil, IPPError{Status: StatusErrorForbidden, Message: "pausing is not allowed"}
+	})
+
+	client := NewIPPClientWithAdapter("user", newTestHttpAdapter(t, proxy.ServeHTTP))
+
+	jobID, err := client.PrintJob(Document{
+		Document: bytes.NewBufferString("%PDF-1.4"),
+		Size:     8,
+		Name:     "secret.pdf",
+		MimeType: MimeTypePDF,
+	}, "virtual-printer", map[string]any{AttributeCopies: 100})
+	assert.Nil(t, err)
+
+	job, ok := printer.Job(jobID)
+	assert.True(t, ok)
+	assert.Equal(t, "", job.Name)
+	assert.Equal(t, 5, job.Attributes[AttributeCopies])
+	assert.Equal(t, "two-sided-long-edge", job.Attributes[AttributeSides])
+
+	attributes, err := client.GetPrinterAttributes("virtual-printer", []string{AttributePrinterUriSupported})
+	assert.Nil(t, err)
+	assert.Equal(t, "ipp://proxy.local/printers/virtual-printer", attributes[AttributePrinterUriSupported][0].Value)
+
+	err = client.PausePrinter("virtual-printer")
+	assert.True(t, errors.Is(err, ErrNotAuthorized))
+
+	err = client.CancelJob(42, false)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestProxy_KeepsAttributeTags(t *testing.T) {
+	var forwarded *Request
+	backend := NewServer()
+	backend.HandleDefault(HandlerFunc(func(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+		forwarded = req
+		return nil, nil
+	}))
+
+	proxy := NewProxy(newTestHttpAdapter(t, backend.ServeHTTP))
+	client := NewIPPClientWithAdapter("user", newTestHttpAdapter(t, proxy.ServeHTTP))
+
+	req := NewRequest(OperationValidateJob, 1)
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/test"
+	req.OperationAttributes["ipp-attribute-fidelity"] = []Attribute{{Tag: TagBoolean, Value: true}}
+	req.JobAttributes[AttributeJobHoldUntil] = []Attribute{{Tag: TagName, Value: "tonight"}}
+
+	_, err := client.SendRequest(client.adapter.GetHttpUri("printers", "test"), req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Attribute{{Tag: TagBoolean, Name: "ipp-attribute-fidelity", Value: true}}, forwarded.OperationAttributes["ipp-attribute-fidelity"])
+	assert.Equal(t, []Attribute{{Tag: TagName, Name: AttributeJobHoldUntil, Value: "tonight"}}, forwarded.JobAttributes[AttributeJobHoldUntil])
+
+	proxy.RewriteRequest(SetJobAttribute("unknown-attribute", "value"))
+	_, err = client.SendRequest(client.adapter.GetHttpUri("printers", "test"), req, nil)
+	var ippErr IPPError
+	assert.True(t, errors.As(err, &ippErr))
+	assert.Equal(t, StatusErrorInternal, ippErr.Status)
+	assert.False(t, IsRetryable(err))
+}
+
+func TestProxy_LimitJobAttribute(t *testing.T) {
+	var forwarded *Request
+	backend := NewServer()
+	backend.HandleDefault(HandlerFunc(func(_ context.Context, req *Request, _ io.Writer) (*Response, error) {
+		forwarded = req
+		return nil, nil
+	}))
+
+	proxy := NewProxy(newTestHttpAdapter(t, backend.ServeHTTP))
+	proxy.RewriteRequest(LimitJobAttribute(AttributeCopies, 5))
+	client := NewIPPClientWithAdapter("user", newTestHttpAdapter(t, proxy.ServeHTTP))
+
+	req := NewRequest(OperationValidateJob, 1)
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/test"
+	req.JobAttributes[AttributeCopies] = []Attribute{{Tag: TagEnum, Value: 100}}
+
+	_, err := client.SendRequest(client.adapter.GetHttpUri("printers", "test"), req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Attribute{{Tag: TagEnum, Name: AttributeCopies, Value: 5}}, forwarded.JobAttributes[AttributeCopies])
+
+	forwarded = nil
+	req.JobAttributes[AttributeCopies] = []Attribute{{Tag: TagText, Value: "100"}}
+	_, err = client.SendRequest(client.adapter.GetHttpUri("printers", "test"), req, nil)
+	var ippErr IPPError
+	assert.True(t, errors.As(err, &ippErr))
+	assert.Equal(t, StatusErrorAttributesOrValues, ippErr.Status)
+	assert.Nil(t, forwarded)
+}
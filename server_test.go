@@ -0,0 +1,103 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_ServeHTTP(t *testing.T) {
+	server := NewServer()
+	server.HandleFunc(OperationPrintJob, func(ctx context.Context, req *Request, _ io.Writer) (*Response, error) {
+		document, err := io.ReadAll(req.File)
+		if err != nil {
+			return nil, err
+		}
+		if string(document) != "test document" {
+			return nil, IPPError{Status: statusErrorDocumentFormatError, Message: "unexpected document"}
+		}
+
+		if _, ok := HTTPRequestFromContext(ctx); !ok {
+			return nil, errors.New("missing http request")
+		}
+
+		resp := NewResponse(StatusOk, req.RequestId)
+		resp.JobAttributes = append(resp.JobAttributes, Attributes{
+			AttributeJobID: []Attribute{{Value: 42}},
+		})
+		return resp, nil
+	})
+	server.HandleFunc(OperationPausePrinter, func(ctx context.Context, req *Request, _ io.Writer) (*Response, error) {
+		return nil, IPPError{Status: StatusErrorNotAuthorized, Message: "not allowed"}
+	})
+
+	client := NewIPPClientWithAdapter("user", newTestHttpAdapter(t, server.ServeHTTP))
+
+	jobID, err := client.PrintJob(Document{
+		Document: bytes.NewBufferString("test document"),
+		Size:     13,
+		Name:     "test.txt",
+		MimeType: MimeTypeOctetStream,
+	}, "printer", map[string]any{})
+	assert.Nil(t, err)
+	assert.Equal(t, 42, jobID)
+
+	err = client.PausePrinter("printer")
+	assert.True(t, errors.Is(err, ErrNotAuthorized))
+	assert.Contains(t, err.Error(), "not allowed")
+
+	err = client.ResumePrinter("printer")
+	assert.True(t, errors.Is(err, ErrOperationNotSupported))
+}
+
+func TestServer_Validation(t *testing.T) {
+	server := NewServer()
+	server.HandleFunc(OperationGetPrinterAttributes, func(ctx context.Context, req *Request, _ io.Writer) (*Response, error) {
+		return nil, nil
+	})
+
+	tests := []struct {
+		name   string
+		modify func(req *Request)
+		status int16
+	}{
+		{"valid", func(req *Request) {}, StatusOk},
+		{"version", func(req *Request) { req.ProtocolVersionMajor = 3 }, StatusErrorVersionNotSupported},
+		{"request id", func(req *Request) { req.RequestId = 0 }, StatusErrorBadRequest},
+		{"charset", func(req *Request) { req.OperationAttributes[AttributeCharset] = "iso-8859-1" }, StatusErrorCharset},
+		{"missing printer uri", func(req *Request) { delete(req.OperationAttributes, AttributePrinterURI) }, StatusErrorBadRequest},
+		{"uri scheme", func(req *Request) { req.OperationAttributes[AttributePrinterURI] = "lpd://localhost/printer" }, StatusErrorUriScheme},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := NewRequest(OperationGetPrinterAttributes, 7)
+			req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/printer"
+			test.modify(req)
+
+			payload, err := req.Encode()
+			assert.Nil(t, err)
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+			httpReq.Header.Set("Content-Type", ContentTypeIPP)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httpReq)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			resp, err := NewResponseDecoder(recorder.Body).Decode(nil)
+			assert.Nil(t, err)
+			assert.Equal(t, test.status, resp.StatusCode)
+			assert.Equal(t, req.RequestId, resp.RequestId)
+		})
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
@@ -0,0 +1,662 @@
+package ipp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+the text format lists the header fields followed by the attribute groups. every attribute is written with its tag
+and its values, collections are written as indented blocks:
+
+	version 2.0
+	operation Print-Job
+	request-id 1
+	operation-attributes-tag
+	    attributes-charset (charset) = utf-8
+	    attributes-natural-language (naturalLanguage) = en-US
+	    printer-uri (uri) = ipp://localhost/printers/test
+	job-attributes-tag
+	    media-col (collection) = {
+	        media-type (keyword) = stationery
+	    }
+	    sides (keyword) = two-sided-long-edge
+	end-of-attributes-tag
+
+blank lines and lines starting with # are ignored when the text is parsed
+*/
+
+// MarshalText formats the request in a human-readable text format
+func (r *Request) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "version %d.%d\n", r.ProtocolVersionMajor, r.ProtocolVersionMinor)
+	fmt.Fprintf(&buf, "operation %s\n", OperationName(r.Operation))
+	fmt.Fprintf(&buf, "request-id %d\n", r.RequestId)
+
+	groups := []struct {
+		tag        int8
+		attributes map[string]any
+	}{
+		{TagDelimiterOperation, r.OperationAttributes},
+		{TagDelimiterJob, r.JobAttributes},
+		{TagDelimiterPrinter, r.PrinterAttributes},
+	}
+
+	for _, group := range groups {
+		if group.tag != TagDelimiterOperation && len(group.attributes) == 0 {
+			continue
+		}
+
+		attributes := make(Attributes, len(group.attributes))
+		for name, value := range group.attributes {
+			attributes[name] = newAttribute(name, value)
+		}
+		if err := writeTextGroup(&buf, group.tag, attributes); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteString(TagTypeName(TagDelimiterEnd) + "\n")
+	return buf.Bytes(), nil
+}
+
+// MarshalText formats the response in a human-readable text format
+func (r *Response) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "version %d.%d\n", r.ProtocolVersionMajor, r.ProtocolVersionMinor)
+	fmt.Fprintf(&buf, "status-code %s\n", StatusName(r.StatusCode))
+	fmt.Fprintf(&buf, "request-id %d\n", r.RequestId)
+
+	if err := writeTextGroup(&buf, TagDelimiterOperation, r.OperationAttributes); err != nil {
+		return nil, err
+	}
+	if len(r.UnsupportedAttributes) > 0 {
+		if err := writeTextGroup(&buf, TagDelimiterUnsupported, r.UnsupportedAttributes); err != nil {
+			return nil, err
+		}
+	}
+	for _, attributes := range r.PrinterAttributes {
+		if err := writeTextGroup(&buf, TagDelimiterPrinter, attributes); err != nil {
+			return nil, err
+		}
+	}
+	for _, attributes := range r.JobAttributes {
+		if err := writeTextGroup(&buf, TagDelimiterJob, attributes); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteString(TagTypeName(TagDelimiterEnd) + "\n")
+	return buf.Bytes(), nil
+}
+
+// UnmarshalText parses a request in the text format written by MarshalText. the attribute values are stored as
+// []Attribute to keep their tags
+func (r *Request) UnmarshalText(text []byte) error {
+	p := newTextParser(text)
+
+	header, err := p.parseHeader()
+	if err != nil {
+		return err
+	}
+
+	r.ProtocolVersionMajor, r.ProtocolVersionMinor = header.versionMajor, header.versionMinor
+	r.RequestId = header.requestID
+	if r.Operation, err = parseTextCode(header.fields["operation"], OperationByName); err != nil {
+		return fmt.Errorf("invalid operation: %w", err)
+	}
+
+	r.OperationAttributes = make(map[string]any)
+	r.JobAttributes = make(map[string]any)
+	r.PrinterAttributes = make(map[string]any)
+	r.File = nil
+	r.FileSize = -1
+
+	return p.parseGroups(func(tag int8, attributes Attributes) error {
+		var group map[string]any
+		switch tag {
+		case TagDelimiterOperation:
+			group = r.OperationAttributes
+		case TagDelimiterJob:
+			group = r.JobAttributes
+		case TagDelimiterPrinter:
+			group = r.PrinterAttributes
+		default:
+			return fmt.Errorf("group %s is not supported in requests", TagTypeName(tag))
+		}
+
+		for name, values := range attributes {
+			group[name] = values
+		}
+		return nil
+	})
+}
+
+// UnmarshalText parses a response in the text format written by MarshalText
+func (r *Response) UnmarshalText(text []byte) error {
+	p := newTextParser(text)
+
+	header, err := p.parseHeader()
+	if err != nil {
+		return err
+	}
+
+	r.ProtocolVersionMajor, r.ProtocolVersionMinor = header.versionMajor, header.versionMinor
+	r.RequestId = header.requestID
+	if r.StatusCode, err = parseTextCode(header.fields["status-code"], StatusByName); err != nil {
+		return fmt.Errorf("invalid status-code: %w", err)
+	}
+
+	r.OperationAttributes = make(Attributes)
+	r.UnsupportedAttributes = make(Attributes)
+	r.PrinterAttributes = make([]Attributes, 0)
+	r.JobAttributes = make([]Attributes, 0)
+
+	return p.parseGroups(func(tag int8, attributes Attributes) error {
+		switch tag {
+		case TagDelimiterOperation:
+			r.OperationAttributes = attributes
+		case TagDelimiterUnsupported:
+			r.UnsupportedAttributes = attributes
+		case TagDelimiterPrinter:
+			r.PrinterAttributes = append(r.PrinterAttributes, attributes)
+		case TagDelimiterJob:
+			r.JobAttributes = append(r.JobAttributes, attributes)
+		default:
+			return fmt.Errorf("group %s is not supported in responses", TagTypeName(tag))
+		}
+		return nil
+	})
+}
+
+func writeTextGroup(buf *bytes.Buffer, tag int8, attributes Attributes) error {
+	buf.WriteString(TagTypeName(tag) + "\n")
+	return writeTextAttributes(buf, attributes, 1)
+}
+
+// writeTextAttributes writes the attributes sorted by name, charset and natural language are written first
+func writeTextAttributes(buf *bytes.Buffer, attributes Attributes, depth int) error {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		if name != AttributeCharset && name != AttributeNaturalLanguage {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range []string{AttributeNaturalLanguage, AttributeCharset} {
+		if _, ok := attributes[name]; ok {
+			names = append([]string{name}, names...)
+		}
+	}
+
+	for _, name := range names {
+		if err := writeTextAttribute(buf, name, attributes[name], depth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTextAttribute(buf *bytes.Buffer, name string, attrs []Attribute, depth int) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	indent := strings.Repeat("    ", depth)
+	tag := attrs[0].Tag
+	if tag == 0 {
+		tag = AttributeTagMapping[name]
+	}
+
+	typeName := TagTypeName(tag)
+	if len(attrs) > 1 {
+		typeName = "1setOf " + typeName
+	}
+
+	if tag >= TagUnsupported && tag < TagInteger {
+		fmt.Fprintf(buf, "%s%s (%s)\n", indent, name, typeName)
+		return nil
+	}
+
+	fmt.Fprintf(buf, "%s%s (%s) = ", indent, name, typeName)
+
+	if tag == TagBeginCollection {
+		for i, attr := range attrs {
+			col, ok := attr.Value.(Collection)
+			if !ok {
+				return fmt.Errorf("collection attribute %s has unsupported type %T", name, attr.Value)
+			}
+
+			if i > 0 {
+				buf.WriteString(indent + "}, ")
+			}
+			buf.WriteString("{\n")
+			if err := writeTextAttributes(buf, Attributes(col), depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "}\n")
+		return nil
+	}
+
+	values := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		value, err := formatTextValue(attr.Value)
+		if err != nil {
+			return fmt.Errorf("attribute %s: %w", name, err)
+		}
+		values = append(values, value)
+	}
+
+	buf.WriteString(strings.Join(values, ",") + "\n")
+	return nil
+}
+
+func formatTextValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		if needsTextQuotes(v) {
+			return strconv.Quote(v), nil
+		}
+		return v, nil
+	case int, int8, int16, int32, int64, bool:
+		return fmt.Sprint(v), nil
+	case []int32:
+		if len(v) == 2 {
+			return fmt.Sprintf("%d-%d", v[0], v[1]), nil
+		}
+	case Resolution:
+		unit := "dpi"
+		if v.Depth == 4 {
+			unit = "dpcm"
+		}
+		return fmt.Sprintf("%dx%d%s", v.Height, v.Width, unit), nil
+	case []int:
+		if len(v) == 11 {
+			return formatTextDate(v), nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported value type %T", value)
+}
+
+func needsTextQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, ",\"{}\\") {
+		return true
+	}
+
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatTextDate formats a RFC 2579 date as ISO 8601 date
+func formatTextDate(v []int) string {
+	b := make([]byte, len(v))
+	for i, value := range v {
+		b[i] = byte(value)
+	}
+
+	year := int(b[0])<<8 | int(b[1])
+	return fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:%02d.%d%c%02d:%02d", year, b[2], b[3], b[4], b[5], b[6], b[7], b[8], b[9], b[10])
+}
+
+var textDatePattern = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})T(\d{2}):(\d{2}):(\d{2})\.(\d)([+-])(\d{2}):(\d{2})$`)
+
+// parseTextDate parses an ISO 8601 date into the RFC 2579 date as returned by the AttributeDecoder
+func parseTextDate(s string) ([]int, error) {
+	m := textDatePattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid date %s", s)
+	}
+
+	sign := m[8][0]
+	n := make([]int, 0, 10)
+	for _, part := range append(m[1:8:8], m[9:]...) {
+		i, _ := strconv.Atoi(part)
+		n = append(n, i)
+	}
+
+	// the decoder returns the bytes as signed values
+	b := []byte{byte(n[0] >> 8), byte(n[0]), byte(n[1]), byte(n[2]), byte(n[3]), byte(n[4]), byte(n[5]), byte(n[6]), sign, byte(n[7]), byte(n[8])}
+	v := make([]int, len(b))
+	for i, value := range b {
+		v[i] = int(int8(value))
+	}
+
+	return v, nil
+}
+
+var (
+	textAttributePattern  = regexp.MustCompile(`^(\S+) \((?:1setOf )?([A-Za-z-]+)\)(?: = (.*))?$`)
+	textRangePattern      = regexp.MustCompile(`^(-?\d+)-(-?\d+)$`)
+	textResolutionPattern = regexp.MustCompile(`^(\d+)x(\d+)(dpi|dpcm)$`)
+)
+
+type textHeader struct {
+	versionMajor int8
+	versionMinor int8
+	requestID    int32
+	fields       map[string]string
+}
+
+type textParser struct {
+	scanner *bufio.Scanner
+	line    int
+	peeked  *string
+}
+
+func newTextParser(text []byte) *textParser {
+	return &textParser{scanner: bufio.NewScanner(bytes.NewReader(text))}
+}
+
+// next returns the next trimmed line which is not empty or a comment
+func (p *textParser) next() (string, bool) {
+	if p.peeked != nil {
+		line := *p.peeked
+		p.peeked = nil
+		return line, true
+	}
+
+	for p.scanner.Scan() {
+		p.line++
+		line := strings.TrimSpace(p.scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line, true
+	}
+
+	return "", false
+}
+
+func (p *textParser) unread(line string) {
+	p.peeked = &line
+}
+
+func (p *textParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *textParser) parseHeader() (*textHeader, error) {
+	header := &textHeader{
+		versionMajor: ProtocolVersionMajor,
+		versionMinor: ProtocolVersionMinor,
+		fields:       make(map[string]string),
+	}
+
+	for {
+		line, ok := p.next()
+		if !ok {
+			return nil, p.errorf("missing attribute groups")
+		}
+
+		if tag, ok := TagByName(line); ok && tag < TagUnsupported {
+			p.unread(line)
+			return header, nil
+		}
+
+		key, value, found := strings.Cut(line, " ")
+		if !found {
+			return nil, p.errorf("invalid header line %q", line)
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "version":
+			major, minor, err := parseVersion(value)
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			header.versionMajor, header.versionMinor = major, minor
+		case "request-id":
+			id, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return nil, p.errorf("invalid request-id %s", value)
+			}
+			header.requestID = int32(id)
+		case "operation", "status-code":
+			header.fields[key] = value
+		default:
+			return nil, p.errorf("unknown header %s", key)
+		}
+	}
+}
+
+// parseGroups parses all attribute groups until end-of-attributes-tag and passes them to add
+func (p *textParser) parseGroups(add func(tag int8, attributes Attributes) error) error {
+	for {
+		line, ok := p.next()
+		if !ok {
+			return p.errorf("missing %s", TagTypeName(TagDelimiterEnd))
+		}
+
+		tag, ok := TagByName(line)
+		if !ok || tag >= TagUnsupported {
+			return p.errorf("expected attribute group, got %q", line)
+		}
+		if tag == TagDelimiterEnd {
+			return nil
+		}
+
+		attributes, err := p.parseAttributes(false)
+		if err != nil {
+			return err
+		}
+		if err := add(tag, attributes); err != nil {
+			return p.errorf("%v", err)
+		}
+	}
+}
+
+// parseAttributes parses attribute lines until the next group or the end of a collection
+func (p *textParser) parseAttributes(collection bool) (Attributes, error) {
+	attributes := make(Attributes)
+
+	for {
+		line, ok := p.next()
+		if !ok {
+			if collection {
+				return nil, p.errorf("unterminated collection")
+			}
+			return attributes, nil
+		}
+
+		if collection && strings.HasPrefix(line, "}") {
+			p.unread(line)
+			return attributes, nil
+		}
+		if tag, ok := TagByName(line); ok && tag < TagUnsupported {
+			if collection {
+				return nil, p.errorf("unterminated collection")
+			}
+			p.unread(line)
+			return attributes, nil
+		}
+
+		name, attrs, err := p.parseAttribute(line)
+		if err != nil {
+			return nil, err
+		}
+		attributes[name] = attrs
+	}
+}
+
+func (p *textParser) parseAttribute(line string) (string, []Attribute, error) {
+	m := textAttributePattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", nil, p.errorf("invalid attribute %q", line)
+	}
+	name, typeName, value := m[1], m[2], m[3]
+
+	tag, ok := TagByName(typeName)
+	if !ok || tag < TagUnsupported {
+		return "", nil, p.errorf("unknown type %s", typeName)
+	}
+
+	if tag < TagInteger {
+		return name, []Attribute{{Tag: tag, Name: name}}, nil
+	}
+
+	if tag == TagBeginCollection {
+		attrs, err := p.parseCollections(name, value)
+		return name, attrs, err
+	}
+
+	values, err := splitTextValues(value)
+	if err != nil {
+		return "", nil, p.errorf("attribute %s: %v", name, err)
+	}
+
+	attrs := make([]Attribute, 0, len(values))
+	for _, v := range values {
+		parsed, err := parseTextValue(tag, v)
+		if err != nil {
+			return "", nil, p.errorf("attribute %s: %v", name, err)
+		}
+		attrs = append(attrs, Attribute{Tag: tag, Name: name, Value: parsed})
+	}
+
+	return name, attrs, nil
+}
+
+// parseCollections parses one or more collection values, each value is a block ending with } or }, {
+func (p *textParser) parseCollections(name, value string) ([]Attribute, error) {
+	if value != "{" {
+		return nil, p.errorf("collection %s must start with {", name)
+	}
+
+	attrs := make([]Attribute, 0, 1)
+	for {
+		members, err := p.parseAttributes(true)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, Attribute{Tag: TagBeginCollection, Name: name, Value: Collection(members)})
+
+		line, _ := p.next()
+		switch strings.ReplaceAll(line, " ", "") {
+		case "}":
+			return attrs, nil
+		case "},{":
+			continue
+		default:
+			return nil, p.errorf("invalid end of collection %s: %q", name, line)
+		}
+	}
+}
+
+// splitTextValues splits comma separated values, quoted values may contain commas
+func splitTextValues(s string) ([]string, error) {
+	values := make([]string, 0, 1)
+
+	for {
+		var value string
+		if strings.HasPrefix(s, "\"") {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value %s", s)
+			}
+			value = quoted
+			s = s[len(quoted):]
+		} else {
+			end := strings.Index(s, ",")
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		values = append(values, value)
+
+		if s == "" {
+			return values, nil
+		}
+		if !strings.HasPrefix(s, ",") {
+			return nil, fmt.Errorf("expected , after value %s", value)
+		}
+		s = s[1:]
+	}
+}
+
+func parseTextValue(tag int8, value string) (any, error) {
+	if strings.HasPrefix(value, "\"") {
+		if tag == TagInteger || tag == TagEnum || tag == TagBoolean || tag == TagRange || tag == TagResolution || tag == TagDate {
+			return nil, fmt.Errorf("quoted value %s for type %s", value, TagTypeName(tag))
+		}
+		return strconv.Unquote(value)
+	}
+
+	switch tag {
+	case TagInteger, TagEnum:
+		return strconv.Atoi(value)
+	case TagBoolean:
+		return strconv.ParseBool(value)
+	case TagRange:
+		m := textRangePattern.FindStringSubmatch(value)
+		if m == nil {
+			return nil, fmt.Errorf("invalid range %s", value)
+		}
+		lower, _ := strconv.ParseInt(m[1], 10, 32)
+		upper, _ := strconv.ParseInt(m[2], 10, 32)
+		return []int32{int32(lower), int32(upper)}, nil
+	case TagResolution:
+		m := textResolutionPattern.FindStringSubmatch(value)
+		if m == nil {
+			return nil, fmt.Errorf("invalid resolution %s", value)
+		}
+		x, _ := strconv.ParseInt(m[1], 10, 32)
+		y, _ := strconv.ParseInt(m[2], 10, 32)
+		units := int8(3)
+		if m[3] == "dpcm" {
+			units = 4
+		}
+		return Resolution{Height: int32(x), Width: int32(y), Depth: units}, nil
+	case TagDate:
+		return parseTextDate(value)
+	case TagTextLang, TagNameLang:
+		return nil, fmt.Errorf("values of type %s are not supported", TagTypeName(tag))
+	}
+
+	return value, nil
+}
+
+// parseVersion parses a protocol version like 2.0
+func parseVersion(s string) (int8, int8, error) {
+	major, minor, _ := strings.Cut(s, ".")
+	ma, err1 := strconv.ParseInt(major, 10, 8)
+	mi, err2 := strconv.ParseInt(minor, 10, 8)
+	if err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("invalid version %s", s)
+	}
+	return int8(ma), int8(mi), nil
+}
+
+// parseTextCode parses an operation or status name or a hex value
+func parseTextCode(s string, byName func(string) (int16, bool)) (int16, error) {
+	if s == "" {
+		return 0, fmt.Errorf("missing value")
+	}
+	if code, ok := byName(s); ok {
+		return code, nil
+	}
+
+	code, err := strconv.ParseInt(s, 0, 32)
+	if err != nil || code > 0xffff {
+		return 0, fmt.Errorf("unknown value %s", s)
+	}
+	return int16(code), nil
+}
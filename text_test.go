@@ -0,0 +1,143 @@
+package ipp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest_MarshalText(t *testing.T) {
+	req := NewRequest(OperationPrintJob, 7)
+	req.OperationAttributes[AttributePrinterURI] = "ipp://localhost/printers/test"
+	req.OperationAttributes[AttributeJobName] = "report, final"
+	req.JobAttributes[AttributeCopies] = 2
+	req.JobAttributes[AttributeSides] = "two-sided-long-edge"
+
+	text, err := req.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, `version 2.0
+operation Print-Job
+request-id 7
+operation-attributes-tag
+    job-name (nameWithoutLanguage) = "report, final"
+    printer-uri (uri) = ipp://localhost/printers/test
+job-attributes-tag
+    copies (integer) = 2
+    sides (keyword) = two-sided-long-edge
+end-of-attributes-tag
+`, string(text))
+
+	var parsed Request
+	assert.NoError(t, parsed.UnmarshalText(text))
+	assert.Equal(t, req.Operation, parsed.Operation)
+	assert.Equal(t, req.RequestId, parsed.RequestId)
+
+	assert.Equal(t, decodeTestRequest(t, req), decodeTestRequest(t, &parsed))
+}
+
+func TestRequest_UnmarshalText(t *testing.T) {
+	text := `# get the media of a printer
+version 1.1
+operation 0x000b
+request-id 3
+
+operation-attributes-tag
+    attributes-charset (charset) = utf-8
+    attributes-natural-language (naturalLanguage) = en
+    printer-uri (uri) = ipp://localhost/printers/test
+    requested-attributes (1setOf keyword) = media-supported,media-col-ready
+job-attributes-tag
+    media-col (collection) = {
+        media-size (collection) = {
+            x-dimension (integer) = 21000
+            y-dimension (integer) = 29700
+        }
+    }, {
+        media-type (keyword) = photographic
+    }
+    printer-resolution (resolution) = 600x300dpi
+    page-ranges (rangeOfInteger) = 1-5
+    job-hold-until (no-value)
+end-of-attributes-tag
+`
+
+	var req Request
+	assert.NoError(t, req.UnmarshalText([]byte(text)))
+	assert.Equal(t, int8(1), req.ProtocolVersionMajor)
+	assert.Equal(t, int8(1), req.ProtocolVersionMinor)
+	assert.Equal(t, OperationGetPrinterAttributes, req.Operation)
+	assert.Equal(t, int32(3), req.RequestId)
+	assert.Equal(t, []Attribute{
+		{Tag: TagKeyword, Name: "requested-attributes", Value: "media-supported"},
+		{Tag: TagKeyword, Name: "requested-attributes", Value: "media-col-ready"},
+	}, req.OperationAttributes["requested-attributes"])
+
+	mediaCol := req.JobAttributes["media-col"].([]Attribute)
+	assert.Len(t, mediaCol, 2)
+	assert.Equal(t, Collection{
+		"media-size": []Attribute{{Tag: TagBeginCollection, Name: "media-size", Value: Collection{
+			"x-dimension": []Attribute{{Tag: TagInteger, Name: "x-dimension", Value: 21000}},
+			"y-dimension": []Attribute{{Tag: TagInteger, Name: "y-dimension", Value: 29700}},
+		}}},
+	}, mediaCol[0].Value)
+	assert.Equal(t, []Attribute{{Tag: TagResolution, Name: "printer-resolution", Value: Resolution{Height: 600, Width: 300, Depth: 3}}}, req.JobAttributes["printer-resolution"])
+	assert.Equal(t, []Attribute{{Tag: TagRange, Name: "page-ranges", Value: []int32{1, 5}}}, req.JobAttributes["page-ranges"])
+	assert.Equal(t, []Attribute{{Tag: TagNoValue, Name: "job-hold-until"}}, req.JobAttributes["job-hold-until"])
+
+	encoded, err := req.Encode()
+	assert.NoError(t, err)
+	decoded, err := NewRequestDecoder(bytes.NewReader(encoded)).Decode(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ipp://localhost/printers/test", decoded.OperationAttributes[AttributePrinterURI])
+
+	for _, invalid := range []string{
+		"operation Print-Job\n",
+		"operation Unknown-Operation\noperation-attributes-tag\nend-of-attributes-tag\n",
+		"operation Print-Job\noperation-attributes-tag\n    copies (integer) = two\nend-of-attributes-tag\n",
+		"operation Print-Job\njob-attributes-tag\n    media-col (collection) = {\nend-of-attributes-tag\n",
+		"operation Print-Job\noperation-attributes-tag\n",
+	} {
+		assert.Error(t, new(Request).UnmarshalText([]byte(invalid)), invalid)
+	}
+}
+
+func TestResponse_MarshalText(t *testing.T) {
+	resp := NewResponse(StatusOk, 5)
+	resp.PrinterAttributes = append(resp.PrinterAttributes, Attributes{
+		AttributePrinterName: []Attribute{{Tag: TagName, Name: AttributePrinterName, Value: "test"}},
+		AttributeMediaColReady: []Attribute{{Tag: TagBeginCollection, Name: AttributeMediaColReady, Value: Collection{
+			"media-type": []Attribute{{Tag: TagKeyword, Name: "media-type", Value: "stationery"}},
+		}}},
+		"printer-current-time": []Attribute{{Tag: TagDate, Name: "printer-current-time", Value: []int{7, -22, 10, 19, 12, 30, 5, 0, '+', 2, 0}}},
+	})
+
+	text, err := resp.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, `version 2.0
+status-code successful-ok
+request-id 5
+operation-attributes-tag
+printer-attributes-tag
+    media-col-ready (collection) = {
+        media-type (keyword) = stationery
+    }
+    printer-current-time (dateTime) = 2026-10-19T12:30:05.0+02:00
+    printer-name (nameWithoutLanguage) = test
+end-of-attributes-tag
+`, string(text))
+
+	var parsed Response
+	assert.NoError(t, parsed.UnmarshalText(text))
+	assert.Equal(t, resp.StatusCode, parsed.StatusCode)
+	assert.Equal(t, resp.PrinterAttributes, parsed.PrinterAttributes)
+}
+
+// decodeTestRequest encodes and decodes a request, the result can be compared regardless of the attribute order
+func decodeTestRequest(t *testing.T, req *Request) *Request {
+	encoded, err := req.Encode()
+	assert.NoError(t, err)
+	decoded, err := NewRequestDecoder(bytes.NewReader(encoded)).Decode(nil)
+	assert.NoError(t, err)
+	return decoded
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sort"
+	"strings"
 )
 
 // ParseControlFile reads and decodes a cups control file into a response
@@ -26,3 +28,32 @@ func ParseControlFile(jobID int, spoolDirectory string) (*Response, error) {
 
 	return NewResponseDecoder(controlFile).Decode(nil)
 }
+
+func lastPathElement(uri any) string {
+	s, _ := uri.(string)
+	return s[strings.LastIndex(s, "/")+1:]
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// localHttpUri builds http uris for adapters which are not connected to a real server
+func localHttpUri(namespace string, object interface{}) string {
+	uri := "http://localhost:631"
+
+	if namespace != "" {
+		uri = fmt.Sprintf("%s/%s", uri, namespace)
+	}
+
+	if object != nil {
+		uri = fmt.Sprintf("%s/%v", uri, object)
+	}
+
+	return uri
+}